@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/labstack/echo/v4"
@@ -14,6 +17,7 @@ import (
 	//"github.com/pressly/goose/v3"
 
 	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/exchange"
 	"WorkRESTAPI/internal/server"
 )
 
@@ -45,6 +49,7 @@ func main() {
 	e := echo.New()
 
 	// Middleware
+	e.Use(middleware.RequestID()) // X-Request-ID, kept in the audit log
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 
@@ -62,9 +67,33 @@ func main() {
 	}
 	defer db.Close()
 
-	// Register all routes from internal
+	// Exchange rates used to compute aggregates in the base currency
 	queries := internals.New(db)
-	server.RegisterRoutes(e, queries)
+	rates := exchange.NewService(db, queries, getEnvWithDefault("BASE_CURRENCY", "PLN"))
+
+	// Load exchange rates from a local CSV/JSON file if configured
+	if ratesFile := os.Getenv("EXCHANGE_RATES_FILE"); ratesFile != "" {
+		loaded, err := exchange.LoadFile(ratesFile)
+		if err != nil {
+			log.Fatalf("Unable to read exchange rates file: %v", err)
+		}
+		imported, err := rates.Import(context.Background(), loaded)
+		if err != nil {
+			log.Fatalf("Unable to import exchange rates: %v", err)
+		}
+		log.Printf("Imported %d exchange rates from %s", imported, ratesFile)
+	}
+
+	// Register all routes from internal
+	server.RegisterRoutes(e, db, queries, rates)
+
+	// Soft-deleted employees and sales are purged once they are older than the retention period
+	server.SetAdminToken(os.Getenv("ADMIN_TOKEN"))
+	retentionDays, err := strconv.Atoi(getEnvWithDefault("SOFT_DELETE_RETENTION_DAYS", "30"))
+	if err != nil || retentionDays < 1 {
+		log.Fatalf("SOFT_DELETE_RETENTION_DAYS must be a positive number of days")
+	}
+	server.StartPurgeJob(context.Background(), time.Duration(retentionDays)*24*time.Hour, 24*time.Hour)
 
 	// Start server
 	serverPort := os.Getenv("PORT")
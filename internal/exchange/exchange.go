@@ -0,0 +1,150 @@
+package exchange
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRateNotFound is returned when no rate is known for a currency pair on a date
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Rate is the value of one unit of From expressed in To, valid from Date
+type Rate struct {
+	From string    `json:"from_currency"`
+	To   string    `json:"to_currency"`
+	Date time.Time `json:"rate_date"`
+	Rate float64   `json:"rate"`
+}
+
+// Validate normalizes the currency codes and checks that the rate can be stored
+func (r *Rate) Validate() error {
+	r.From = strings.ToUpper(strings.TrimSpace(r.From))
+	r.To = strings.ToUpper(strings.TrimSpace(r.To))
+	if !currencyCodeRegex.MatchString(r.From) || !currencyCodeRegex.MatchString(r.To) {
+		return fmt.Errorf("invalid currency pair %q/%q", r.From, r.To)
+	}
+	if r.From == r.To {
+		return fmt.Errorf("currency pair %s/%s must use two different currencies", r.From, r.To)
+	}
+	if r.Date.IsZero() {
+		return fmt.Errorf("missing rate date for %s/%s", r.From, r.To)
+	}
+	if r.Rate <= 0 {
+		return fmt.Errorf("rate for %s/%s must be greater than 0", r.From, r.To)
+	}
+	return nil
+}
+
+// Service converts amounts between currencies using the exchange_rates table
+type Service struct {
+	db      *sql.DB
+	queries *internals.Queries
+	base    string
+}
+
+func NewService(db *sql.DB, queries *internals.Queries, base string) *Service {
+	return &Service{db: db, queries: queries, base: strings.ToUpper(base)}
+}
+
+// Base returns the currency every aggregate is computed in
+func (s *Service) Base() string {
+	return s.base
+}
+
+// Rate returns the latest rate from -> to known on the given date.
+// When only the inverse pair is stored, its reciprocal is returned.
+func (s *Service) Rate(ctx context.Context, from, to string, date time.Time) (Rate, error) {
+	if from == to {
+		return Rate{From: from, To: to, Date: date, Rate: 1}, nil
+	}
+
+	row, err := s.queries.GetExchangeRateOnDate(ctx, internals.GetExchangeRateOnDateParams{
+		FromCurrency: from,
+		ToCurrency:   to,
+		RateDate:     date,
+	})
+	if err == nil {
+		return fromRow(row)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Rate{}, err
+	}
+
+	row, err = s.queries.GetExchangeRateOnDate(ctx, internals.GetExchangeRateOnDateParams{
+		FromCurrency: to,
+		ToCurrency:   from,
+		RateDate:     date,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return Rate{}, fmt.Errorf("%w: %s/%s on %s", ErrRateNotFound, from, to, date.Format("2006-01-02"))
+	}
+	if err != nil {
+		return Rate{}, err
+	}
+	inverse, err := fromRow(row)
+	if err != nil {
+		return Rate{}, err
+	}
+	return Rate{From: from, To: to, Date: inverse.Date, Rate: 1 / inverse.Rate}, nil
+}
+
+// Convert converts an amount from one currency to another as of the given date,
+// rounded to the grosz
+func (s *Service) Convert(ctx context.Context, amount money.Amount, from, to string, date time.Time) (money.Amount, Rate, error) {
+	rate, err := s.Rate(ctx, from, to, date)
+	if err != nil {
+		return 0, Rate{}, err
+	}
+	return amount.Mul(rate.Rate), rate, nil
+}
+
+// Import stores the given rates, replacing rates already stored for the same pair and date.
+// The rates are stored in one transaction, on error none of them is.
+func (s *Service) Import(ctx context.Context, rates []Rate) (int, error) {
+	for i := range rates {
+		if err := rates[i].Validate(); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	q := s.queries.WithTx(tx)
+	for _, rate := range rates {
+		_, err := q.UpsertExchangeRate(ctx, internals.UpsertExchangeRateParams{
+			FromCurrency: rate.From,
+			ToCurrency:   rate.To,
+			RateDate:     rate.Date,
+			Rate:         strconv.FormatFloat(rate.Rate, 'f', -1, 64),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("%s/%s on %s: %w", rate.From, rate.To, rate.Date.Format("2006-01-02"), err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+func fromRow(row internals.ExchangeRate) (Rate, error) {
+	rate, err := strconv.ParseFloat(row.Rate, 64)
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate %q for %s/%s: %w", row.Rate, row.FromCurrency, row.ToCurrency, err)
+	}
+	return Rate{From: row.FromCurrency, To: row.ToCurrency, Date: row.RateDate, Rate: rate}, nil
+}
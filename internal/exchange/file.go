@@ -0,0 +1,134 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rates files are either CSV with a header row:
+//
+//	from_currency,to_currency,rate_date,rate
+//	EUR,PLN,2025-01-31,4.2718
+//
+// or a JSON array:
+//
+//	[{"from_currency": "EUR", "to_currency": "PLN", "rate_date": "2025-01-31", "rate": 4.2718}]
+
+var csvColumns = []string{"from_currency", "to_currency", "rate_date", "rate"}
+
+// LoadFile reads rates from a local .csv or .json file
+func LoadFile(path string) ([]Rate, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(f)
+	case ".json":
+		return ParseJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported rates file %q, expected .csv or .json", path)
+	}
+}
+
+// ParseCSV reads rates from CSV with a from_currency,to_currency,rate_date,rate header
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty rates file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range csvColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("missing %q column in rates header", column)
+		}
+	}
+
+	var rates []Rate
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		date, err := parseRateDate(record[index["rate_date"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(record[index["rate"]]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[index["rate"]])
+		}
+		rate := Rate{
+			From: record[index["from_currency"]],
+			To:   record[index["to_currency"]],
+			Date: date,
+			Rate: value,
+		}
+		if err := rate.Validate(); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+// ParseJSON reads rates from a JSON array of objects
+func ParseJSON(r io.Reader) ([]Rate, error) {
+	var records []struct {
+		From     string  `json:"from_currency"`
+		To       string  `json:"to_currency"`
+		RateDate string  `json:"rate_date"`
+		Rate     float64 `json:"rate"`
+	}
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("invalid rates JSON: %w", err)
+	}
+
+	rates := make([]Rate, 0, len(records))
+	for i, record := range records {
+		date, err := parseRateDate(record.RateDate)
+		if err != nil {
+			return nil, fmt.Errorf("rate %d: %w", i+1, err)
+		}
+		rate := Rate{From: record.From, To: record.To, Date: date, Rate: record.Rate}
+		if err := rate.Validate(); err != nil {
+			return nil, fmt.Errorf("rate %d: %w", i+1, err)
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func parseRateDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, format := range []string{"2006-01-02", time.RFC3339} {
+		if date, err := time.Parse(format, value); err == nil {
+			return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid rate date %q, expected YYYY-MM-DD", value)
+}
@@ -6,26 +6,217 @@ package internals
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"WorkRESTAPI/internal/money"
 )
 
+type AuditLog struct {
+	ID        int32
+	Entity    string
+	EntityID  int32
+	Action    string
+	Actor     string
+	RequestID sql.NullString
+	Before    json.RawMessage
+	After     json.RawMessage
+	CreatedAt sql.NullTime
+}
+
+type Category struct {
+	ID          int32
+	Name        string
+	Description sql.NullString
+	TaxRate     string
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+}
+
+type CommissionAssignment struct {
+	EmployeeID int32
+	PlanID     int32
+	ValidFrom  time.Time
+	CreatedAt  sql.NullTime
+}
+
+type CommissionCategoryRate struct {
+	PlanID   int32
+	Category string
+	Rate     string
+}
+
+type CommissionPlan struct {
+	ID              int32
+	Name            string
+	Description     sql.NullString
+	Currency        string
+	BaseRate        string
+	AcceleratorRate string
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+}
+
+type CommissionTier struct {
+	ID        int32
+	PlanID    int32
+	Threshold money.Amount
+	Rate      string
+}
+
+type Currency struct {
+	Code       string
+	Name       string
+	MinorUnits int16
+	Symbol     string
+	Enabled    bool
+	CreatedAt  sql.NullTime
+	UpdatedAt  sql.NullTime
+}
+
+type Customer struct {
+	ID        int32
+	Name      string
+	Company   sql.NullString
+	TaxID     sql.NullString
+	Email     sql.NullString
+	Address   sql.NullString
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
+
+type Department struct {
+	ID          int32
+	Name        string
+	Description sql.NullString
+	ParentID    sql.NullInt32
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+}
+
 type Employee struct {
+	ID              int32
+	Name            string
+	Surname         string
+	Email           string
+	DepartmentID    sql.NullInt32
+	ManagerID       sql.NullInt32
+	HireDate        time.Time
+	TerminationDate sql.NullTime
+	JobTitle        sql.NullString
+	Status          string
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	DeletedAt       sql.NullTime
+}
+
+type ExchangeRate struct {
+	ID           int32
+	FromCurrency string
+	ToCurrency   string
+	RateDate     time.Time
+	Rate         string
+	CreatedAt    sql.NullTime
+	UpdatedAt    sql.NullTime
+}
+
+type Offboarding struct {
+	ID              int32
+	EmployeeID      int32
+	EmployeeName    string
+	EmployeeSurname string
+	EmployeeEmail   string
+	ReassignedTo    sql.NullInt32
+	FromDate        sql.NullTime
+	ToDate          sql.NullTime
+	Category        sql.NullString
+	CustomerID      sql.NullInt32
+	SalesReassigned int32
+	Action          string
+	PerformedBy     string
+	CreatedAt       sql.NullTime
+}
+
+type Product struct {
 	ID        int32
+	Sku       string
 	Name      string
-	Surname   string
-	Email     string
+	Category  string
+	Price     money.Amount
+	Currency  string
+	Active    bool
+	TaxRate   sql.NullString
 	CreatedAt sql.NullTime
 	UpdatedAt sql.NullTime
 }
 
+type Promotion struct {
+	ID              int32
+	Code            string
+	Description     sql.NullString
+	DiscountPercent string
+	DiscountAmount  money.Amount
+	Currency        sql.NullString
+	ValidFrom       sql.NullTime
+	ValidTo         sql.NullTime
+	Active          bool
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+}
+
+type Refund struct {
+	ID         int32
+	SaleID     int32
+	Amount     money.Amount
+	Reason     string
+	RefundDate time.Time
+	CreatedAt  sql.NullTime
+}
+
 type Sale struct {
+	ID              int32
+	ProductName     string
+	Category        string
+	Currency        string
+	Price           money.Amount
+	SaleDate        time.Time
+	EmployeeID      sql.NullInt32
+	ProductID       sql.NullInt32
+	NetAmount       money.Amount
+	TaxAmount       money.Amount
+	DiscountAmount  money.Amount
+	DiscountPercent string
+	PromotionID     sql.NullInt32
+	ListPrice       money.Amount
+	CustomerID      sql.NullInt32
+	CreatedAt       sql.NullTime
+	UpdatedAt       sql.NullTime
+	DeletedAt       sql.NullTime
+}
+
+type SaleItem struct {
+	ID             int32
+	SaleID         int32
+	ProductID      sql.NullInt32
+	ProductName    string
+	Category       string
+	Quantity       int32
+	UnitPrice      money.Amount
+	DiscountAmount money.Amount
+	LineTotal      money.Amount
+	TaxRate        string
+	NetAmount      money.Amount
+	TaxAmount      money.Amount
+	CreatedAt      sql.NullTime
+}
+
+type SalesTarget struct {
 	ID          int32
-	ProductName string
-	Category    string
-	Currency    string
-	Price       string
-	SaleDate    time.Time
 	EmployeeID  int32
+	PeriodType  string
+	PeriodStart time.Time
+	Amount      money.Amount
+	Currency    string
 	CreatedAt   sql.NullTime
 	UpdatedAt   sql.NullTime
 }
@@ -0,0 +1,177 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Scale is the number of decimal places every amount is stored with, matching DECIMAL(10,2) prices
+const Scale = 2
+
+// ErrInvalidAmount is returned when a value is not a decimal amount with at most Scale decimal places
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Amount is an exact amount of money counted in hundredths of the currency unit (grosz, cents).
+// It is stored as NUMERIC in the database and written to JSON as a number with two decimals.
+type Amount int64
+
+// Parse reads an amount like "4500", "4500.5" or "-12.99".
+// Digits beyond Scale decimal places are only accepted when they are zeros.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	value := s
+	negative := false
+	switch {
+	case strings.HasPrefix(value, "-"):
+		negative = true
+		value = value[1:]
+	case strings.HasPrefix(value, "+"):
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	if trimmed := strings.TrimRight(fraction, "0"); len(trimmed) > Scale {
+		return 0, fmt.Errorf("%w %q: more than %d decimal places", ErrInvalidAmount, s, Scale)
+	} else if len(fraction) > Scale {
+		fraction = fraction[:Scale]
+	}
+	fraction += strings.Repeat("0", Scale-len(fraction))
+
+	digits := strings.TrimLeft(whole, "0") + fraction
+	if len(digits) > 18 || !isDigits(whole) || !isDigits(fraction) {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	units, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q", ErrInvalidAmount, s)
+	}
+	if negative {
+		units = -units
+	}
+	return Amount(units), nil
+}
+
+// Float64 returns the amount as a float, for ratios and percentages only
+func (a Amount) Float64() float64 {
+	return float64(a) / 100
+}
+
+// Mul multiplies the amount by a factor such as an exchange rate, rounding half away from zero
+func (a Amount) Mul(factor float64) Amount {
+	return Amount(math.Round(float64(a) * factor))
+}
+
+// HasDecimals reports whether the amount fits in the given number of decimal places,
+// e.g. 1500.50 does not fit a currency without minor units
+func (a Amount) HasDecimals(places int) bool {
+	if places >= Scale {
+		return true
+	}
+	if places < 0 {
+		places = 0
+	}
+	return int64(a)%int64(math.Pow10(Scale-places)) == 0
+}
+
+// String formats the amount with Scale decimal places, e.g. "4500.00"
+func (a Amount) String() string {
+	return a.StringFixed(Scale)
+}
+
+// StringFixed formats the amount with the given number of decimal places,
+// rounding half away from zero when fewer than Scale places are requested
+func (a Amount) StringFixed(places int) string {
+	if places < 0 {
+		places = 0
+	}
+	units := int64(a)
+	if places < Scale {
+		step := int64(math.Pow10(Scale - places))
+		units = int64(math.Round(float64(units)/float64(step))) * step
+	}
+
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	whole := strconv.FormatInt(units/100, 10)
+	if places == 0 {
+		return sign + whole
+	}
+	fraction := fmt.Sprintf("%02d", units%100)
+	if places > Scale {
+		fraction += strings.Repeat("0", places-Scale)
+	} else {
+		fraction = fraction[:places]
+	}
+	return sign + whole + "." + fraction
+}
+
+// MarshalJSON writes the amount as a JSON number with fixed scale, e.g. 4500.00
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	value := string(data)
+	if value == "null" {
+		return nil
+	}
+	value = strings.Trim(value, `"`)
+	parsed, err := Parse(value)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan implements sql.Scanner for NUMERIC columns
+func (a *Amount) Scan(src any) error {
+	switch value := src.(type) {
+	case []byte:
+		parsed, err := Parse(string(value))
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case string:
+		parsed, err := Parse(value)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+	case int64:
+		*a = Amount(value * 100)
+	case float64:
+		*a = Amount(math.Round(value * 100))
+	case nil:
+		return errors.New("cannot scan NULL into money.Amount")
+	default:
+		return fmt.Errorf("cannot scan %T into money.Amount", src)
+	}
+	return nil
+}
+
+// Value implements driver.Valuer, the amount is sent as an exact decimal string
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
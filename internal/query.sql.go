@@ -8,250 +8,3913 @@ package internals
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
+
+	"WorkRESTAPI/internal/money"
 )
 
-const createEmployee = `-- name: CreateEmployee :one
-INSERT INTO employees (name, surname, email) 
+const clearPurgedManagers = `-- name: ClearPurgedManagers :many
+-- Employees reporting to someone PurgeEmployees removes lose their manager before it,
+-- they are returned as they were before
+WITH purged AS ( 
+    SELECT m.id FROM employees m 
+    WHERE m.deleted_at < $1::timestamptz 
+      AND NOT EXISTS (SELECT 1 FROM sales s WHERE s.employee_id = m.id) 
+) 
+UPDATE employees 
+SET manager_id = NULL, updated_at = CURRENT_TIMESTAMP 
+FROM employees old 
+WHERE old.id = employees.id 
+  AND employees.manager_id IN (SELECT id FROM purged) 
+  AND employees.id NOT IN (SELECT id FROM purged) 
+RETURNING old.id, old.name, old.surname, old.email, old.department_id, old.manager_id, old.hire_date, old.termination_date, old.job_title, old.status, old.created_at, old.updated_at, old.deleted_at
+`
+
+func (q *Queries) ClearPurgedManagers(ctx context.Context, deletedBefore time.Time) ([]Employee, error) {
+	rows, err := q.db.QueryContext(ctx, clearPurgedManagers, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Employee
+	for rows.Next() {
+		var i Employee
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Surname,
+			&i.Email,
+			&i.DepartmentID,
+			&i.ManagerID,
+			&i.HireDate,
+			&i.TerminationDate,
+			&i.JobTitle,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countAuditLog = `-- name: CountAuditLog :one
+SELECT COUNT(*) 
+FROM audit_log 
+WHERE ($1::text IS NULL OR entity = $1) 
+  AND ($2::int IS NULL OR entity_id = $2)
+`
+
+type CountAuditLogParams struct {
+	Entity   sql.NullString
+	EntityID sql.NullInt32
+}
+
+func (q *Queries) CountAuditLog(ctx context.Context, arg CountAuditLogParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAuditLog, arg.Entity, arg.EntityID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countCustomers = `-- name: CountCustomers :one
+SELECT COUNT(*) 
+FROM customers c 
+WHERE ($1::varchar IS NULL 
+    OR c.name ILIKE '%' || $1 || '%' 
+    OR c.company ILIKE '%' || $1 || '%' 
+    OR c.tax_id ILIKE '%' || $1 || '%' 
+    OR c.email ILIKE '%' || $1 || '%')
+`
+
+func (q *Queries) CountCustomers(ctx context.Context, search sql.NullString) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countCustomers, search)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countEmployees = `-- name: CountEmployees :one
+SELECT COUNT(*) 
+FROM employees e 
+WHERE ($1::varchar IS NULL 
+    OR e.name ILIKE '%' || $1 || '%' 
+    OR e.surname ILIKE '%' || $1 || '%' 
+    OR e.email ILIKE '%' || $1 || '%') 
+  AND ($2::int IS NULL OR e.id IN (SELECT department_employees($2))) 
+  AND ($3::int IS NULL OR e.id IN (SELECT manager_subtree($3))) 
+  AND ($4::varchar IS NULL OR e.status = $4) 
+  AND ($5::bool OR e.deleted_at IS NULL)
+`
+
+type CountEmployeesParams struct {
+	Search         sql.NullString
+	DepartmentID   sql.NullInt32
+	ManagerID      sql.NullInt32
+	Status         sql.NullString
+	IncludeDeleted bool
+}
+
+func (q *Queries) CountEmployees(ctx context.Context, arg CountEmployeesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countEmployees,
+		arg.Search,
+		arg.DepartmentID,
+		arg.ManagerID,
+		arg.Status,
+		arg.IncludeDeleted,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSales = `-- name: CountSales :one
+SELECT COUNT(*) 
+FROM sales 
+WHERE ($1::int IS NULL OR employee_id = $1) 
+  AND (NOT $2::bool OR employee_id IS NULL) 
+  AND ($3::bool OR deleted_at IS NULL) 
+  AND ($4::varchar IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = sales.id AND i.category = $4)) 
+  AND ($5::varchar IS NULL OR currency = $5) 
+  AND ($6::numeric IS NULL OR price >= $6) 
+  AND ($7::numeric IS NULL OR price <= $7) 
+  AND ($8::timestamptz IS NULL OR sale_date >= $8) 
+  AND ($9::timestamptz IS NULL OR sale_date < $9) 
+  AND ($10::int IS NULL OR customer_id = $10)
+`
+
+type CountSalesParams struct {
+	EmployeeID     sql.NullInt32
+	Unassigned     bool
+	IncludeDeleted bool
+	Category       sql.NullString
+	Currency       sql.NullString
+	MinPrice       sql.NullString
+	MaxPrice       sql.NullString
+	FromDate       sql.NullTime
+	ToDate         sql.NullTime
+	CustomerID     sql.NullInt32
+}
+
+func (q *Queries) CountSales(ctx context.Context, arg CountSalesParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSales,
+		arg.EmployeeID,
+		arg.Unassigned,
+		arg.IncludeDeleted,
+		arg.Category,
+		arg.Currency,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.FromDate,
+		arg.ToDate,
+		arg.CustomerID,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAuditEntry = `-- name: CreateAuditEntry :one
+INSERT INTO audit_log (entity, entity_id, action, actor, request_id, before, after) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING id, entity, entity_id, action, actor, request_id, before, after, created_at
+`
+
+type CreateAuditEntryParams struct {
+	Entity    string
+	EntityID  int32
+	Action    string
+	Actor     string
+	RequestID sql.NullString
+	Before    json.RawMessage
+	After     json.RawMessage
+}
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEntry,
+		arg.Entity,
+		arg.EntityID,
+		arg.Action,
+		arg.Actor,
+		arg.RequestID,
+		arg.Before,
+		arg.After,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Entity,
+		&i.EntityID,
+		&i.Action,
+		&i.Actor,
+		&i.RequestID,
+		&i.Before,
+		&i.After,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createCategory = `-- name: CreateCategory :one
+INSERT INTO categories (name, description, tax_rate) 
 VALUES ($1, $2, $3) 
-RETURNING id, name, surname, email, created_at, updated_at
+RETURNING id, name, description, tax_rate, created_at, updated_at
 `
 
-type CreateEmployeeParams struct {
-	Name    string
-	Surname string
-	Email   string
+type CreateCategoryParams struct {
+	Name        string
+	Description sql.NullString
+	TaxRate     string
 }
 
-func (q *Queries) CreateEmployee(ctx context.Context, arg CreateEmployeeParams) (Employee, error) {
-	row := q.db.QueryRowContext(ctx, createEmployee, arg.Name, arg.Surname, arg.Email)
-	var i Employee
+func (q *Queries) CreateCategory(ctx context.Context, arg CreateCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, createCategory, arg.Name, arg.Description, arg.TaxRate)
+	var i Category
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
-		&i.Surname,
-		&i.Email,
+		&i.Description,
+		&i.TaxRate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const createSale = `-- name: CreateSale :one
-INSERT INTO sales (product_name, category, currency, price, sale_date, employee_id) 
-VALUES ($1, $2, $3, $4, $5, $6) 
-RETURNING id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at
+const createCommissionCategoryRate = `-- name: CreateCommissionCategoryRate :one
+INSERT INTO commission_category_rates (plan_id, category, rate) 
+VALUES ($1, $2, $3) 
+RETURNING plan_id, category, rate
 `
 
-type CreateSaleParams struct {
-	ProductName string
-	Category    string
-	Currency    string
-	Price       string
-	SaleDate    time.Time
-	EmployeeID  int32
+type CreateCommissionCategoryRateParams struct {
+	PlanID   int32
+	Category string
+	Rate     string
 }
 
-func (q *Queries) CreateSale(ctx context.Context, arg CreateSaleParams) (Sale, error) {
-	row := q.db.QueryRowContext(ctx, createSale,
-		arg.ProductName,
-		arg.Category,
+func (q *Queries) CreateCommissionCategoryRate(ctx context.Context, arg CreateCommissionCategoryRateParams) (CommissionCategoryRate, error) {
+	row := q.db.QueryRowContext(ctx, createCommissionCategoryRate, arg.PlanID, arg.Category, arg.Rate)
+	var i CommissionCategoryRate
+	err := row.Scan(&i.PlanID, &i.Category, &i.Rate)
+	return i, err
+}
+
+const createCommissionPlan = `-- name: CreateCommissionPlan :one
+INSERT INTO commission_plans (name, description, currency, base_rate, accelerator_rate) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at
+`
+
+type CreateCommissionPlanParams struct {
+	Name            string
+	Description     sql.NullString
+	Currency        string
+	BaseRate        string
+	AcceleratorRate string
+}
+
+func (q *Queries) CreateCommissionPlan(ctx context.Context, arg CreateCommissionPlanParams) (CommissionPlan, error) {
+	row := q.db.QueryRowContext(ctx, createCommissionPlan,
+		arg.Name,
+		arg.Description,
 		arg.Currency,
-		arg.Price,
-		arg.SaleDate,
-		arg.EmployeeID,
+		arg.BaseRate,
+		arg.AcceleratorRate,
 	)
-	var i Sale
+	var i CommissionPlan
 	err := row.Scan(
 		&i.ID,
-		&i.ProductName,
-		&i.Category,
+		&i.Name,
+		&i.Description,
 		&i.Currency,
-		&i.Price,
-		&i.SaleDate,
-		&i.EmployeeID,
+		&i.BaseRate,
+		&i.AcceleratorRate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const deleteEmployee = `-- name: DeleteEmployee :exec
-DELETE FROM employees 
-WHERE id = $1
+const createCommissionTier = `-- name: CreateCommissionTier :one
+INSERT INTO commission_tiers (plan_id, threshold, rate) 
+VALUES ($1, $2, $3) 
+RETURNING id, plan_id, threshold, rate
 `
 
-func (q *Queries) DeleteEmployee(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteEmployee, id)
-	return err
+type CreateCommissionTierParams struct {
+	PlanID    int32
+	Threshold money.Amount
+	Rate      string
 }
 
-const deleteSale = `-- name: DeleteSale :exec
-DELETE FROM sales 
-WHERE id = $1
+func (q *Queries) CreateCommissionTier(ctx context.Context, arg CreateCommissionTierParams) (CommissionTier, error) {
+	row := q.db.QueryRowContext(ctx, createCommissionTier, arg.PlanID, arg.Threshold, arg.Rate)
+	var i CommissionTier
+	err := row.Scan(
+		&i.ID,
+		&i.PlanID,
+		&i.Threshold,
+		&i.Rate,
+	)
+	return i, err
+}
+
+const createCurrency = `-- name: CreateCurrency :one
+INSERT INTO currencies (code, name, minor_units, symbol, enabled) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING code, name, minor_units, symbol, enabled, created_at, updated_at
 `
 
-func (q *Queries) DeleteSale(ctx context.Context, id int32) error {
-	_, err := q.db.ExecContext(ctx, deleteSale, id)
-	return err
+type CreateCurrencyParams struct {
+	Code       string
+	Name       string
+	MinorUnits int16
+	Symbol     string
+	Enabled    bool
 }
 
-const getEmployee = `-- name: GetEmployee :one
-SELECT id, name, surname, email, created_at, updated_at 
-FROM employees 
-WHERE id = $1
+func (q *Queries) CreateCurrency(ctx context.Context, arg CreateCurrencyParams) (Currency, error) {
+	row := q.db.QueryRowContext(ctx, createCurrency,
+		arg.Code,
+		arg.Name,
+		arg.MinorUnits,
+		arg.Symbol,
+		arg.Enabled,
+	)
+	var i Currency
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.MinorUnits,
+		&i.Symbol,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createCustomer = `-- name: CreateCustomer :one
+INSERT INTO customers (name, company, tax_id, email, address) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING id, name, company, tax_id, email, address, created_at, updated_at
 `
 
-func (q *Queries) GetEmployee(ctx context.Context, id int32) (Employee, error) {
-	row := q.db.QueryRowContext(ctx, getEmployee, id)
-	var i Employee
+type CreateCustomerParams struct {
+	Name    string
+	Company sql.NullString
+	TaxID   sql.NullString
+	Email   sql.NullString
+	Address sql.NullString
+}
+
+func (q *Queries) CreateCustomer(ctx context.Context, arg CreateCustomerParams) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, createCustomer,
+		arg.Name,
+		arg.Company,
+		arg.TaxID,
+		arg.Email,
+		arg.Address,
+	)
+	var i Customer
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
-		&i.Surname,
+		&i.Company,
+		&i.TaxID,
 		&i.Email,
+		&i.Address,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getEmployeeByEmail = `-- name: GetEmployeeByEmail :one
-SELECT id, name, surname, email, created_at, updated_at 
-FROM employees 
-WHERE email = $1
+const createDepartment = `-- name: CreateDepartment :one
+INSERT INTO departments (name, description, parent_id) 
+VALUES ($1, $2, $3) 
+RETURNING id, name, description, parent_id, created_at, updated_at
 `
 
-func (q *Queries) GetEmployeeByEmail(ctx context.Context, email string) (Employee, error) {
-	row := q.db.QueryRowContext(ctx, getEmployeeByEmail, email)
-	var i Employee
+type CreateDepartmentParams struct {
+	Name        string
+	Description sql.NullString
+	ParentID    sql.NullInt32
+}
+
+func (q *Queries) CreateDepartment(ctx context.Context, arg CreateDepartmentParams) (Department, error) {
+	row := q.db.QueryRowContext(ctx, createDepartment, arg.Name, arg.Description, arg.ParentID)
+	var i Department
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
-		&i.Surname,
-		&i.Email,
+		&i.Description,
+		&i.ParentID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getEmployeeWithSales = `-- name: GetEmployeeWithSales :one
-SELECT 
-    e.id,
-    e.name,
-    e.surname,
-    e.email,
-    e.created_at,
-    e.updated_at,
-    COUNT(s.id) as total_sales,
-    COALESCE(SUM(s.price), 0) as total_revenue
-FROM employees e
-LEFT JOIN sales s ON e.id = s.employee_id
-WHERE e.id = $1
-GROUP BY e.id, e.name, e.surname, e.email, e.created_at, e.updated_at
+const createEmployee = `-- name: CreateEmployee :one
+INSERT INTO employees (name, surname, email, hire_date, job_title) 
+VALUES ($1, $2, $3, COALESCE($4::date, CURRENT_DATE), $5) 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at
 `
 
-type GetEmployeeWithSalesRow struct {
-	ID           int32
-	Name         string
-	Surname      string
-	Email        string
-	CreatedAt    sql.NullTime
-	UpdatedAt    sql.NullTime
-	TotalSales   int64
-	TotalRevenue interface{}
+type CreateEmployeeParams struct {
+	Name     string
+	Surname  string
+	Email    string
+	HireDate sql.NullTime
+	JobTitle sql.NullString
 }
 
-func (q *Queries) GetEmployeeWithSales(ctx context.Context, id int32) (GetEmployeeWithSalesRow, error) {
-	row := q.db.QueryRowContext(ctx, getEmployeeWithSales, id)
-	var i GetEmployeeWithSalesRow
+func (q *Queries) CreateEmployee(ctx context.Context, arg CreateEmployeeParams) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, createEmployee,
+		arg.Name,
+		arg.Surname,
+		arg.Email,
+		arg.HireDate,
+		arg.JobTitle,
+	)
+	var i Employee
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
 		&i.Surname,
 		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.TotalSales,
-		&i.TotalRevenue,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
-const getEmployees = `-- name: GetEmployees :many
-SELECT id, name, surname, email, created_at, updated_at 
-FROM employees 
-ORDER BY id
+const createExchangeRate = `-- name: CreateExchangeRate :one
+INSERT INTO exchange_rates (from_currency, to_currency, rate_date, rate) 
+VALUES ($1, $2, $3, $4) 
+RETURNING id, from_currency, to_currency, rate_date, rate, created_at, updated_at
 `
 
-func (q *Queries) GetEmployees(ctx context.Context) ([]Employee, error) {
-	rows, err := q.db.QueryContext(ctx, getEmployees)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Employee
-	for rows.Next() {
-		var i Employee
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Surname,
-			&i.Email,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CreateExchangeRateParams struct {
+	FromCurrency string
+	ToCurrency   string
+	RateDate     time.Time
+	Rate         string
 }
 
-const getSale = `-- name: GetSale :one
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
-FROM sales 
-WHERE id = $1
+func (q *Queries) CreateExchangeRate(ctx context.Context, arg CreateExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, createExchangeRate,
+		arg.FromCurrency,
+		arg.ToCurrency,
+		arg.RateDate,
+		arg.Rate,
+	)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.ID,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.RateDate,
+		&i.Rate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createOffboarding = `-- name: CreateOffboarding :one
+INSERT INTO offboardings (employee_id, employee_name, employee_surname, employee_email, reassigned_to, from_date, to_date, category, customer_id, sales_reassigned, action, performed_by) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
+RETURNING id, employee_id, employee_name, employee_surname, employee_email, reassigned_to, from_date, to_date, category, customer_id, sales_reassigned, action, performed_by, created_at
 `
 
-func (q *Queries) GetSale(ctx context.Context, id int32) (Sale, error) {
-	row := q.db.QueryRowContext(ctx, getSale, id)
-	var i Sale
+type CreateOffboardingParams struct {
+	EmployeeID      int32
+	EmployeeName    string
+	EmployeeSurname string
+	EmployeeEmail   string
+	ReassignedTo    sql.NullInt32
+	FromDate        sql.NullTime
+	ToDate          sql.NullTime
+	Category        sql.NullString
+	CustomerID      sql.NullInt32
+	SalesReassigned int32
+	Action          string
+	PerformedBy     string
+}
+
+func (q *Queries) CreateOffboarding(ctx context.Context, arg CreateOffboardingParams) (Offboarding, error) {
+	row := q.db.QueryRowContext(ctx, createOffboarding,
+		arg.EmployeeID,
+		arg.EmployeeName,
+		arg.EmployeeSurname,
+		arg.EmployeeEmail,
+		arg.ReassignedTo,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Category,
+		arg.CustomerID,
+		arg.SalesReassigned,
+		arg.Action,
+		arg.PerformedBy,
+	)
+	var i Offboarding
 	err := row.Scan(
 		&i.ID,
-		&i.ProductName,
+		&i.EmployeeID,
+		&i.EmployeeName,
+		&i.EmployeeSurname,
+		&i.EmployeeEmail,
+		&i.ReassignedTo,
+		&i.FromDate,
+		&i.ToDate,
+		&i.Category,
+		&i.CustomerID,
+		&i.SalesReassigned,
+		&i.Action,
+		&i.PerformedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createProduct = `-- name: CreateProduct :one
+INSERT INTO products (sku, name, category, price, currency, active, tax_rate) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at
+`
+
+type CreateProductParams struct {
+	Sku      string
+	Name     string
+	Category string
+	Price    money.Amount
+	Currency string
+	Active   bool
+	TaxRate  sql.NullString
+}
+
+func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, createProduct,
+		arg.Sku,
+		arg.Name,
+		arg.Category,
+		arg.Price,
+		arg.Currency,
+		arg.Active,
+		arg.TaxRate,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Sku,
+		&i.Name,
 		&i.Category,
-		&i.Currency,
 		&i.Price,
-		&i.SaleDate,
-		&i.EmployeeID,
+		&i.Currency,
+		&i.Active,
+		&i.TaxRate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getSales = `-- name: GetSales :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
-FROM sales 
-ORDER BY sale_date DESC
+const createPromotion = `-- name: CreatePromotion :one
+INSERT INTO promotions (code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+RETURNING id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at
 `
 
-func (q *Queries) GetSales(ctx context.Context) ([]Sale, error) {
-	rows, err := q.db.QueryContext(ctx, getSales)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Sale
-	for rows.Next() {
+type CreatePromotionParams struct {
+	Code            string
+	Description     sql.NullString
+	DiscountPercent string
+	DiscountAmount  money.Amount
+	Currency        sql.NullString
+	ValidFrom       sql.NullTime
+	ValidTo         sql.NullTime
+	Active          bool
+}
+
+func (q *Queries) CreatePromotion(ctx context.Context, arg CreatePromotionParams) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, createPromotion,
+		arg.Code,
+		arg.Description,
+		arg.DiscountPercent,
+		arg.DiscountAmount,
+		arg.Currency,
+		arg.ValidFrom,
+		arg.ValidTo,
+		arg.Active,
+	)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountPercent,
+		&i.DiscountAmount,
+		&i.Currency,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createRefund = `-- name: CreateRefund :one
+INSERT INTO refunds (sale_id, amount, reason, refund_date) 
+VALUES ($1, $2, $3, $4) 
+RETURNING id, sale_id, amount, reason, refund_date, created_at
+`
+
+type CreateRefundParams struct {
+	SaleID     int32
+	Amount     money.Amount
+	Reason     string
+	RefundDate time.Time
+}
+
+func (q *Queries) CreateRefund(ctx context.Context, arg CreateRefundParams) (Refund, error) {
+	row := q.db.QueryRowContext(ctx, createRefund,
+		arg.SaleID,
+		arg.Amount,
+		arg.Reason,
+		arg.RefundDate,
+	)
+	var i Refund
+	err := row.Scan(
+		&i.ID,
+		&i.SaleID,
+		&i.Amount,
+		&i.Reason,
+		&i.RefundDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSale = `-- name: CreateSale :one
+INSERT INTO sales (product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, customer_id) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) 
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at
+`
+
+type CreateSaleParams struct {
+	ProductName     string
+	Category        string
+	Currency        string
+	Price           money.Amount
+	SaleDate        time.Time
+	EmployeeID      sql.NullInt32
+	ProductID       sql.NullInt32
+	NetAmount       money.Amount
+	TaxAmount       money.Amount
+	DiscountAmount  money.Amount
+	DiscountPercent string
+	PromotionID     sql.NullInt32
+	CustomerID      sql.NullInt32
+}
+
+func (q *Queries) CreateSale(ctx context.Context, arg CreateSaleParams) (Sale, error) {
+	row := q.db.QueryRowContext(ctx, createSale,
+		arg.ProductName,
+		arg.Category,
+		arg.Currency,
+		arg.Price,
+		arg.SaleDate,
+		arg.EmployeeID,
+		arg.ProductID,
+		arg.NetAmount,
+		arg.TaxAmount,
+		arg.DiscountAmount,
+		arg.DiscountPercent,
+		arg.PromotionID,
+		arg.CustomerID,
+	)
+	var i Sale
+	err := row.Scan(
+		&i.ID,
+		&i.ProductName,
+		&i.Category,
+		&i.Currency,
+		&i.Price,
+		&i.SaleDate,
+		&i.EmployeeID,
+		&i.ProductID,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.DiscountPercent,
+		&i.PromotionID,
+		&i.ListPrice,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const createSaleItem = `-- name: CreateSaleItem :one
+INSERT INTO sale_items (sale_id, product_id, product_name, category, quantity, unit_price, discount_amount, tax_rate, net_amount, tax_amount) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
+RETURNING id, sale_id, product_id, product_name, category, quantity, unit_price, discount_amount, line_total, tax_rate, net_amount, tax_amount, created_at
+`
+
+type CreateSaleItemParams struct {
+	SaleID         int32
+	ProductID      sql.NullInt32
+	ProductName    string
+	Category       string
+	Quantity       int32
+	UnitPrice      money.Amount
+	DiscountAmount money.Amount
+	TaxRate        string
+	NetAmount      money.Amount
+	TaxAmount      money.Amount
+}
+
+func (q *Queries) CreateSaleItem(ctx context.Context, arg CreateSaleItemParams) (SaleItem, error) {
+	row := q.db.QueryRowContext(ctx, createSaleItem,
+		arg.SaleID,
+		arg.ProductID,
+		arg.ProductName,
+		arg.Category,
+		arg.Quantity,
+		arg.UnitPrice,
+		arg.DiscountAmount,
+		arg.TaxRate,
+		arg.NetAmount,
+		arg.TaxAmount,
+	)
+	var i SaleItem
+	err := row.Scan(
+		&i.ID,
+		&i.SaleID,
+		&i.ProductID,
+		&i.ProductName,
+		&i.Category,
+		&i.Quantity,
+		&i.UnitPrice,
+		&i.DiscountAmount,
+		&i.LineTotal,
+		&i.TaxRate,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteCategory = `-- name: DeleteCategory :exec
+DELETE FROM categories 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCategory(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCategory, id)
+	return err
+}
+
+const deleteCommissionAssignment = `-- name: DeleteCommissionAssignment :execrows
+DELETE FROM commission_assignments 
+WHERE employee_id = $1 AND valid_from = $2
+`
+
+type DeleteCommissionAssignmentParams struct {
+	EmployeeID int32
+	ValidFrom  time.Time
+}
+
+func (q *Queries) DeleteCommissionAssignment(ctx context.Context, arg DeleteCommissionAssignmentParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteCommissionAssignment, arg.EmployeeID, arg.ValidFrom)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteCommissionCategoryRates = `-- name: DeleteCommissionCategoryRates :exec
+DELETE FROM commission_category_rates 
+WHERE plan_id = $1
+`
+
+func (q *Queries) DeleteCommissionCategoryRates(ctx context.Context, planID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCommissionCategoryRates, planID)
+	return err
+}
+
+const deleteCommissionPlan = `-- name: DeleteCommissionPlan :exec
+DELETE FROM commission_plans 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCommissionPlan(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCommissionPlan, id)
+	return err
+}
+
+const deleteCommissionTiers = `-- name: DeleteCommissionTiers :exec
+DELETE FROM commission_tiers 
+WHERE plan_id = $1
+`
+
+func (q *Queries) DeleteCommissionTiers(ctx context.Context, planID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCommissionTiers, planID)
+	return err
+}
+
+const deleteCustomer = `-- name: DeleteCustomer :exec
+DELETE FROM customers 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteCustomer(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomer, id)
+	return err
+}
+
+const deleteDepartment = `-- name: DeleteDepartment :exec
+DELETE FROM departments 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteDepartment(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteDepartment, id)
+	return err
+}
+
+const deleteEmployee = `-- name: DeleteEmployee :execrows
+-- Soft delete, the employee is kept until PurgeEmployees removes them
+UPDATE employees 
+SET deleted_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) DeleteEmployee(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteEmployee, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteExchangeRate = `-- name: DeleteExchangeRate :exec
+DELETE FROM exchange_rates 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteExchangeRate(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteExchangeRate, id)
+	return err
+}
+
+const deleteProduct = `-- name: DeleteProduct :exec
+DELETE FROM products 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteProduct(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteProduct, id)
+	return err
+}
+
+const deletePromotion = `-- name: DeletePromotion :exec
+DELETE FROM promotions 
+WHERE id = $1
+`
+
+func (q *Queries) DeletePromotion(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deletePromotion, id)
+	return err
+}
+
+const deleteRefund = `-- name: DeleteRefund :exec
+DELETE FROM refunds 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteRefund(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteRefund, id)
+	return err
+}
+
+const deleteSale = `-- name: DeleteSale :execrows
+-- Soft delete, the sale with its lines and refunds is kept until PurgeSales removes it
+UPDATE sales 
+SET deleted_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) DeleteSale(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteSale, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteSaleItems = `-- name: DeleteSaleItems :exec
+DELETE FROM sale_items 
+WHERE sale_id = $1
+`
+
+func (q *Queries) DeleteSaleItems(ctx context.Context, saleID int32) error {
+	_, err := q.db.ExecContext(ctx, deleteSaleItems, saleID)
+	return err
+}
+
+const deleteSalesTarget = `-- name: DeleteSalesTarget :exec
+DELETE FROM sales_targets 
+WHERE id = $1
+`
+
+func (q *Queries) DeleteSalesTarget(ctx context.Context, id int32) error {
+	_, err := q.db.ExecContext(ctx, deleteSalesTarget, id)
+	return err
+}
+
+const getCategories = `-- name: GetCategories :many
+SELECT id, name, description, tax_rate, created_at, updated_at 
+FROM categories 
+ORDER BY name
+`
+
+func (q *Queries) GetCategories(ctx context.Context) ([]Category, error) {
+	rows, err := q.db.QueryContext(ctx, getCategories)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Category
+	for rows.Next() {
+		var i Category
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.TaxRate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCategory = `-- name: GetCategory :one
+SELECT id, name, description, tax_rate, created_at, updated_at 
+FROM categories 
+WHERE id = $1
+`
+
+func (q *Queries) GetCategory(ctx context.Context, id int32) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategory, id)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.TaxRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCategoryByName = `-- name: GetCategoryByName :one
+SELECT id, name, description, tax_rate, created_at, updated_at 
+FROM categories 
+WHERE LOWER(name) = LOWER($1)
+`
+
+func (q *Queries) GetCategoryByName(ctx context.Context, lower string) (Category, error) {
+	row := q.db.QueryRowContext(ctx, getCategoryByName, lower)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.TaxRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCategoryStats = `-- name: GetCategoryStats :many
+WITH converted AS (
+    SELECT i.category, s.id as sale_id, convert_amount(i.line_total, s.currency, $1::varchar, s.sale_date) as amount
+    FROM sale_items i
+    JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL
+    WHERE s.sale_date >= $2 AND s.sale_date < $3
+        AND ($4::int IS NULL OR s.employee_id = $4)
+        AND ($5::int IS NULL OR s.employee_id IN (SELECT department_employees($5))) 
+        AND ($6::int IS NULL OR s.employee_id IN (SELECT manager_subtree($6)))
+)
+SELECT 
+    c.id,
+    c.name,
+    COUNT(DISTINCT cv.sale_id) as total_sales,
+    COALESCE(SUM(cv.amount), 0)::numeric(12,2) as total_revenue,
+    (SUM(cv.amount) / NULLIF(COUNT(DISTINCT cv.sale_id), 0))::numeric(12,2) as avg_sale_value
+FROM categories c
+LEFT JOIN converted cv ON cv.category = c.name
+GROUP BY c.id, c.name
+ORDER BY total_revenue DESC, c.name
+`
+
+type GetCategoryStatsParams struct {
+	BaseCurrency string
+	FromDate     time.Time
+	ToDate       time.Time
+	EmployeeID   sql.NullInt32
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+}
+
+type GetCategoryStatsRow struct {
+	ID           int32
+	Name         string
+	TotalSales   int64
+	TotalRevenue string
+	AvgSaleValue sql.NullString
+}
+
+func (q *Queries) GetCategoryStats(ctx context.Context, arg GetCategoryStatsParams) ([]GetCategoryStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCategoryStats,
+		arg.BaseCurrency,
+		arg.FromDate,
+		arg.ToDate,
+		arg.EmployeeID,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCategoryStatsRow
+	for rows.Next() {
+		var i GetCategoryStatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.TotalSales,
+			&i.TotalRevenue,
+			&i.AvgSaleValue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommissionAssignments = `-- name: GetCommissionAssignments :many
+SELECT employee_id, plan_id, valid_from, created_at 
+FROM commission_assignments 
+WHERE employee_id = $1 
+ORDER BY valid_from
+`
+
+func (q *Queries) GetCommissionAssignments(ctx context.Context, employeeID int32) ([]CommissionAssignment, error) {
+	rows, err := q.db.QueryContext(ctx, getCommissionAssignments, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommissionAssignment
+	for rows.Next() {
+		var i CommissionAssignment
+		if err := rows.Scan(
+			&i.EmployeeID,
+			&i.PlanID,
+			&i.ValidFrom,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommissionAssignmentsOn = `-- name: GetCommissionAssignmentsOn :many
+SELECT DISTINCT ON (employee_id) employee_id, plan_id, valid_from, created_at 
+FROM commission_assignments 
+WHERE valid_from <= $1::date 
+  AND ($2::int IS NULL OR employee_id = $2) 
+  AND employee_id IN (SELECT id FROM employees WHERE deleted_at IS NULL) 
+  AND ($3::int IS NULL OR employee_id IN (SELECT department_employees($3))) 
+  AND ($4::int IS NULL OR employee_id IN (SELECT manager_subtree($4))) 
+ORDER BY employee_id, valid_from DESC
+`
+
+type GetCommissionAssignmentsOnParams struct {
+	OnDate       time.Time
+	EmployeeID   sql.NullInt32
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+}
+
+func (q *Queries) GetCommissionAssignmentsOn(ctx context.Context, arg GetCommissionAssignmentsOnParams) ([]CommissionAssignment, error) {
+	rows, err := q.db.QueryContext(ctx, getCommissionAssignmentsOn,
+		arg.OnDate,
+		arg.EmployeeID,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommissionAssignment
+	for rows.Next() {
+		var i CommissionAssignment
+		if err := rows.Scan(
+			&i.EmployeeID,
+			&i.PlanID,
+			&i.ValidFrom,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommissionBase = `-- name: GetCommissionBase :many
+WITH amounts AS ( 
+    -- Every line is converted at the rate of its sale date, refunds are shared 
+    -- between the lines of their sale and converted at the refund date 
+    SELECT i.category, s.currency, false as refund, 
+        convert_amount(i.line_total, s.currency, $1::varchar, s.sale_date) as amount 
+    FROM sale_items i 
+    JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+    WHERE s.employee_id = $2 AND s.sale_date >= $3 AND s.sale_date < $4 
+    UNION ALL 
+    SELECT i.category, s.currency, true as refund, 
+        convert_amount(r.amount * i.line_total / s.price, s.currency, $1::varchar, r.refund_date) as amount 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    JOIN sale_items i ON i.sale_id = s.id 
+    WHERE s.employee_id = $2 AND r.refund_date >= $3 AND r.refund_date < $4 
+) 
+SELECT 
+    category, 
+    COALESCE(SUM(amount) FILTER (WHERE NOT refund), 0)::numeric(12,2) as revenue, 
+    COALESCE(SUM(amount) FILTER (WHERE refund), 0)::numeric(12,2) as refunded, 
+    COALESCE(string_agg(DISTINCT currency, ',') FILTER (WHERE amount IS NULL), '')::varchar as missing_rates 
+FROM amounts 
+GROUP BY category 
+ORDER BY category
+`
+
+type GetCommissionBaseParams struct {
+	PlanCurrency string
+	EmployeeID   int32
+	FromDate     time.Time
+	ToDate       time.Time
+}
+
+type GetCommissionBaseRow struct {
+	Category     string
+	Revenue      string
+	Refunded     string
+	MissingRates string
+}
+
+func (q *Queries) GetCommissionBase(ctx context.Context, arg GetCommissionBaseParams) ([]GetCommissionBaseRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCommissionBase,
+		arg.PlanCurrency,
+		arg.EmployeeID,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCommissionBaseRow
+	for rows.Next() {
+		var i GetCommissionBaseRow
+		if err := rows.Scan(
+			&i.Category,
+			&i.Revenue,
+			&i.Refunded,
+			&i.MissingRates,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommissionCategoryRates = `-- name: GetCommissionCategoryRates :many
+SELECT plan_id, category, rate 
+FROM commission_category_rates 
+WHERE plan_id = $1 
+ORDER BY category
+`
+
+func (q *Queries) GetCommissionCategoryRates(ctx context.Context, planID int32) ([]CommissionCategoryRate, error) {
+	rows, err := q.db.QueryContext(ctx, getCommissionCategoryRates, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommissionCategoryRate
+	for rows.Next() {
+		var i CommissionCategoryRate
+		if err := rows.Scan(&i.PlanID, &i.Category, &i.Rate); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommissionPlan = `-- name: GetCommissionPlan :one
+SELECT id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at 
+FROM commission_plans 
+WHERE id = $1
+`
+
+func (q *Queries) GetCommissionPlan(ctx context.Context, id int32) (CommissionPlan, error) {
+	row := q.db.QueryRowContext(ctx, getCommissionPlan, id)
+	var i CommissionPlan
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Currency,
+		&i.BaseRate,
+		&i.AcceleratorRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCommissionPlans = `-- name: GetCommissionPlans :many
+SELECT id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at 
+FROM commission_plans 
+ORDER BY name
+`
+
+func (q *Queries) GetCommissionPlans(ctx context.Context) ([]CommissionPlan, error) {
+	rows, err := q.db.QueryContext(ctx, getCommissionPlans)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommissionPlan
+	for rows.Next() {
+		var i CommissionPlan
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.Currency,
+			&i.BaseRate,
+			&i.AcceleratorRate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCommissionTiers = `-- name: GetCommissionTiers :many
+SELECT id, plan_id, threshold, rate 
+FROM commission_tiers 
+WHERE plan_id = $1 
+ORDER BY threshold
+`
+
+func (q *Queries) GetCommissionTiers(ctx context.Context, planID int32) ([]CommissionTier, error) {
+	rows, err := q.db.QueryContext(ctx, getCommissionTiers, planID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CommissionTier
+	for rows.Next() {
+		var i CommissionTier
+		if err := rows.Scan(
+			&i.ID,
+			&i.PlanID,
+			&i.Threshold,
+			&i.Rate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCurrencies = `-- name: GetCurrencies :many
+SELECT code, name, minor_units, symbol, enabled, created_at, updated_at 
+FROM currencies 
+ORDER BY code
+`
+
+func (q *Queries) GetCurrencies(ctx context.Context) ([]Currency, error) {
+	rows, err := q.db.QueryContext(ctx, getCurrencies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Currency
+	for rows.Next() {
+		var i Currency
+		if err := rows.Scan(
+			&i.Code,
+			&i.Name,
+			&i.MinorUnits,
+			&i.Symbol,
+			&i.Enabled,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCurrenciesWithoutExchangeRate = `-- name: GetCurrenciesWithoutExchangeRate :many
+SELECT currency
+FROM sales 
+WHERE deleted_at IS NULL AND sale_date >= $1 AND sale_date < $2 
+    AND convert_amount(price, currency, $3::varchar, sale_date) IS NULL 
+UNION 
+SELECT s.currency 
+FROM refunds r 
+JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+WHERE r.refund_date >= $1 AND r.refund_date < $2 
+    AND convert_amount(r.amount, s.currency, $3::varchar, r.refund_date) IS NULL 
+ORDER BY currency
+`
+
+type GetCurrenciesWithoutExchangeRateParams struct {
+	FromDate     time.Time
+	ToDate       time.Time
+	BaseCurrency string
+}
+
+func (q *Queries) GetCurrenciesWithoutExchangeRate(ctx context.Context, arg GetCurrenciesWithoutExchangeRateParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, getCurrenciesWithoutExchangeRate, arg.FromDate, arg.ToDate, arg.BaseCurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var currency string
+		if err := rows.Scan(&currency); err != nil {
+			return nil, err
+		}
+		items = append(items, currency)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCurrency = `-- name: GetCurrency :one
+SELECT code, name, minor_units, symbol, enabled, created_at, updated_at 
+FROM currencies 
+WHERE code = $1
+`
+
+func (q *Queries) GetCurrency(ctx context.Context, code string) (Currency, error) {
+	row := q.db.QueryRowContext(ctx, getCurrency, code)
+	var i Currency
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.MinorUnits,
+		&i.Symbol,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCustomer = `-- name: GetCustomer :one
+SELECT id, name, company, tax_id, email, address, created_at, updated_at 
+FROM customers 
+WHERE id = $1
+`
+
+func (q *Queries) GetCustomer(ctx context.Context, id int32) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, getCustomer, id)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Company,
+		&i.TaxID,
+		&i.Email,
+		&i.Address,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCustomerByTaxID = `-- name: GetCustomerByTaxID :one
+SELECT id, name, company, tax_id, email, address, created_at, updated_at 
+FROM customers 
+WHERE tax_id = $1
+`
+
+func (q *Queries) GetCustomerByTaxID(ctx context.Context, taxID sql.NullString) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, getCustomerByTaxID, taxID)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Company,
+		&i.TaxID,
+		&i.Email,
+		&i.Address,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCustomerRevenueByCurrency = `-- name: GetCustomerRevenueByCurrency :many
+WITH sold AS ( 
+    SELECT 
+        s.currency, 
+        COUNT(*) as total_sales, 
+        SUM(s.price) as total_revenue, 
+        SUM(convert_amount(s.price, s.currency, $1::varchar, s.sale_date)) as base_revenue, 
+        COUNT(*) FILTER (WHERE convert_amount(s.price, s.currency, $1::varchar, s.sale_date) IS NULL) as missing_rates, 
+        MIN(s.sale_date) as first_sale_date, 
+        MAX(s.sale_date) as last_sale_date 
+    FROM sales s 
+    WHERE s.deleted_at IS NULL AND s.customer_id = $2 
+    GROUP BY s.currency 
+), refunded AS ( 
+    SELECT 
+        s.currency, 
+        SUM(r.amount) as total_refunded, 
+        SUM(convert_amount(r.amount, s.currency, $1::varchar, r.refund_date)) as base_refunded, 
+        COUNT(*) FILTER (WHERE convert_amount(r.amount, s.currency, $1::varchar, r.refund_date) IS NULL) as missing_rates 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    WHERE s.customer_id = $2 
+    GROUP BY s.currency 
+) 
+SELECT 
+    sold.currency, 
+    sold.total_sales, 
+    sold.total_revenue::numeric(12,2) as total_revenue, 
+    COALESCE(refunded.total_refunded, 0)::numeric(12,2) as total_refunded, 
+    COALESCE(sold.base_revenue, 0)::numeric(12,2) as base_revenue, 
+    COALESCE(refunded.base_refunded, 0)::numeric(12,2) as base_refunded, 
+    (sold.missing_rates + COALESCE(refunded.missing_rates, 0))::bigint as missing_rates, 
+    sold.first_sale_date, 
+    sold.last_sale_date 
+FROM sold 
+LEFT JOIN refunded ON refunded.currency = sold.currency 
+ORDER BY sold.currency
+`
+
+type GetCustomerRevenueByCurrencyParams struct {
+	BaseCurrency string
+	CustomerID   int32
+}
+
+type GetCustomerRevenueByCurrencyRow struct {
+	Currency      string
+	TotalSales    int64
+	TotalRevenue  string
+	TotalRefunded string
+	BaseRevenue   string
+	BaseRefunded  string
+	MissingRates  int64
+	FirstSaleDate sql.NullTime
+	LastSaleDate  sql.NullTime
+}
+
+func (q *Queries) GetCustomerRevenueByCurrency(ctx context.Context, arg GetCustomerRevenueByCurrencyParams) ([]GetCustomerRevenueByCurrencyRow, error) {
+	rows, err := q.db.QueryContext(ctx, getCustomerRevenueByCurrency, arg.BaseCurrency, arg.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetCustomerRevenueByCurrencyRow
+	for rows.Next() {
+		var i GetCustomerRevenueByCurrencyRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.TotalSales,
+			&i.TotalRevenue,
+			&i.TotalRefunded,
+			&i.BaseRevenue,
+			&i.BaseRefunded,
+			&i.MissingRates,
+			&i.FirstSaleDate,
+			&i.LastSaleDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDepartment = `-- name: GetDepartment :one
+SELECT id, name, description, parent_id, created_at, updated_at 
+FROM departments 
+WHERE id = $1
+`
+
+func (q *Queries) GetDepartment(ctx context.Context, id int32) (Department, error) {
+	row := q.db.QueryRowContext(ctx, getDepartment, id)
+	var i Department
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getDepartments = `-- name: GetDepartments :many
+SELECT id, name, description, parent_id, created_at, updated_at 
+FROM departments 
+ORDER BY name
+`
+
+func (q *Queries) GetDepartments(ctx context.Context) ([]Department, error) {
+	rows, err := q.db.QueryContext(ctx, getDepartments)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Department
+	for rows.Next() {
+		var i Department
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Description,
+			&i.ParentID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEmployee = `-- name: GetEmployee :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetEmployee(ctx context.Context, id int32) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, getEmployee, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getEmployeeByEmail = `-- name: GetEmployeeByEmail :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE email = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetEmployeeByEmail(ctx context.Context, email string) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, getEmployeeByEmail, email)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getEmployeeForUpdate = `-- name: GetEmployeeForUpdate :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE id = $1 AND deleted_at IS NULL 
+FOR UPDATE
+`
+
+func (q *Queries) GetEmployeeForUpdate(ctx context.Context, id int32) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, getEmployeeForUpdate, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getEmployeeIncludingDeleted = `-- name: GetEmployeeIncludingDeleted :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE id = $1
+`
+
+func (q *Queries) GetEmployeeIncludingDeleted(ctx context.Context, id int32) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, getEmployeeIncludingDeleted, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getEmployeeIncludingDeletedForUpdate = `-- name: GetEmployeeIncludingDeletedForUpdate :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE id = $1 
+FOR UPDATE
+`
+
+func (q *Queries) GetEmployeeIncludingDeletedForUpdate(ctx context.Context, id int32) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, getEmployeeIncludingDeletedForUpdate, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getEmployeeRevenueByCurrency = `-- name: GetEmployeeRevenueByCurrency :many
+WITH sold AS ( 
+    SELECT currency, COUNT(id) as total_sales, SUM(price) as total_revenue 
+    FROM sales 
+    WHERE deleted_at IS NULL AND employee_id = $1 AND sale_date >= $2 AND sale_date < $3 
+    GROUP BY currency 
+), refunded AS ( 
+    SELECT s.currency, SUM(r.amount) as total_refunded 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    WHERE s.employee_id = $1 AND r.refund_date >= $2 AND r.refund_date < $3 
+    GROUP BY s.currency 
+) 
+SELECT 
+    COALESCE(sold.currency, refunded.currency)::varchar as currency, 
+    COALESCE(sold.total_sales, 0) as total_sales, 
+    COALESCE(sold.total_revenue, 0)::numeric as total_revenue, 
+    COALESCE(refunded.total_refunded, 0)::numeric as total_refunded 
+FROM sold 
+FULL JOIN refunded ON refunded.currency = sold.currency 
+ORDER BY 1
+`
+
+type GetEmployeeRevenueByCurrencyParams struct {
+	EmployeeID int32
+	FromDate   time.Time
+	ToDate     time.Time
+}
+
+type GetEmployeeRevenueByCurrencyRow struct {
+	Currency      string
+	TotalSales    int64
+	TotalRevenue  string
+	TotalRefunded string
+}
+
+func (q *Queries) GetEmployeeRevenueByCurrency(ctx context.Context, arg GetEmployeeRevenueByCurrencyParams) ([]GetEmployeeRevenueByCurrencyRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEmployeeRevenueByCurrency, arg.EmployeeID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEmployeeRevenueByCurrencyRow
+	for rows.Next() {
+		var i GetEmployeeRevenueByCurrencyRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.TotalSales,
+			&i.TotalRevenue,
+			&i.TotalRefunded,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEmployeeSalesStats = `-- name: GetEmployeeSalesStats :many
+WITH converted AS (
+    SELECT s.employee_id, s.sale_date, convert_amount(
+        CASE WHEN $1::varchar IS NULL THEN s.price 
+            ELSE (SELECT SUM(i.line_total) FROM sale_items i WHERE i.sale_id = s.id AND i.category = $1) 
+        END, s.currency, $2::varchar, s.sale_date) as amount,
+        convert_amount(
+        CASE WHEN $1::varchar IS NULL THEN s.discount_amount 
+            ELSE (SELECT SUM(i.discount_amount) FROM sale_items i WHERE i.sale_id = s.id AND i.category = $1) 
+        END, s.currency, $2::varchar, s.sale_date) as discount
+    FROM sales s
+    WHERE s.deleted_at IS NULL AND s.sale_date >= $3 AND s.sale_date < $4
+        AND ($1 IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = s.id AND i.category = $1))
+), refunded AS (
+    -- Refunds of a sale are shared between its categories in proportion to their part of the price
+    SELECT s.employee_id, SUM(convert_amount(
+        CASE WHEN $1::varchar IS NULL THEN r.amount 
+            ELSE r.amount * (SELECT SUM(i.line_total) FROM sale_items i WHERE i.sale_id = s.id AND i.category = $1) / s.price 
+        END, s.currency, $2::varchar, r.refund_date)) as amount
+    FROM refunds r
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL
+    WHERE r.refund_date >= $3 AND r.refund_date < $4
+        AND ($1 IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = s.id AND i.category = $1))
+    GROUP BY s.employee_id
+)
+SELECT 
+    e.id,
+    e.name,
+    e.surname,
+    e.email,
+    COUNT(c.employee_id) as total_sales,
+    COALESCE(SUM(c.amount), 0)::numeric(12,2) as total_revenue,
+    COALESCE(SUM(c.discount), 0)::numeric(12,2) as total_discount,
+    COALESCE(MAX(rf.amount), 0)::numeric(12,2) as total_refunded,
+    AVG(c.amount)::numeric(12,2) as avg_sale_value,
+    (PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY c.amount))::numeric(12,2) as median_sale_value,
+    MIN(c.amount)::numeric(12,2) as min_sale_value,
+    MAX(c.amount)::numeric(12,2) as max_sale_value,
+    MIN(c.sale_date) as first_sale_date,
+    MAX(c.sale_date) as last_sale_date
+FROM employees e
+LEFT JOIN converted c ON e.id = c.employee_id
+LEFT JOIN refunded rf ON e.id = rf.employee_id
+WHERE e.deleted_at IS NULL
+  AND ($5::int IS NULL OR e.id = $5)
+  AND ($6::int IS NULL OR e.id IN (SELECT department_employees($6))) 
+  AND ($7::int IS NULL OR e.id IN (SELECT manager_subtree($7)))
+GROUP BY e.id, e.name, e.surname, e.email
+ORDER BY total_revenue DESC, e.id
+`
+
+type GetEmployeeSalesStatsParams struct {
+	Category     sql.NullString
+	BaseCurrency string
+	FromDate     time.Time
+	ToDate       time.Time
+	EmployeeID   sql.NullInt32
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+}
+
+type GetEmployeeSalesStatsRow struct {
+	ID              int32
+	Name            string
+	Surname         string
+	Email           string
+	TotalSales      int64
+	TotalRevenue    string
+	TotalDiscount   string
+	TotalRefunded   string
+	AvgSaleValue    sql.NullString
+	MedianSaleValue sql.NullString
+	MinSaleValue    sql.NullString
+	MaxSaleValue    sql.NullString
+	FirstSaleDate   sql.NullTime
+	LastSaleDate    sql.NullTime
+}
+
+func (q *Queries) GetEmployeeSalesStats(ctx context.Context, arg GetEmployeeSalesStatsParams) ([]GetEmployeeSalesStatsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEmployeeSalesStats,
+		arg.Category,
+		arg.BaseCurrency,
+		arg.FromDate,
+		arg.ToDate,
+		arg.EmployeeID,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEmployeeSalesStatsRow
+	for rows.Next() {
+		var i GetEmployeeSalesStatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Surname,
+			&i.Email,
+			&i.TotalSales,
+			&i.TotalRevenue,
+			&i.TotalDiscount,
+			&i.TotalRefunded,
+			&i.AvgSaleValue,
+			&i.MedianSaleValue,
+			&i.MinSaleValue,
+			&i.MaxSaleValue,
+			&i.FirstSaleDate,
+			&i.LastSaleDate,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEmployeeSalesTarget = `-- name: GetEmployeeSalesTarget :one
+SELECT id, employee_id, period_type, period_start, amount, currency, created_at, updated_at 
+FROM sales_targets 
+WHERE employee_id = $1 AND period_type = $2 AND period_start = $3
+`
+
+type GetEmployeeSalesTargetParams struct {
+	EmployeeID  int32
+	PeriodType  string
+	PeriodStart time.Time
+}
+
+func (q *Queries) GetEmployeeSalesTarget(ctx context.Context, arg GetEmployeeSalesTargetParams) (SalesTarget, error) {
+	row := q.db.QueryRowContext(ctx, getEmployeeSalesTarget, arg.EmployeeID, arg.PeriodType, arg.PeriodStart)
+	var i SalesTarget
+	err := row.Scan(
+		&i.ID,
+		&i.EmployeeID,
+		&i.PeriodType,
+		&i.PeriodStart,
+		&i.Amount,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getEmployees = `-- name: GetEmployees :many
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE deleted_at IS NULL 
+ORDER BY id
+`
+
+func (q *Queries) GetEmployees(ctx context.Context) ([]Employee, error) {
+	rows, err := q.db.QueryContext(ctx, getEmployees)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Employee
+	for rows.Next() {
+		var i Employee
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Surname,
+			&i.Email,
+			&i.DepartmentID,
+			&i.ManagerID,
+			&i.HireDate,
+			&i.TerminationDate,
+			&i.JobTitle,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExchangeRate = `-- name: GetExchangeRate :one
+SELECT id, from_currency, to_currency, rate_date, rate, created_at, updated_at 
+FROM exchange_rates 
+WHERE id = $1
+`
+
+func (q *Queries) GetExchangeRate(ctx context.Context, id int32) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, getExchangeRate, id)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.ID,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.RateDate,
+		&i.Rate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getExchangeRateOnDate = `-- name: GetExchangeRateOnDate :one
+SELECT id, from_currency, to_currency, rate_date, rate, created_at, updated_at 
+FROM exchange_rates 
+WHERE from_currency = $1 AND to_currency = $2 AND rate_date <= $3 
+ORDER BY rate_date DESC 
+LIMIT 1
+`
+
+type GetExchangeRateOnDateParams struct {
+	FromCurrency string
+	ToCurrency   string
+	RateDate     time.Time
+}
+
+func (q *Queries) GetExchangeRateOnDate(ctx context.Context, arg GetExchangeRateOnDateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, getExchangeRateOnDate, arg.FromCurrency, arg.ToCurrency, arg.RateDate)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.ID,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.RateDate,
+		&i.Rate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getExchangeRates = `-- name: GetExchangeRates :many
+SELECT id, from_currency, to_currency, rate_date, rate, created_at, updated_at 
+FROM exchange_rates 
+WHERE ($1::varchar IS NULL OR from_currency = $1) 
+    AND ($2::varchar IS NULL OR to_currency = $2) 
+ORDER BY rate_date DESC, from_currency, to_currency
+`
+
+type GetExchangeRatesParams struct {
+	FromCurrency sql.NullString
+	ToCurrency   sql.NullString
+}
+
+func (q *Queries) GetExchangeRates(ctx context.Context, arg GetExchangeRatesParams) ([]ExchangeRate, error) {
+	rows, err := q.db.QueryContext(ctx, getExchangeRates, arg.FromCurrency, arg.ToCurrency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExchangeRate
+	for rows.Next() {
+		var i ExchangeRate
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromCurrency,
+			&i.ToCurrency,
+			&i.RateDate,
+			&i.Rate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProduct = `-- name: GetProduct :one
+SELECT id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at 
+FROM products 
+WHERE id = $1
+`
+
+func (q *Queries) GetProduct(ctx context.Context, id int32) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProduct, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Sku,
+		&i.Name,
+		&i.Category,
+		&i.Price,
+		&i.Currency,
+		&i.Active,
+		&i.TaxRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getProductBySku = `-- name: GetProductBySku :one
+SELECT id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at 
+FROM products 
+WHERE sku = $1
+`
+
+func (q *Queries) GetProductBySku(ctx context.Context, sku string) (Product, error) {
+	row := q.db.QueryRowContext(ctx, getProductBySku, sku)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Sku,
+		&i.Name,
+		&i.Category,
+		&i.Price,
+		&i.Currency,
+		&i.Active,
+		&i.TaxRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getProducts = `-- name: GetProducts :many
+SELECT id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at 
+FROM products 
+WHERE ($1::bool IS NULL OR active = $1) 
+  AND ($2::varchar IS NULL OR category = $2) 
+ORDER BY sku
+`
+
+type GetProductsParams struct {
+	Active   sql.NullBool
+	Category sql.NullString
+}
+
+func (q *Queries) GetProducts(ctx context.Context, arg GetProductsParams) ([]Product, error) {
+	rows, err := q.db.QueryContext(ctx, getProducts, arg.Active, arg.Category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Product
+	for rows.Next() {
+		var i Product
+		if err := rows.Scan(
+			&i.ID,
+			&i.Sku,
+			&i.Name,
+			&i.Category,
+			&i.Price,
+			&i.Currency,
+			&i.Active,
+			&i.TaxRate,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPromotion = `-- name: GetPromotion :one
+SELECT id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at 
+FROM promotions 
+WHERE id = $1
+`
+
+func (q *Queries) GetPromotion(ctx context.Context, id int32) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, getPromotion, id)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountPercent,
+		&i.DiscountAmount,
+		&i.Currency,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPromotionByCode = `-- name: GetPromotionByCode :one
+SELECT id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at 
+FROM promotions 
+WHERE code = $1
+`
+
+func (q *Queries) GetPromotionByCode(ctx context.Context, code string) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, getPromotionByCode, code)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountPercent,
+		&i.DiscountAmount,
+		&i.Currency,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.Active,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getPromotions = `-- name: GetPromotions :many
+SELECT id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at 
+FROM promotions 
+WHERE $1::bool IS NULL OR active = $1 
+ORDER BY code
+`
+
+func (q *Queries) GetPromotions(ctx context.Context, active sql.NullBool) ([]Promotion, error) {
+	rows, err := q.db.QueryContext(ctx, getPromotions, active)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Promotion
+	for rows.Next() {
+		var i Promotion
+		if err := rows.Scan(
+			&i.ID,
+			&i.Code,
+			&i.Description,
+			&i.DiscountPercent,
+			&i.DiscountAmount,
+			&i.Currency,
+			&i.ValidFrom,
+			&i.ValidTo,
+			&i.Active,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRefund = `-- name: GetRefund :one
+SELECT id, sale_id, amount, reason, refund_date, created_at 
+FROM refunds 
+WHERE id = $1
+`
+
+func (q *Queries) GetRefund(ctx context.Context, id int32) (Refund, error) {
+	row := q.db.QueryRowContext(ctx, getRefund, id)
+	var i Refund
+	err := row.Scan(
+		&i.ID,
+		&i.SaleID,
+		&i.Amount,
+		&i.Reason,
+		&i.RefundDate,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getRefundedAmount = `-- name: GetRefundedAmount :one
+SELECT COALESCE(SUM(amount), 0)::numeric(12,2) as total_refunded 
+FROM refunds 
+WHERE sale_id = $1
+`
+
+func (q *Queries) GetRefundedAmount(ctx context.Context, saleID int32) (string, error) {
+	row := q.db.QueryRowContext(ctx, getRefundedAmount, saleID)
+	var totalRefunded string
+	err := row.Scan(&totalRefunded)
+	return totalRefunded, err
+}
+
+const getRefundsByEmployeeAndDateRange = `-- name: GetRefundsByEmployeeAndDateRange :many
+SELECT r.id, r.sale_id, r.amount, r.reason, r.refund_date, s.currency, s.product_name 
+FROM refunds r 
+JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+WHERE s.employee_id = $1 AND r.refund_date >= $2 AND r.refund_date < $3 
+ORDER BY r.refund_date, r.id
+`
+
+type GetRefundsByEmployeeAndDateRangeParams struct {
+	EmployeeID int32
+	FromDate   time.Time
+	ToDate     time.Time
+}
+
+type GetRefundsByEmployeeAndDateRangeRow struct {
+	ID          int32
+	SaleID      int32
+	Amount      money.Amount
+	Reason      string
+	RefundDate  time.Time
+	Currency    string
+	ProductName string
+}
+
+func (q *Queries) GetRefundsByEmployeeAndDateRange(ctx context.Context, arg GetRefundsByEmployeeAndDateRangeParams) ([]GetRefundsByEmployeeAndDateRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRefundsByEmployeeAndDateRange, arg.EmployeeID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRefundsByEmployeeAndDateRangeRow
+	for rows.Next() {
+		var i GetRefundsByEmployeeAndDateRangeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SaleID,
+			&i.Amount,
+			&i.Reason,
+			&i.RefundDate,
+			&i.Currency,
+			&i.ProductName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRevenueByCategory = `-- name: GetRevenueByCategory :many
+SELECT 
+    i.category,
+    COUNT(DISTINCT s.id) as total_sales,
+    COALESCE(SUM(convert_amount(i.line_total, s.currency, $1::varchar, s.sale_date)), 0)::numeric(12,2) as total_revenue
+FROM sale_items i 
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+WHERE s.sale_date >= $2 AND s.sale_date < $3 
+    AND ($4::int IS NULL OR s.employee_id IN (SELECT department_employees($4))) 
+    AND ($5::int IS NULL OR s.employee_id IN (SELECT manager_subtree($5))) 
+GROUP BY i.category 
+ORDER BY total_revenue DESC, i.category
+`
+
+type GetRevenueByCategoryParams struct {
+	BaseCurrency string
+	FromDate     time.Time
+	ToDate       time.Time
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+}
+
+type GetRevenueByCategoryRow struct {
+	Category     string
+	TotalSales   int64
+	TotalRevenue string
+}
+
+func (q *Queries) GetRevenueByCategory(ctx context.Context, arg GetRevenueByCategoryParams) ([]GetRevenueByCategoryRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRevenueByCategory,
+		arg.BaseCurrency,
+		arg.FromDate,
+		arg.ToDate,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRevenueByCategoryRow
+	for rows.Next() {
+		var i GetRevenueByCategoryRow
+		if err := rows.Scan(&i.Category, &i.TotalSales, &i.TotalRevenue); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRevenueByCurrency = `-- name: GetRevenueByCurrency :many
+WITH sold AS (
+    SELECT currency, COUNT(id) as total_sales, SUM(price) as total_revenue, SUM(discount_amount) as total_discount 
+    FROM sales 
+    WHERE deleted_at IS NULL AND sale_date >= $1 AND sale_date < $2 
+        AND ($3::int IS NULL OR employee_id IN (SELECT department_employees($3))) 
+        AND ($4::int IS NULL OR employee_id IN (SELECT manager_subtree($4))) 
+    GROUP BY currency
+), refunded AS (
+    SELECT s.currency, SUM(r.amount) as total_refunded 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    WHERE r.refund_date >= $1 AND r.refund_date < $2 
+        AND ($3::int IS NULL OR s.employee_id IN (SELECT department_employees($3))) 
+        AND ($4::int IS NULL OR s.employee_id IN (SELECT manager_subtree($4))) 
+    GROUP BY s.currency
+)
+SELECT 
+    COALESCE(sold.currency, refunded.currency)::varchar as currency,
+    COALESCE(sold.total_sales, 0) as total_sales,
+    COALESCE(sold.total_revenue, 0)::numeric as total_revenue,
+    COALESCE(sold.total_discount, 0)::numeric as total_discount,
+    COALESCE(refunded.total_refunded, 0)::numeric as total_refunded
+FROM sold 
+FULL JOIN refunded ON refunded.currency = sold.currency 
+ORDER BY 1
+`
+
+type GetRevenueByCurrencyParams struct {
+	FromDate     time.Time
+	ToDate       time.Time
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+}
+
+type GetRevenueByCurrencyRow struct {
+	Currency      string
+	TotalSales    int64
+	TotalRevenue  string
+	TotalDiscount string
+	TotalRefunded string
+}
+
+func (q *Queries) GetRevenueByCurrency(ctx context.Context, arg GetRevenueByCurrencyParams) ([]GetRevenueByCurrencyRow, error) {
+	rows, err := q.db.QueryContext(ctx, getRevenueByCurrency,
+		arg.FromDate,
+		arg.ToDate,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRevenueByCurrencyRow
+	for rows.Next() {
+		var i GetRevenueByCurrencyRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.TotalSales,
+			&i.TotalRevenue,
+			&i.TotalDiscount,
+			&i.TotalRefunded,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSale = `-- name: GetSale :one
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND id = $1
+`
+
+func (q *Queries) GetSale(ctx context.Context, id int32) (Sale, error) {
+	row := q.db.QueryRowContext(ctx, getSale, id)
+	var i Sale
+	err := row.Scan(
+		&i.ID,
+		&i.ProductName,
+		&i.Category,
+		&i.Currency,
+		&i.Price,
+		&i.SaleDate,
+		&i.EmployeeID,
+		&i.ProductID,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.DiscountPercent,
+		&i.PromotionID,
+		&i.ListPrice,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getSaleForUpdate = `-- name: GetSaleForUpdate :one
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND id = $1 
+FOR UPDATE
+`
+
+func (q *Queries) GetSaleForUpdate(ctx context.Context, id int32) (Sale, error) {
+	row := q.db.QueryRowContext(ctx, getSaleForUpdate, id)
+	var i Sale
+	err := row.Scan(
+		&i.ID,
+		&i.ProductName,
+		&i.Category,
+		&i.Currency,
+		&i.Price,
+		&i.SaleDate,
+		&i.EmployeeID,
+		&i.ProductID,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.DiscountPercent,
+		&i.PromotionID,
+		&i.ListPrice,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getSaleIncludingDeleted = `-- name: GetSaleIncludingDeleted :one
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE id = $1
+`
+
+func (q *Queries) GetSaleIncludingDeleted(ctx context.Context, id int32) (Sale, error) {
+	row := q.db.QueryRowContext(ctx, getSaleIncludingDeleted, id)
+	var i Sale
+	err := row.Scan(
+		&i.ID,
+		&i.ProductName,
+		&i.Category,
+		&i.Currency,
+		&i.Price,
+		&i.SaleDate,
+		&i.EmployeeID,
+		&i.ProductID,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.DiscountPercent,
+		&i.PromotionID,
+		&i.ListPrice,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getSaleIncludingDeletedForUpdate = `-- name: GetSaleIncludingDeletedForUpdate :one
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE id = $1 
+FOR UPDATE
+`
+
+func (q *Queries) GetSaleIncludingDeletedForUpdate(ctx context.Context, id int32) (Sale, error) {
+	row := q.db.QueryRowContext(ctx, getSaleIncludingDeletedForUpdate, id)
+	var i Sale
+	err := row.Scan(
+		&i.ID,
+		&i.ProductName,
+		&i.Category,
+		&i.Currency,
+		&i.Price,
+		&i.SaleDate,
+		&i.EmployeeID,
+		&i.ProductID,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.DiscountPercent,
+		&i.PromotionID,
+		&i.ListPrice,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const getSaleItems = `-- name: GetSaleItems :many
+SELECT id, sale_id, product_id, product_name, category, quantity, unit_price, discount_amount, line_total, tax_rate, net_amount, tax_amount, created_at 
+FROM sale_items 
+WHERE sale_id = $1 
+ORDER BY id
+`
+
+func (q *Queries) GetSaleItems(ctx context.Context, saleID int32) ([]SaleItem, error) {
+	rows, err := q.db.QueryContext(ctx, getSaleItems, saleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleItem
+	for rows.Next() {
+		var i SaleItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.SaleID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Category,
+			&i.Quantity,
+			&i.UnitPrice,
+			&i.DiscountAmount,
+			&i.LineTotal,
+			&i.TaxRate,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSaleItemsByEmployeeAndDateRange = `-- name: GetSaleItemsByEmployeeAndDateRange :many
+SELECT i.id, i.sale_id, i.product_id, i.product_name, i.category, i.quantity, i.unit_price, i.discount_amount, i.line_total, i.tax_rate, i.net_amount, i.tax_amount, i.created_at 
+FROM sale_items i 
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+WHERE s.employee_id = $1 AND s.sale_date >= $2 AND s.sale_date < $3 
+ORDER BY i.sale_id, i.id
+`
+
+type GetSaleItemsByEmployeeAndDateRangeParams struct {
+	EmployeeID int32
+	FromDate   time.Time
+	ToDate     time.Time
+}
+
+func (q *Queries) GetSaleItemsByEmployeeAndDateRange(ctx context.Context, arg GetSaleItemsByEmployeeAndDateRangeParams) ([]SaleItem, error) {
+	rows, err := q.db.QueryContext(ctx, getSaleItemsByEmployeeAndDateRange, arg.EmployeeID, arg.FromDate, arg.ToDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SaleItem
+	for rows.Next() {
+		var i SaleItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.SaleID,
+			&i.ProductID,
+			&i.ProductName,
+			&i.Category,
+			&i.Quantity,
+			&i.UnitPrice,
+			&i.DiscountAmount,
+			&i.LineTotal,
+			&i.TaxRate,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSales = `-- name: GetSales :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL 
+ORDER BY sale_date DESC
+`
+
+func (q *Queries) GetSales(ctx context.Context) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, getSales)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sale
+	for rows.Next() {
+		var i Sale
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Category,
+			&i.Currency,
+			&i.Price,
+			&i.SaleDate,
+			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesByCategory = `-- name: GetSalesByCategory :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND category = $1 
+ORDER BY sale_date DESC
+`
+
+func (q *Queries) GetSalesByCategory(ctx context.Context, category string) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesByCategory, category)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sale
+	for rows.Next() {
+		var i Sale
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Category,
+			&i.Currency,
+			&i.Price,
+			&i.SaleDate,
+			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesByDateRange = `-- name: GetSalesByDateRange :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND sale_date BETWEEN $1 AND $2 
+ORDER BY sale_date DESC
+`
+
+type GetSalesByDateRangeParams struct {
+	SaleDate   time.Time
+	SaleDate_2 time.Time
+}
+
+func (q *Queries) GetSalesByDateRange(ctx context.Context, arg GetSalesByDateRangeParams) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesByDateRange, arg.SaleDate, arg.SaleDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sale
+	for rows.Next() {
+		var i Sale
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Category,
+			&i.Currency,
+			&i.Price,
+			&i.SaleDate,
+			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesByEmployee = `-- name: GetSalesByEmployee :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND employee_id = $1 
+ORDER BY sale_date DESC
+`
+
+func (q *Queries) GetSalesByEmployee(ctx context.Context, employeeID int32) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesByEmployee, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sale
+	for rows.Next() {
+		var i Sale
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Category,
+			&i.Currency,
+			&i.Price,
+			&i.SaleDate,
+			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesByEmployeeAndDateRange = `-- name: GetSalesByEmployeeAndDateRange :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND employee_id = $1 AND sale_date >= $2 AND sale_date < $3 
+ORDER BY sale_date
+`
+
+type GetSalesByEmployeeAndDateRangeParams struct {
+	EmployeeID int32
+	SaleDate   time.Time
+	SaleDate_2 time.Time
+}
+
+func (q *Queries) GetSalesByEmployeeAndDateRange(ctx context.Context, arg GetSalesByEmployeeAndDateRangeParams) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesByEmployeeAndDateRange, arg.EmployeeID, arg.SaleDate, arg.SaleDate_2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sale
+	for rows.Next() {
+		var i Sale
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Category,
+			&i.Currency,
+			&i.Price,
+			&i.SaleDate,
+			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesStatsByEmployee = `-- name: GetSalesStatsByEmployee :many
+WITH converted AS (
+    SELECT employee_id, convert_amount(price, currency, $1::varchar, sale_date) as amount
+    FROM sales
+    WHERE deleted_at IS NULL AND sale_date >= $2 AND sale_date < $3
+), refunded AS (
+    SELECT s.employee_id, SUM(convert_amount(r.amount, s.currency, $1::varchar, r.refund_date)) as amount
+    FROM refunds r
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL
+    WHERE r.refund_date >= $2 AND r.refund_date < $3
+    GROUP BY s.employee_id
+)
+SELECT 
+    e.id,
+    e.name,
+    e.surname,
+    e.email,
+    COUNT(c.employee_id) as total_sales,
+    COALESCE(SUM(c.amount), 0)::numeric(12,2) as total_revenue,
+    COALESCE(MAX(rf.amount), 0)::numeric(12,2) as total_refunded,
+    COALESCE(AVG(c.amount), 0)::numeric(12,2) as avg_sale_value,
+    RANK() OVER (ORDER BY COALESCE(SUM(c.amount), 0) - COALESCE(MAX(rf.amount), 0) DESC) as rank
+FROM employees e
+LEFT JOIN converted c ON e.id = c.employee_id
+LEFT JOIN refunded rf ON e.id = rf.employee_id
+WHERE e.deleted_at IS NULL
+  AND ($4::int IS NULL OR e.id IN (SELECT department_employees($4))) 
+  AND ($5::int IS NULL OR e.id IN (SELECT manager_subtree($5)))
+GROUP BY e.id, e.name, e.surname, e.email
+ORDER BY rank, e.id
+`
+
+type GetSalesStatsByEmployeeParams struct {
+	BaseCurrency string
+	FromDate     time.Time
+	ToDate       time.Time
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+}
+
+type GetSalesStatsByEmployeeRow struct {
+	ID            int32
+	Name          string
+	Surname       string
+	Email         string
+	TotalSales    int64
+	TotalRevenue  string
+	TotalRefunded string
+	AvgSaleValue  string
+	Rank          int64
+}
+
+func (q *Queries) GetSalesStatsByEmployee(ctx context.Context, arg GetSalesStatsByEmployeeParams) ([]GetSalesStatsByEmployeeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesStatsByEmployee,
+		arg.BaseCurrency,
+		arg.FromDate,
+		arg.ToDate,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSalesStatsByEmployeeRow
+	for rows.Next() {
+		var i GetSalesStatsByEmployeeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Surname,
+			&i.Email,
+			&i.TotalSales,
+			&i.TotalRevenue,
+			&i.TotalRefunded,
+			&i.AvgSaleValue,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSalesTarget = `-- name: GetSalesTarget :one
+SELECT id, employee_id, period_type, period_start, amount, currency, created_at, updated_at 
+FROM sales_targets 
+WHERE id = $1
+`
+
+func (q *Queries) GetSalesTarget(ctx context.Context, id int32) (SalesTarget, error) {
+	row := q.db.QueryRowContext(ctx, getSalesTarget, id)
+	var i SalesTarget
+	err := row.Scan(
+		&i.ID,
+		&i.EmployeeID,
+		&i.PeriodType,
+		&i.PeriodStart,
+		&i.Amount,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getSalesTimeseries = `-- name: GetSalesTimeseries :many
+WITH buckets AS (
+    SELECT generate_series(
+        date_trunc($1::text, $2::timestamptz),
+        $3::timestamptz - interval '1 microsecond',
+        CASE WHEN $1 = 'quarter' THEN interval '3 months' ELSE ('1 ' || $1)::interval END
+    ) as bucket_start
+),
+filtered AS (
+    SELECT 
+        date_trunc($1, s.sale_date) as bucket_start,
+        CASE $4::text 
+            WHEN 'employee' THEN COALESCE(s.employee_id::text, '') 
+            WHEN 'category' THEN l.category 
+            WHEN 'currency' THEN s.currency 
+            WHEN 'department' THEN COALESCE(e.department_id::text, '') 
+            ELSE '' 
+        END as group_key,
+        CASE $4::text 
+            WHEN 'employee' THEN COALESCE(e.name || ' ' || e.surname, 'Unassigned') 
+            WHEN 'department' THEN COALESCE(d.name, '') 
+            ELSE '' 
+        END as group_label,
+        CASE WHEN $4 = 'currency' THEN l.amount 
+            ELSE convert_amount(l.amount, s.currency, $5::varchar, s.sale_date) 
+        END as amount
+    FROM sales s
+    -- Sales of the unassigned pool have no employee
+    LEFT JOIN employees e ON e.id = s.employee_id
+    LEFT JOIN departments d ON d.id = e.department_id
+    -- Grouped by category a sale counts once in each category of its lines, with their total
+    CROSS JOIN LATERAL (
+        SELECT s.category, s.price as amount WHERE $4 <> 'category'
+        UNION ALL
+        SELECT i.category, SUM(i.line_total) FROM sale_items i 
+        WHERE i.sale_id = s.id AND $4 = 'category' 
+        GROUP BY i.category
+    ) l
+    WHERE s.deleted_at IS NULL AND s.sale_date >= $2 AND s.sale_date < $3
+        AND ($6::int IS NULL OR s.employee_id IN (SELECT department_employees($6))) 
+        AND ($7::int IS NULL OR s.employee_id IN (SELECT manager_subtree($7)))
+),
+groups AS (
+    SELECT DISTINCT group_key, group_label FROM filtered
+    UNION
+    SELECT '', '' WHERE $4 = ''
+)
+SELECT 
+    b.bucket_start::timestamptz as bucket_start,
+    g.group_key::text as group_key,
+    g.group_label::text as group_label,
+    COUNT(f.bucket_start) as total_sales,
+    COALESCE(SUM(f.amount), 0)::numeric(12,2) as total_revenue
+FROM buckets b
+CROSS JOIN groups g
+LEFT JOIN filtered f ON f.bucket_start = b.bucket_start AND f.group_key = g.group_key
+GROUP BY b.bucket_start, g.group_key, g.group_label
+ORDER BY g.group_key, b.bucket_start
+`
+
+type GetSalesTimeseriesParams struct {
+	BucketInterval string
+	FromDate       time.Time
+	ToDate         time.Time
+	GroupBy        string
+	BaseCurrency   string
+	DepartmentID   sql.NullInt32
+	ManagerID      sql.NullInt32
+}
+
+type GetSalesTimeseriesRow struct {
+	BucketStart  time.Time
+	GroupKey     string
+	GroupLabel   string
+	TotalSales   int64
+	TotalRevenue string
+}
+
+func (q *Queries) GetSalesTimeseries(ctx context.Context, arg GetSalesTimeseriesParams) ([]GetSalesTimeseriesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getSalesTimeseries,
+		arg.BucketInterval,
+		arg.FromDate,
+		arg.ToDate,
+		arg.GroupBy,
+		arg.BaseCurrency,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSalesTimeseriesRow
+	for rows.Next() {
+		var i GetSalesTimeseriesRow
+		if err := rows.Scan(
+			&i.BucketStart,
+			&i.GroupKey,
+			&i.GroupLabel,
+			&i.TotalSales,
+			&i.TotalRevenue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTaxBreakdown = `-- name: GetTaxBreakdown :many
+SELECT 
+    s.currency,
+    i.tax_rate,
+    COALESCE(SUM(i.net_amount), 0)::numeric(12,2) as net_amount,
+    COALESCE(SUM(i.tax_amount), 0)::numeric(12,2) as tax_amount,
+    COALESCE(SUM(i.line_total), 0)::numeric(12,2) as gross_amount
+FROM sale_items i 
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+WHERE s.sale_date >= $1 AND s.sale_date < $2 
+    AND ($3::int IS NULL OR s.employee_id = $3) 
+    AND ($4::int IS NULL OR s.employee_id IN (SELECT department_employees($4))) 
+    AND ($5::int IS NULL OR s.employee_id IN (SELECT manager_subtree($5))) 
+GROUP BY s.currency, i.tax_rate 
+ORDER BY s.currency, i.tax_rate DESC
+`
+
+type GetTaxBreakdownParams struct {
+	FromDate     time.Time
+	ToDate       time.Time
+	EmployeeID   sql.NullInt32
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+}
+
+type GetTaxBreakdownRow struct {
+	Currency    string
+	TaxRate     string
+	NetAmount   string
+	TaxAmount   string
+	GrossAmount string
+}
+
+func (q *Queries) GetTaxBreakdown(ctx context.Context, arg GetTaxBreakdownParams) ([]GetTaxBreakdownRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTaxBreakdown,
+		arg.FromDate,
+		arg.ToDate,
+		arg.EmployeeID,
+		arg.DepartmentID,
+		arg.ManagerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTaxBreakdownRow
+	for rows.Next() {
+		var i GetTaxBreakdownRow
+		if err := rows.Scan(
+			&i.Currency,
+			&i.TaxRate,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.GrossAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopProducts = `-- name: GetTopProducts :many
+SELECT 
+    i.product_id,
+    COALESCE(p.sku, '')::varchar as sku,
+    COALESCE(p.name, i.product_name)::varchar as product_name,
+    COUNT(DISTINCT s.id) as total_sales,
+    SUM(i.quantity)::bigint as total_quantity,
+    COALESCE(SUM(convert_amount(i.line_total, s.currency, $1::varchar, s.sale_date)), 0)::numeric(12,2) as total_revenue
+FROM sale_items i
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL
+LEFT JOIN products p ON p.id = i.product_id
+WHERE s.sale_date >= $2 AND s.sale_date < $3
+    AND ($4::int IS NULL OR s.employee_id IN (SELECT department_employees($4))) 
+    AND ($5::int IS NULL OR s.employee_id IN (SELECT manager_subtree($5)))
+GROUP BY i.product_id, p.sku, COALESCE(p.name, i.product_name)
+ORDER BY total_revenue DESC, total_quantity DESC, product_name
+LIMIT $6::int
+`
+
+type GetTopProductsParams struct {
+	BaseCurrency string
+	FromDate     time.Time
+	ToDate       time.Time
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+	ProductLimit int32
+}
+
+type GetTopProductsRow struct {
+	ProductID     sql.NullInt32
+	Sku           string
+	ProductName   string
+	TotalSales    int64
+	TotalQuantity int64
+	TotalRevenue  string
+}
+
+func (q *Queries) GetTopProducts(ctx context.Context, arg GetTopProductsParams) ([]GetTopProductsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTopProducts,
+		arg.BaseCurrency,
+		arg.FromDate,
+		arg.ToDate,
+		arg.DepartmentID,
+		arg.ManagerID,
+		arg.ProductLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopProductsRow
+	for rows.Next() {
+		var i GetTopProductsRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.Sku,
+			&i.ProductName,
+			&i.TotalSales,
+			&i.TotalQuantity,
+			&i.TotalRevenue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isInManagerSubtree = `-- name: IsInManagerSubtree :one
+SELECT EXISTS ( 
+    SELECT 1 FROM manager_subtree($1) AS m(id) WHERE m.id = $2::int 
+)
+`
+
+type IsInManagerSubtreeParams struct {
+	ManagerID  int32
+	EmployeeID int32
+}
+
+func (q *Queries) IsInManagerSubtree(ctx context.Context, arg IsInManagerSubtreeParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isInManagerSubtree, arg.ManagerID, arg.EmployeeID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isSubDepartment = `-- name: IsSubDepartment :one
+-- Whether department_id is root_id or one of the teams below it
+WITH RECURSIVE tree AS ( 
+    SELECT id FROM departments WHERE id = $1 
+    UNION 
+    SELECT d.id FROM departments d JOIN tree t ON d.parent_id = t.id 
+) 
+SELECT EXISTS (SELECT 1 FROM tree WHERE id = $2::int)
+`
+
+type IsSubDepartmentParams struct {
+	RootID       int32
+	DepartmentID int32
+}
+
+func (q *Queries) IsSubDepartment(ctx context.Context, arg IsSubDepartmentParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isSubDepartment, arg.RootID, arg.DepartmentID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listAuditLog = `-- name: ListAuditLog :many
+-- Latest entries first, entity and entity_id are optional filters
+SELECT id, entity, entity_id, action, actor, request_id, before, after, created_at 
+FROM audit_log 
+WHERE ($1::text IS NULL OR entity = $1) 
+  AND ($2::int IS NULL OR entity_id = $2) 
+  AND ($3::int IS NULL OR id < $3) 
+ORDER BY id DESC 
+LIMIT $4
+`
+
+type ListAuditLogParams struct {
+	Entity    sql.NullString
+	EntityID  sql.NullInt32
+	CursorID  sql.NullInt32
+	PageLimit int32
+}
+
+func (q *Queries) ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditLog,
+		arg.Entity,
+		arg.EntityID,
+		arg.CursorID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Entity,
+			&i.EntityID,
+			&i.Action,
+			&i.Actor,
+			&i.RequestID,
+			&i.Before,
+			&i.After,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCustomers = `-- name: ListCustomers :many
+SELECT c.id, c.name, c.company, c.tax_id, c.email, c.address, c.created_at, c.updated_at 
+FROM customers c 
+WHERE ($1::varchar IS NULL 
+    OR c.name ILIKE '%' || $1 || '%' 
+    OR c.company ILIKE '%' || $1 || '%' 
+    OR c.tax_id ILIKE '%' || $1 || '%' 
+    OR c.email ILIKE '%' || $1 || '%') 
+  AND ($2::int IS NULL OR CASE $3::varchar 
+    WHEN 'id' THEN c.id > $2 
+    WHEN '-id' THEN c.id < $2 
+    WHEN 'name' THEN (c.name, c.id) > ($4::varchar, $2) 
+    WHEN '-name' THEN (c.name, c.id) < ($4, $2) 
+  END) 
+ORDER BY 
+  CASE WHEN $3 = 'name' THEN c.name END ASC, 
+  CASE WHEN $3 = '-name' THEN c.name END DESC, 
+  CASE WHEN $3 IN ('id', 'name') THEN c.id END ASC, 
+  CASE WHEN $3 IN ('-id', '-name') THEN c.id END DESC 
+LIMIT $5::int
+`
+
+type ListCustomersParams struct {
+	Search      sql.NullString
+	CursorID    sql.NullInt32
+	Sort        string
+	CursorValue sql.NullString
+	PageLimit   int32
+}
+
+func (q *Queries) ListCustomers(ctx context.Context, arg ListCustomersParams) ([]Customer, error) {
+	rows, err := q.db.QueryContext(ctx, listCustomers,
+		arg.Search,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorValue,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Customer
+	for rows.Next() {
+		var i Customer
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Company,
+			&i.TaxID,
+			&i.Email,
+			&i.Address,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEmployees = `-- name: ListEmployees :many
+SELECT 
+    e.id, 
+    e.name, 
+    e.surname, 
+    e.email, 
+    e.department_id, 
+    e.manager_id, 
+    e.hire_date, 
+    e.termination_date, 
+    e.job_title, 
+    e.status, 
+    e.created_at, 
+    e.updated_at, 
+    e.deleted_at, 
+    summary.total_sales, 
+    summary.total_revenue, 
+    summary.last_sale_date, 
+    summary.missing_currencies 
+FROM employees e 
+CROSS JOIN LATERAL ( 
+    SELECT 
+        COUNT(*) as total_sales, 
+        COALESCE(SUM(convert_amount(s.price, s.currency, $1::varchar, s.sale_date)), 0)::numeric(12,2) as total_revenue, 
+        MAX(s.sale_date) as last_sale_date, 
+        COALESCE(string_agg(DISTINCT s.currency, ',') FILTER (WHERE convert_amount(s.price, s.currency, $1::varchar, s.sale_date) IS NULL), '')::text as missing_currencies 
+    FROM sales s 
+    WHERE s.deleted_at IS NULL AND s.employee_id = e.id AND $2::bool 
+) summary 
+WHERE ($3::varchar IS NULL 
+    OR e.name ILIKE '%' || $3 || '%' 
+    OR e.surname ILIKE '%' || $3 || '%' 
+    OR e.email ILIKE '%' || $3 || '%') 
+  AND ($4::int IS NULL OR e.id IN (SELECT department_employees($4))) 
+  AND ($5::int IS NULL OR e.id IN (SELECT manager_subtree($5))) 
+  AND ($6::varchar IS NULL OR e.status = $6) 
+  AND ($7::bool OR e.deleted_at IS NULL) 
+  AND ($8::int IS NULL OR CASE $9::varchar 
+    WHEN 'id' THEN e.id > $8 
+    WHEN '-id' THEN e.id < $8 
+    WHEN 'name' THEN (e.name, e.id) > ($10::varchar, $8) 
+    WHEN '-name' THEN (e.name, e.id) < ($10, $8) 
+    WHEN 'surname' THEN (e.surname, e.id) > ($10, $8) 
+    WHEN '-surname' THEN (e.surname, e.id) < ($10, $8) 
+    WHEN 'email' THEN (e.email, e.id) > ($10, $8) 
+    WHEN '-email' THEN (e.email, e.id) < ($10, $8) 
+  END) 
+ORDER BY 
+  CASE WHEN $9 = 'name' THEN e.name END ASC, 
+  CASE WHEN $9 = '-name' THEN e.name END DESC, 
+  CASE WHEN $9 = 'surname' THEN e.surname END ASC, 
+  CASE WHEN $9 = '-surname' THEN e.surname END DESC, 
+  CASE WHEN $9 = 'email' THEN e.email END ASC, 
+  CASE WHEN $9 = '-email' THEN e.email END DESC, 
+  CASE WHEN $9 IN ('id', 'name', 'surname', 'email') THEN e.id END ASC, 
+  CASE WHEN $9 IN ('-id', '-name', '-surname', '-email') THEN e.id END DESC 
+LIMIT $11::int
+`
+
+type ListEmployeesParams struct {
+	BaseCurrency   string
+	WithSales      bool
+	Search         sql.NullString
+	DepartmentID   sql.NullInt32
+	ManagerID      sql.NullInt32
+	Status         sql.NullString
+	IncludeDeleted bool
+	CursorID       sql.NullInt32
+	Sort           string
+	CursorValue    sql.NullString
+	PageLimit      int32
+}
+
+type ListEmployeesRow struct {
+	ID                int32
+	Name              string
+	Surname           string
+	Email             string
+	DepartmentID      sql.NullInt32
+	ManagerID         sql.NullInt32
+	HireDate          time.Time
+	TerminationDate   sql.NullTime
+	JobTitle          sql.NullString
+	Status            string
+	CreatedAt         sql.NullTime
+	UpdatedAt         sql.NullTime
+	DeletedAt         sql.NullTime
+	TotalSales        int64
+	TotalRevenue      string
+	LastSaleDate      sql.NullTime
+	MissingCurrencies string
+}
+
+func (q *Queries) ListEmployees(ctx context.Context, arg ListEmployeesParams) ([]ListEmployeesRow, error) {
+	rows, err := q.db.QueryContext(ctx, listEmployees,
+		arg.BaseCurrency,
+		arg.WithSales,
+		arg.Search,
+		arg.DepartmentID,
+		arg.ManagerID,
+		arg.Status,
+		arg.IncludeDeleted,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorValue,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListEmployeesRow
+	for rows.Next() {
+		var i ListEmployeesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Surname,
+			&i.Email,
+			&i.DepartmentID,
+			&i.ManagerID,
+			&i.HireDate,
+			&i.TerminationDate,
+			&i.JobTitle,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.TotalSales,
+			&i.TotalRevenue,
+			&i.LastSaleDate,
+			&i.MissingCurrencies,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOffboardings = `-- name: ListOffboardings :many
+SELECT id, employee_id, employee_name, employee_surname, employee_email, reassigned_to, from_date, to_date, category, customer_id, sales_reassigned, action, performed_by, created_at 
+FROM offboardings 
+WHERE $1::int IS NULL OR employee_id = $1 
+ORDER BY created_at DESC, id DESC
+`
+
+func (q *Queries) ListOffboardings(ctx context.Context, employeeID sql.NullInt32) ([]Offboarding, error) {
+	rows, err := q.db.QueryContext(ctx, listOffboardings, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Offboarding
+	for rows.Next() {
+		var i Offboarding
+		if err := rows.Scan(
+			&i.ID,
+			&i.EmployeeID,
+			&i.EmployeeName,
+			&i.EmployeeSurname,
+			&i.EmployeeEmail,
+			&i.ReassignedTo,
+			&i.FromDate,
+			&i.ToDate,
+			&i.Category,
+			&i.CustomerID,
+			&i.SalesReassigned,
+			&i.Action,
+			&i.PerformedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRefunds = `-- name: ListRefunds :many
+SELECT r.id, r.sale_id, r.amount, r.reason, r.refund_date, r.created_at 
+FROM refunds r 
+JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+WHERE ($1::int IS NULL OR r.sale_id = $1) 
+  AND ($2::int IS NULL OR s.employee_id = $2) 
+  AND ($3::timestamptz IS NULL OR r.refund_date >= $3) 
+  AND ($4::timestamptz IS NULL OR r.refund_date < $4) 
+ORDER BY r.refund_date, r.id
+`
+
+type ListRefundsParams struct {
+	SaleID     sql.NullInt32
+	EmployeeID sql.NullInt32
+	FromDate   sql.NullTime
+	ToDate     sql.NullTime
+}
+
+func (q *Queries) ListRefunds(ctx context.Context, arg ListRefundsParams) ([]Refund, error) {
+	rows, err := q.db.QueryContext(ctx, listRefunds,
+		arg.SaleID,
+		arg.EmployeeID,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Refund
+	for rows.Next() {
+		var i Refund
+		if err := rows.Scan(
+			&i.ID,
+			&i.SaleID,
+			&i.Amount,
+			&i.Reason,
+			&i.RefundDate,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSales = `-- name: ListSales :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE ($1::int IS NULL OR employee_id = $1) 
+  AND (NOT $2::bool OR employee_id IS NULL) 
+  AND ($3::bool OR deleted_at IS NULL) 
+  AND ($4::varchar IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = sales.id AND i.category = $4)) 
+  AND ($5::varchar IS NULL OR currency = $5) 
+  AND ($6::numeric IS NULL OR price >= $6) 
+  AND ($7::numeric IS NULL OR price <= $7) 
+  AND ($8::timestamptz IS NULL OR sale_date >= $8) 
+  AND ($9::timestamptz IS NULL OR sale_date < $9) 
+  AND ($10::int IS NULL OR customer_id = $10) 
+  AND ($11::int IS NULL OR CASE $12::varchar 
+    WHEN 'sale_date' THEN (sale_date, id) > ($13::timestamptz, $11) 
+    WHEN '-sale_date' THEN (sale_date, id) < ($13, $11) 
+    WHEN 'price' THEN (price, id) > ($14::numeric, $11) 
+    WHEN '-price' THEN (price, id) < ($14, $11) 
+    WHEN 'id' THEN id > $11 
+    WHEN '-id' THEN id < $11 
+  END) 
+ORDER BY 
+  CASE WHEN $12 = 'sale_date' THEN sale_date END ASC, 
+  CASE WHEN $12 = '-sale_date' THEN sale_date END DESC, 
+  CASE WHEN $12 = 'price' THEN price END ASC, 
+  CASE WHEN $12 = '-price' THEN price END DESC, 
+  CASE WHEN $12 IN ('sale_date', 'price', 'id') THEN id END ASC, 
+  CASE WHEN $12 IN ('-sale_date', '-price', '-id') THEN id END DESC 
+LIMIT $15::int
+`
+
+type ListSalesParams struct {
+	EmployeeID     sql.NullInt32
+	Unassigned     bool
+	IncludeDeleted bool
+	Category       sql.NullString
+	Currency       sql.NullString
+	MinPrice       sql.NullString
+	MaxPrice       sql.NullString
+	FromDate       sql.NullTime
+	ToDate         sql.NullTime
+	CustomerID     sql.NullInt32
+	CursorID       sql.NullInt32
+	Sort           string
+	CursorDate     sql.NullTime
+	CursorPrice    sql.NullString
+	PageLimit      int32
+}
+
+func (q *Queries) ListSales(ctx context.Context, arg ListSalesParams) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, listSales,
+		arg.EmployeeID,
+		arg.Unassigned,
+		arg.IncludeDeleted,
+		arg.Category,
+		arg.Currency,
+		arg.MinPrice,
+		arg.MaxPrice,
+		arg.FromDate,
+		arg.ToDate,
+		arg.CustomerID,
+		arg.CursorID,
+		arg.Sort,
+		arg.CursorDate,
+		arg.CursorPrice,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sale
+	for rows.Next() {
 		var i Sale
 		if err := rows.Scan(
 			&i.ID,
@@ -261,8 +3924,17 @@ func (q *Queries) GetSales(ctx context.Context) ([]Sale, error) {
 			&i.Price,
 			&i.SaleDate,
 			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -277,15 +3949,207 @@ func (q *Queries) GetSales(ctx context.Context) ([]Sale, error) {
 	return items, nil
 }
 
-const getSalesByCategory = `-- name: GetSalesByCategory :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
-FROM sales 
-WHERE category = $1 
-ORDER BY sale_date DESC
+const listSalesTargets = `-- name: ListSalesTargets :many
+SELECT id, employee_id, period_type, period_start, amount, currency, created_at, updated_at 
+FROM sales_targets 
+WHERE ($1::int IS NULL OR employee_id = $1) 
+  AND ($2::varchar IS NULL OR period_type = $2) 
+  AND ($3::date IS NULL OR period_start >= $3) 
+  AND ($4::date IS NULL OR period_start < $4) 
+ORDER BY period_start, period_type, employee_id
 `
 
-func (q *Queries) GetSalesByCategory(ctx context.Context, category string) ([]Sale, error) {
-	rows, err := q.db.QueryContext(ctx, getSalesByCategory, category)
+type ListSalesTargetsParams struct {
+	EmployeeID sql.NullInt32
+	PeriodType sql.NullString
+	FromDate   sql.NullTime
+	ToDate     sql.NullTime
+}
+
+func (q *Queries) ListSalesTargets(ctx context.Context, arg ListSalesTargetsParams) ([]SalesTarget, error) {
+	rows, err := q.db.QueryContext(ctx, listSalesTargets,
+		arg.EmployeeID,
+		arg.PeriodType,
+		arg.FromDate,
+		arg.ToDate,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SalesTarget
+	for rows.Next() {
+		var i SalesTarget
+		if err := rows.Scan(
+			&i.ID,
+			&i.EmployeeID,
+			&i.PeriodType,
+			&i.PeriodStart,
+			&i.Amount,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockDepartmentChanges = `-- name: LockDepartmentChanges :exec
+-- Serializes department moves until the end of the transaction, so that two of them cannot make a cycle
+SELECT pg_advisory_xact_lock(hashtext('departments.parent_id'))
+`
+
+func (q *Queries) LockDepartmentChanges(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockDepartmentChanges)
+	return err
+}
+
+const lockManagerChanges = `-- name: LockManagerChanges :exec
+-- Serializes manager changes until the end of the transaction, so that two of them cannot make a cycle
+SELECT pg_advisory_xact_lock(hashtext('employees.manager_id'))
+`
+
+func (q *Queries) LockManagerChanges(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, lockManagerChanges)
+	return err
+}
+
+const purgeEmployees = `-- name: PurgeEmployees :many
+-- Employees still referenced by sales are kept until the sales are purged or reassigned
+DELETE FROM employees 
+WHERE deleted_at < $1::timestamptz 
+  AND NOT EXISTS (SELECT 1 FROM sales s WHERE s.employee_id = employees.id) 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at
+`
+
+func (q *Queries) PurgeEmployees(ctx context.Context, deletedBefore time.Time) ([]Employee, error) {
+	rows, err := q.db.QueryContext(ctx, purgeEmployees, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Employee
+	for rows.Next() {
+		var i Employee
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Surname,
+			&i.Email,
+			&i.DepartmentID,
+			&i.ManagerID,
+			&i.HireDate,
+			&i.TerminationDate,
+			&i.JobTitle,
+			&i.Status,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const purgeSales = `-- name: PurgeSales :many
+-- Lines and refunds of the sales are removed with them
+DELETE FROM sales 
+WHERE deleted_at < $1::timestamptz 
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at
+`
+
+func (q *Queries) PurgeSales(ctx context.Context, deletedBefore time.Time) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, purgeSales, deletedBefore)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Sale
+	for rows.Next() {
+		var i Sale
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductName,
+			&i.Category,
+			&i.Currency,
+			&i.Price,
+			&i.SaleDate,
+			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reassignEmployeeSales = `-- name: ReassignEmployeeSales :many
+-- Moves the sales of an employee, or only those of a date range, category or customer,
+-- to another employee or to the unassigned pool when to_employee_id is NULL.
+-- The sales are returned as they were before the move.
+UPDATE sales 
+SET employee_id = $1, updated_at = CURRENT_TIMESTAMP 
+FROM sales old 
+WHERE old.id = sales.id AND sales.employee_id = $2 AND sales.deleted_at IS NULL 
+  AND ($3::timestamptz IS NULL OR sales.sale_date >= $3) 
+  AND ($4::timestamptz IS NULL OR sales.sale_date < $4) 
+  AND ($5::varchar IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = sales.id AND i.category = $5)) 
+  AND ($6::int IS NULL OR sales.customer_id = $6) 
+RETURNING old.id, old.product_name, old.category, old.currency, old.price, old.sale_date, old.employee_id, old.product_id, old.net_amount, old.tax_amount, old.discount_amount, old.discount_percent, old.promotion_id, old.list_price, old.customer_id, old.created_at, old.updated_at, old.deleted_at
+`
+
+type ReassignEmployeeSalesParams struct {
+	ToEmployeeID sql.NullInt32
+	EmployeeID   int32
+	FromDate     sql.NullTime
+	ToDate       sql.NullTime
+	Category     sql.NullString
+	CustomerID   sql.NullInt32
+}
+
+func (q *Queries) ReassignEmployeeSales(ctx context.Context, arg ReassignEmployeeSalesParams) ([]Sale, error) {
+	rows, err := q.db.QueryContext(ctx, reassignEmployeeSales,
+		arg.ToEmployeeID,
+		arg.EmployeeID,
+		arg.FromDate,
+		arg.ToDate,
+		arg.Category,
+		arg.CustomerID,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -301,8 +4165,17 @@ func (q *Queries) GetSalesByCategory(ctx context.Context, category string) ([]Sa
 			&i.Price,
 			&i.SaleDate,
 			&i.EmployeeID,
+			&i.ProductID,
+			&i.NetAmount,
+			&i.TaxAmount,
+			&i.DiscountAmount,
+			&i.DiscountPercent,
+			&i.PromotionID,
+			&i.ListPrice,
+			&i.CustomerID,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.DeletedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -317,167 +4190,440 @@ func (q *Queries) GetSalesByCategory(ctx context.Context, category string) ([]Sa
 	return items, nil
 }
 
-const getSalesByDateRange = `-- name: GetSalesByDateRange :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
-FROM sales 
-WHERE sale_date BETWEEN $1 AND $2 
-ORDER BY sale_date DESC
+const restoreEmployee = `-- name: RestoreEmployee :one
+UPDATE employees 
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NOT NULL 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at
+`
+
+func (q *Queries) RestoreEmployee(ctx context.Context, id int32) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, restoreEmployee, id)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const restoreSale = `-- name: RestoreSale :one
+UPDATE sales 
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NOT NULL 
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at
+`
+
+func (q *Queries) RestoreSale(ctx context.Context, id int32) (Sale, error) {
+	row := q.db.QueryRowContext(ctx, restoreSale, id)
+	var i Sale
+	err := row.Scan(
+		&i.ID,
+		&i.ProductName,
+		&i.Category,
+		&i.Currency,
+		&i.Price,
+		&i.SaleDate,
+		&i.EmployeeID,
+		&i.ProductID,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.DiscountPercent,
+		&i.PromotionID,
+		&i.ListPrice,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setCommissionAssignment = `-- name: SetCommissionAssignment :one
+INSERT INTO commission_assignments (employee_id, plan_id, valid_from) 
+VALUES ($1, $2, $3) 
+ON CONFLICT (employee_id, valid_from) DO UPDATE SET plan_id = EXCLUDED.plan_id 
+RETURNING employee_id, plan_id, valid_from, created_at
+`
+
+type SetCommissionAssignmentParams struct {
+	EmployeeID int32
+	PlanID     int32
+	ValidFrom  time.Time
+}
+
+func (q *Queries) SetCommissionAssignment(ctx context.Context, arg SetCommissionAssignmentParams) (CommissionAssignment, error) {
+	row := q.db.QueryRowContext(ctx, setCommissionAssignment, arg.EmployeeID, arg.PlanID, arg.ValidFrom)
+	var i CommissionAssignment
+	err := row.Scan(
+		&i.EmployeeID,
+		&i.PlanID,
+		&i.ValidFrom,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const setEmployeeDepartment = `-- name: SetEmployeeDepartment :one
+UPDATE employees 
+SET department_id = $2, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at
+`
+
+type SetEmployeeDepartmentParams struct {
+	ID           int32
+	DepartmentID sql.NullInt32
+}
+
+func (q *Queries) SetEmployeeDepartment(ctx context.Context, arg SetEmployeeDepartmentParams) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, setEmployeeDepartment, arg.ID, arg.DepartmentID)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setEmployeeManager = `-- name: SetEmployeeManager :one
+UPDATE employees 
+SET manager_id = $2, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at
+`
+
+type SetEmployeeManagerParams struct {
+	ID        int32
+	ManagerID sql.NullInt32
+}
+
+func (q *Queries) SetEmployeeManager(ctx context.Context, arg SetEmployeeManagerParams) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, setEmployeeManager, arg.ID, arg.ManagerID)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const setSalesTarget = `-- name: SetSalesTarget :one
+INSERT INTO sales_targets (employee_id, period_type, period_start, amount, currency) 
+VALUES ($1, $2, $3, $4, $5) 
+ON CONFLICT (employee_id, period_type, period_start) 
+DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency, updated_at = CURRENT_TIMESTAMP 
+RETURNING id, employee_id, period_type, period_start, amount, currency, created_at, updated_at
+`
+
+type SetSalesTargetParams struct {
+	EmployeeID  int32
+	PeriodType  string
+	PeriodStart time.Time
+	Amount      money.Amount
+	Currency    string
+}
+
+func (q *Queries) SetSalesTarget(ctx context.Context, arg SetSalesTargetParams) (SalesTarget, error) {
+	row := q.db.QueryRowContext(ctx, setSalesTarget,
+		arg.EmployeeID,
+		arg.PeriodType,
+		arg.PeriodStart,
+		arg.Amount,
+		arg.Currency,
+	)
+	var i SalesTarget
+	err := row.Scan(
+		&i.ID,
+		&i.EmployeeID,
+		&i.PeriodType,
+		&i.PeriodStart,
+		&i.Amount,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const terminateEmployee = `-- name: TerminateEmployee :one
+-- Ends the employment on the given day, the employee can no longer make sales
+UPDATE employees 
+SET termination_date = $2, status = 'inactive', updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at
+`
+
+type TerminateEmployeeParams struct {
+	ID              int32
+	TerminationDate time.Time
+}
+
+func (q *Queries) TerminateEmployee(ctx context.Context, arg TerminateEmployeeParams) (Employee, error) {
+	row := q.db.QueryRowContext(ctx, terminateEmployee, arg.ID, arg.TerminationDate)
+	var i Employee
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Surname,
+		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateCategory = `-- name: UpdateCategory :one
+UPDATE categories 
+SET name = $2, description = $3, tax_rate = $4, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, description, tax_rate, created_at, updated_at
+`
+
+type UpdateCategoryParams struct {
+	ID          int32
+	Name        string
+	Description sql.NullString
+	TaxRate     string
+}
+
+func (q *Queries) UpdateCategory(ctx context.Context, arg UpdateCategoryParams) (Category, error) {
+	row := q.db.QueryRowContext(ctx, updateCategory,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.TaxRate,
+	)
+	var i Category
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.TaxRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateCommissionPlan = `-- name: UpdateCommissionPlan :one
+UPDATE commission_plans 
+SET name = $2, description = $3, currency = $4, base_rate = $5, accelerator_rate = $6, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at
 `
 
-type GetSalesByDateRangeParams struct {
-	SaleDate   time.Time
-	SaleDate_2 time.Time
+type UpdateCommissionPlanParams struct {
+	ID              int32
+	Name            string
+	Description     sql.NullString
+	Currency        string
+	BaseRate        string
+	AcceleratorRate string
 }
 
-func (q *Queries) GetSalesByDateRange(ctx context.Context, arg GetSalesByDateRangeParams) ([]Sale, error) {
-	rows, err := q.db.QueryContext(ctx, getSalesByDateRange, arg.SaleDate, arg.SaleDate_2)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Sale
-	for rows.Next() {
-		var i Sale
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProductName,
-			&i.Category,
-			&i.Currency,
-			&i.Price,
-			&i.SaleDate,
-			&i.EmployeeID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) UpdateCommissionPlan(ctx context.Context, arg UpdateCommissionPlanParams) (CommissionPlan, error) {
+	row := q.db.QueryRowContext(ctx, updateCommissionPlan,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Currency,
+		arg.BaseRate,
+		arg.AcceleratorRate,
+	)
+	var i CommissionPlan
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.Currency,
+		&i.BaseRate,
+		&i.AcceleratorRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
-const getSalesByEmployee = `-- name: GetSalesByEmployee :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
-FROM sales 
-WHERE employee_id = $1 
-ORDER BY sale_date DESC
+const updateCurrency = `-- name: UpdateCurrency :one
+UPDATE currencies 
+SET name = $2, minor_units = $3, symbol = $4, enabled = $5, updated_at = CURRENT_TIMESTAMP 
+WHERE code = $1 
+RETURNING code, name, minor_units, symbol, enabled, created_at, updated_at
 `
 
-func (q *Queries) GetSalesByEmployee(ctx context.Context, employeeID int32) ([]Sale, error) {
-	rows, err := q.db.QueryContext(ctx, getSalesByEmployee, employeeID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Sale
-	for rows.Next() {
-		var i Sale
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProductName,
-			&i.Category,
-			&i.Currency,
-			&i.Price,
-			&i.SaleDate,
-			&i.EmployeeID,
-			&i.CreatedAt,
-			&i.UpdatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type UpdateCurrencyParams struct {
+	Code       string
+	Name       string
+	MinorUnits int16
+	Symbol     string
+	Enabled    bool
 }
 
-const getSalesStatsByEmployee = `-- name: GetSalesStatsByEmployee :many
-SELECT 
-    e.id,
-    e.name,
-    e.surname,
-    e.email,
-    COUNT(s.id) as total_sales,
-    COALESCE(SUM(s.price), 0) as total_revenue,
-    COALESCE(AVG(s.price), 0) as avg_sale_value
-FROM employees e
-LEFT JOIN sales s ON e.id = s.employee_id
-GROUP BY e.id, e.name, e.surname, e.email
-ORDER BY total_revenue DESC
+func (q *Queries) UpdateCurrency(ctx context.Context, arg UpdateCurrencyParams) (Currency, error) {
+	row := q.db.QueryRowContext(ctx, updateCurrency,
+		arg.Code,
+		arg.Name,
+		arg.MinorUnits,
+		arg.Symbol,
+		arg.Enabled,
+	)
+	var i Currency
+	err := row.Scan(
+		&i.Code,
+		&i.Name,
+		&i.MinorUnits,
+		&i.Symbol,
+		&i.Enabled,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateCustomer = `-- name: UpdateCustomer :one
+UPDATE customers 
+SET name = $2, company = $3, tax_id = $4, email = $5, address = $6, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, company, tax_id, email, address, created_at, updated_at
 `
 
-type GetSalesStatsByEmployeeRow struct {
-	ID           int32
-	Name         string
-	Surname      string
-	Email        string
-	TotalSales   int64
-	TotalRevenue interface{}
-	AvgSaleValue interface{}
+type UpdateCustomerParams struct {
+	ID      int32
+	Name    string
+	Company sql.NullString
+	TaxID   sql.NullString
+	Email   sql.NullString
+	Address sql.NullString
 }
 
-func (q *Queries) GetSalesStatsByEmployee(ctx context.Context) ([]GetSalesStatsByEmployeeRow, error) {
-	rows, err := q.db.QueryContext(ctx, getSalesStatsByEmployee)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetSalesStatsByEmployeeRow
-	for rows.Next() {
-		var i GetSalesStatsByEmployeeRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Surname,
-			&i.Email,
-			&i.TotalSales,
-			&i.TotalRevenue,
-			&i.AvgSaleValue,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Close(); err != nil {
-		return nil, err
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+func (q *Queries) UpdateCustomer(ctx context.Context, arg UpdateCustomerParams) (Customer, error) {
+	row := q.db.QueryRowContext(ctx, updateCustomer,
+		arg.ID,
+		arg.Name,
+		arg.Company,
+		arg.TaxID,
+		arg.Email,
+		arg.Address,
+	)
+	var i Customer
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Company,
+		&i.TaxID,
+		&i.Email,
+		&i.Address,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateDepartment = `-- name: UpdateDepartment :one
+UPDATE departments 
+SET name = $2, description = $3, parent_id = $4, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, description, parent_id, created_at, updated_at
+`
+
+type UpdateDepartmentParams struct {
+	ID          int32
+	Name        string
+	Description sql.NullString
+	ParentID    sql.NullInt32
+}
+
+func (q *Queries) UpdateDepartment(ctx context.Context, arg UpdateDepartmentParams) (Department, error) {
+	row := q.db.QueryRowContext(ctx, updateDepartment,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.ParentID,
+	)
+	var i Department
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Description,
+		&i.ParentID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
 }
 
 const updateEmployee = `-- name: UpdateEmployee :one
 UPDATE employees 
-SET name = $2, surname = $3, email = $4, updated_at = CURRENT_TIMESTAMP 
-WHERE id = $1 
-RETURNING id, name, surname, email, created_at, updated_at
+SET name = $1, surname = $2, email = $3, 
+    hire_date = $4, termination_date = $5, 
+    job_title = $6, status = $7, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $8 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at
 `
 
 type UpdateEmployeeParams struct {
-	ID      int32
-	Name    string
-	Surname string
-	Email   string
+	Name            string
+	Surname         string
+	Email           string
+	HireDate        time.Time
+	TerminationDate sql.NullTime
+	JobTitle        sql.NullString
+	Status          string
+	ID              int32
 }
 
 func (q *Queries) UpdateEmployee(ctx context.Context, arg UpdateEmployeeParams) (Employee, error) {
 	row := q.db.QueryRowContext(ctx, updateEmployee,
-		arg.ID,
 		arg.Name,
 		arg.Surname,
 		arg.Email,
+		arg.HireDate,
+		arg.TerminationDate,
+		arg.JobTitle,
+		arg.Status,
+		arg.ID,
 	)
 	var i Employee
 	err := row.Scan(
@@ -485,6 +4631,142 @@ func (q *Queries) UpdateEmployee(ctx context.Context, arg UpdateEmployeeParams)
 		&i.Name,
 		&i.Surname,
 		&i.Email,
+		&i.DepartmentID,
+		&i.ManagerID,
+		&i.HireDate,
+		&i.TerminationDate,
+		&i.JobTitle,
+		&i.Status,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const updateExchangeRate = `-- name: UpdateExchangeRate :one
+UPDATE exchange_rates 
+SET from_currency = $2, to_currency = $3, rate_date = $4, rate = $5, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, from_currency, to_currency, rate_date, rate, created_at, updated_at
+`
+
+type UpdateExchangeRateParams struct {
+	ID           int32
+	FromCurrency string
+	ToCurrency   string
+	RateDate     time.Time
+	Rate         string
+}
+
+func (q *Queries) UpdateExchangeRate(ctx context.Context, arg UpdateExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, updateExchangeRate,
+		arg.ID,
+		arg.FromCurrency,
+		arg.ToCurrency,
+		arg.RateDate,
+		arg.Rate,
+	)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.ID,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.RateDate,
+		&i.Rate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateProduct = `-- name: UpdateProduct :one
+UPDATE products 
+SET sku = $2, name = $3, category = $4, price = $5, currency = $6, active = $7, tax_rate = $8, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at
+`
+
+type UpdateProductParams struct {
+	ID       int32
+	Sku      string
+	Name     string
+	Category string
+	Price    money.Amount
+	Currency string
+	Active   bool
+	TaxRate  sql.NullString
+}
+
+func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
+	row := q.db.QueryRowContext(ctx, updateProduct,
+		arg.ID,
+		arg.Sku,
+		arg.Name,
+		arg.Category,
+		arg.Price,
+		arg.Currency,
+		arg.Active,
+		arg.TaxRate,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Sku,
+		&i.Name,
+		&i.Category,
+		&i.Price,
+		&i.Currency,
+		&i.Active,
+		&i.TaxRate,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updatePromotion = `-- name: UpdatePromotion :one
+UPDATE promotions 
+SET code = $2, description = $3, discount_percent = $4, discount_amount = $5, currency = $6, valid_from = $7, valid_to = $8, active = $9, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at
+`
+
+type UpdatePromotionParams struct {
+	ID              int32
+	Code            string
+	Description     sql.NullString
+	DiscountPercent string
+	DiscountAmount  money.Amount
+	Currency        sql.NullString
+	ValidFrom       sql.NullTime
+	ValidTo         sql.NullTime
+	Active          bool
+}
+
+func (q *Queries) UpdatePromotion(ctx context.Context, arg UpdatePromotionParams) (Promotion, error) {
+	row := q.db.QueryRowContext(ctx, updatePromotion,
+		arg.ID,
+		arg.Code,
+		arg.Description,
+		arg.DiscountPercent,
+		arg.DiscountAmount,
+		arg.Currency,
+		arg.ValidFrom,
+		arg.ValidTo,
+		arg.Active,
+	)
+	var i Promotion
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountPercent,
+		&i.DiscountAmount,
+		&i.Currency,
+		&i.ValidFrom,
+		&i.ValidTo,
+		&i.Active,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -493,19 +4775,26 @@ func (q *Queries) UpdateEmployee(ctx context.Context, arg UpdateEmployeeParams)
 
 const updateSale = `-- name: UpdateSale :one
 UPDATE sales 
-SET product_name = $2, category = $3, currency = $4, price = $5, sale_date = $6, employee_id = $7, updated_at = CURRENT_TIMESTAMP 
+SET product_name = $2, category = $3, currency = $4, price = $5, sale_date = $6, employee_id = $7, product_id = $8, net_amount = $9, tax_amount = $10, discount_amount = $11, discount_percent = $12, promotion_id = $13, customer_id = $14, updated_at = CURRENT_TIMESTAMP 
 WHERE id = $1 
-RETURNING id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at
 `
 
 type UpdateSaleParams struct {
-	ID          int32
-	ProductName string
-	Category    string
-	Currency    string
-	Price       string
-	SaleDate    time.Time
-	EmployeeID  int32
+	ID              int32
+	ProductName     string
+	Category        string
+	Currency        string
+	Price           money.Amount
+	SaleDate        time.Time
+	EmployeeID      sql.NullInt32
+	ProductID       sql.NullInt32
+	NetAmount       money.Amount
+	TaxAmount       money.Amount
+	DiscountAmount  money.Amount
+	DiscountPercent string
+	PromotionID     sql.NullInt32
+	CustomerID      sql.NullInt32
 }
 
 func (q *Queries) UpdateSale(ctx context.Context, arg UpdateSaleParams) (Sale, error) {
@@ -517,6 +4806,13 @@ func (q *Queries) UpdateSale(ctx context.Context, arg UpdateSaleParams) (Sale, e
 		arg.Price,
 		arg.SaleDate,
 		arg.EmployeeID,
+		arg.ProductID,
+		arg.NetAmount,
+		arg.TaxAmount,
+		arg.DiscountAmount,
+		arg.DiscountPercent,
+		arg.PromotionID,
+		arg.CustomerID,
 	)
 	var i Sale
 	err := row.Scan(
@@ -527,6 +4823,49 @@ func (q *Queries) UpdateSale(ctx context.Context, arg UpdateSaleParams) (Sale, e
 		&i.Price,
 		&i.SaleDate,
 		&i.EmployeeID,
+		&i.ProductID,
+		&i.NetAmount,
+		&i.TaxAmount,
+		&i.DiscountAmount,
+		&i.DiscountPercent,
+		&i.PromotionID,
+		&i.ListPrice,
+		&i.CustomerID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+	)
+	return i, err
+}
+
+const upsertExchangeRate = `-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (from_currency, to_currency, rate_date, rate) 
+VALUES ($1, $2, $3, $4) 
+ON CONFLICT (from_currency, to_currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate, updated_at = CURRENT_TIMESTAMP 
+RETURNING id, from_currency, to_currency, rate_date, rate, created_at, updated_at
+`
+
+type UpsertExchangeRateParams struct {
+	FromCurrency string
+	ToCurrency   string
+	RateDate     time.Time
+	Rate         string
+}
+
+func (q *Queries) UpsertExchangeRate(ctx context.Context, arg UpsertExchangeRateParams) (ExchangeRate, error) {
+	row := q.db.QueryRowContext(ctx, upsertExchangeRate,
+		arg.FromCurrency,
+		arg.ToCurrency,
+		arg.RateDate,
+		arg.Rate,
+	)
+	var i ExchangeRate
+	err := row.Scan(
+		&i.ID,
+		&i.FromCurrency,
+		&i.ToCurrency,
+		&i.RateDate,
+		&i.Rate,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
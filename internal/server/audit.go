@@ -0,0 +1,188 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Audited entities
+const (
+	auditEmployee = "employee"
+	auditSale     = "sale"
+)
+
+// Actor of the changes made by the server itself, such as the purge job
+const auditSystem = "system"
+
+// auditChange is one change to an employee or a sale. Before is nil for a create and After for a delete.
+type auditChange struct {
+	Entity   string
+	EntityID int32
+	Action   string // create, update, delete or restore
+	Before   any
+	After    any
+	Actor    string // the X-Actor header of the request when empty, see auditActor
+}
+
+// Longest X-Actor and X-Request-ID headers the audit log keeps, in bytes
+const (
+	maxAuditActor     = 255
+	maxAuditRequestID = 100
+)
+
+// checkAuditHeaders refuses requests whose X-Actor or X-Request-ID cannot be kept in the audit log as sent,
+// a change would otherwise be recorded under a shortened name or fail with its audit entry
+func checkAuditHeaders(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		actor := strings.TrimSpace(c.Request().Header.Get("X-Actor"))
+		if !utf8.ValidString(actor) || len(actor) > maxAuditActor {
+			return c.JSON(400, map[string]string{"error": fmt.Sprintf("X-Actor must be valid UTF-8 of at most %d bytes", maxAuditActor)})
+		}
+		requestID := c.Request().Header.Get(echo.HeaderXRequestID)
+		if !utf8.ValidString(requestID) || len(requestID) > maxAuditRequestID {
+			return c.JSON(400, map[string]string{"error": fmt.Sprintf("X-Request-ID must be valid UTF-8 of at most %d bytes", maxAuditRequestID)})
+		}
+		return next(c)
+	}
+}
+
+// Helper function to tell who made the request, from the X-Actor header
+func auditActor(c echo.Context) string {
+	actor := strings.TrimSpace(c.Request().Header.Get("X-Actor"))
+	if actor == "" {
+		return "anonymous"
+	}
+	return truncateUTF8(actor, maxAuditActor)
+}
+
+// Helper function to read the ID the RequestID middleware gave the request
+func auditRequestID(c echo.Context) sql.NullString {
+	requestID := c.Response().Header().Get(echo.HeaderXRequestID)
+	if requestID == "" {
+		requestID = c.Request().Header.Get(echo.HeaderXRequestID)
+	}
+	requestID = truncateUTF8(requestID, maxAuditRequestID)
+	return sql.NullString{String: requestID, Valid: requestID != ""}
+}
+
+// Helper function to turn a record into the JSON kept in the audit log, nil stays NULL
+func auditJSON(record any) (json.RawMessage, error) {
+	if record == nil {
+		return nil, nil
+	}
+	return json.Marshal(record)
+}
+
+// Helper function to record changes in the audit log. q is the transaction making the changes,
+// so that a change is never kept without its audit entry.
+func recordAudit(c echo.Context, q *internals.Queries, changes ...auditChange) error {
+	return writeAudit(c.Request().Context(), q, auditRequestID(c), auditActor(c), changes...)
+}
+
+// Helper function to write audit entries of changes made outside a request, such as by the purge job.
+// actor is used for changes without their own actor.
+func writeAudit(ctx context.Context, q *internals.Queries, requestID sql.NullString, actor string, changes ...auditChange) error {
+	for _, change := range changes {
+		before, err := auditJSON(change.Before)
+		if err != nil {
+			return err
+		}
+		after, err := auditJSON(change.After)
+		if err != nil {
+			return err
+		}
+		changeActor := change.Actor
+		if changeActor == "" {
+			changeActor = actor
+		}
+		if _, err := q.CreateAuditEntry(ctx, internals.CreateAuditEntryParams{
+			Entity:    change.Entity,
+			EntityID:  change.EntityID,
+			Action:    change.Action,
+			Actor:     changeActor,
+			RequestID: requestID,
+			Before:    before,
+			After:     after,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAuditLog lists the audit log page by page, latest first, e.g. /audit?entity=sale&id=5&limit=20.
+// The next page is requested with the next_cursor value from the response metadata.
+// Only admins may read it, it keeps deleted and purged records as they were.
+func GetAuditLog(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if err := requireAdmin(c); err != nil {
+		return adminError(c, err)
+	}
+
+	var filters internals.CountAuditLogParams
+	switch entity := c.QueryParam("entity"); entity {
+	case "":
+	case auditEmployee, auditSale:
+		filters.Entity = sql.NullString{String: entity, Valid: true}
+	default:
+		return c.JSON(400, map[string]string{"error": "Invalid entity (employee, sale)"})
+	}
+	if idStr := c.QueryParam("id"); idStr != "" {
+		if !filters.Entity.Valid {
+			return c.JSON(400, map[string]string{"error": "entity is required with id"})
+		}
+		id, err := strconv.ParseInt(idStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+		}
+		filters.EntityID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+
+	limit, err := parsePageLimit(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	cursor, err := parsePageCursor(c, "-id")
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	params := internals.ListAuditLogParams{
+		Entity:    filters.Entity,
+		EntityID:  filters.EntityID,
+		PageLimit: limit + 1, // one extra row tells whether there is a next page
+	}
+	if cursor != nil {
+		params.CursorID = sql.NullInt32{Int32: cursor.ID, Valid: true}
+	}
+
+	entries, err := queries.ListAuditLog(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get audit log"})
+	}
+	total, err := queries.CountAuditLog(ctx, filters)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to count audit log"})
+	}
+
+	result := page[internals.AuditLog]{
+		Data: make([]internals.AuditLog, 0, len(entries)),
+		Meta: pageMeta{Total: total, Limit: limit, Sort: "-id"},
+	}
+	if len(entries) > int(limit) {
+		entries = entries[:limit]
+		result.Meta.NextCursor = pageCursor{Sort: "-id", ID: entries[len(entries)-1].ID}.encode()
+	}
+	result.Data = append(result.Data, entries...)
+
+	return c.JSON(200, result)
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type categoryRequest struct {
+	Name        string        `json:"name"`
+	Description *string       `json:"description"`
+	TaxRate     *money.Amount `json:"tax_rate"` // VAT in percent, 23 when not given
+}
+
+type categoryStats struct {
+	CategoryID   int32         `json:"category_id"`
+	Category     string        `json:"category"`
+	TotalSales   int64         `json:"total_sales"`
+	TotalRevenue money.Amount  `json:"total_revenue"`
+	AvgSaleValue *money.Amount `json:"avg_sale_value"`
+	Share        float64       `json:"share"` // percent of the period's total revenue
+}
+
+type categoryStatsResponse struct {
+	Period       string          `json:"period"`
+	Scope        string          `json:"scope,omitempty"` // department or manager the statistics are limited to
+	Currency     string          `json:"currency"`
+	TotalSales   int64           `json:"total_sales"`
+	TotalRevenue money.Amount    `json:"total_revenue"`
+	Categories   []categoryStats `json:"categories"`
+}
+
+// Helper function to look up the managed category of a sale, ignoring case.
+// The canonical spelling from the categories table is what gets stored.
+func getSaleCategory(ctx context.Context, name string) (internals.Category, error) {
+	category, err := queries.GetCategoryByName(ctx, strings.TrimSpace(name))
+	if err != nil {
+		return internals.Category{}, fmt.Errorf("Unknown category %q. Add it first with POST /category", name)
+	}
+	return category, nil
+}
+
+func GetAllCategories(c echo.Context) error {
+	ctx := c.Request().Context()
+	categories, err := queries.GetCategories(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get categories"})
+	}
+	return c.JSON(200, categories)
+}
+
+func GetCategory(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Category ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	category, err := queries.GetCategory(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Category not found"})
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+func CreateCategory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req categoryRequest
+	err := c.Bind(&req)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return c.JSON(400, map[string]string{"error": "Invalid tax_rate format. Use at most 2 decimal places"})
+	}
+	if err != nil || req.Name == "" {
+		req = categoryRequest{Name: c.QueryParam("name")}
+		if description := c.QueryParam("description"); description != "" {
+			req.Description = &description
+		}
+		if taxRateStr := c.QueryParam("tax_rate"); taxRateStr != "" {
+			taxRate, err := money.Parse(taxRateStr)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid tax_rate format. Use at most 2 decimal places"})
+			}
+			req.TaxRate = &taxRate
+		}
+	}
+
+	params := internals.CreateCategoryParams{Name: strings.TrimSpace(req.Name), TaxRate: defaultTaxRate.String()}
+	if params.Name == "" {
+		return c.JSON(400, map[string]string{"error": "Category name is required"})
+	}
+	if len(params.Name) > 100 {
+		return c.JSON(400, map[string]string{"error": "Category name must be at most 100 characters long"})
+	}
+	if req.Description != nil && *req.Description != "" {
+		params.Description = sql.NullString{String: *req.Description, Valid: true}
+	}
+	if req.TaxRate != nil {
+		if err := validateTaxRate(*req.TaxRate); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		params.TaxRate = req.TaxRate.String()
+	}
+
+	category, err := queries.CreateCategory(ctx, params)
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Category already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create category"})
+	}
+	return c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory renames or describes a category, a new name is applied to all of its sales.
+// A new tax rate only applies to sales made from now on.
+func UpdateCategory(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Category ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	current, err := queries.GetCategory(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Category not found"})
+	}
+
+	params := internals.UpdateCategoryParams{
+		ID:          current.ID,
+		Name:        current.Name,
+		Description: current.Description,
+		TaxRate:     current.TaxRate,
+	}
+
+	var req categoryRequest
+	err = c.Bind(&req)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return c.JSON(400, map[string]string{"error": "Invalid tax_rate format. Use at most 2 decimal places"})
+	}
+	if err == nil {
+		if req.Name != "" {
+			params.Name = strings.TrimSpace(req.Name)
+		}
+		if req.Description != nil {
+			params.Description = sql.NullString{String: *req.Description, Valid: *req.Description != ""}
+		}
+		if req.TaxRate != nil {
+			if err := validateTaxRate(*req.TaxRate); err != nil {
+				return c.JSON(400, map[string]string{"error": err.Error()})
+			}
+			params.TaxRate = req.TaxRate.String()
+		}
+	}
+	if name := c.QueryParam("name"); name != "" {
+		params.Name = strings.TrimSpace(name)
+	}
+	if description := c.QueryParam("description"); description != "" {
+		params.Description = sql.NullString{String: description, Valid: true}
+	}
+	if taxRateStr := c.QueryParam("tax_rate"); taxRateStr != "" {
+		taxRate, err := money.Parse(taxRateStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid tax_rate format. Use at most 2 decimal places"})
+		}
+		if err := validateTaxRate(taxRate); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		params.TaxRate = taxRate.String()
+	}
+
+	if params.Name == "" {
+		return c.JSON(400, map[string]string{"error": "Category name is required"})
+	}
+	if len(params.Name) > 100 {
+		return c.JSON(400, map[string]string{"error": "Category name must be at most 100 characters long"})
+	}
+
+	category, err := queries.UpdateCategory(ctx, params)
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Category already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update category"})
+	}
+	return c.JSON(http.StatusOK, category)
+}
+
+func DeleteCategory(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Category ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetCategory(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Category not found"})
+	}
+	err = queries.DeleteCategory(ctx, int32(id))
+	if isForeignKeyViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Category has sales, move them to another category first"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete category"})
+	}
+	return c.JSON(200, map[string]string{"message": "Category deleted successfully"})
+}
+
+// GetCategoriesStats answers /categories/stats with the revenue and revenue share of every category,
+// categories without sales included. It takes the same period parameters as /employees/stats
+// and an optional ?employee_id=, ?department_id= or ?manager_id=.
+func GetCategoriesStats(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	period, err := parseStatsPeriod(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	scope, err := parseOrgScope(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	params := internals.GetCategoryStatsParams{
+		BaseCurrency: rates.Base(),
+		FromDate:     period.From,
+		ToDate:       period.To,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+	}
+	if employeeIDStr := c.QueryParam("employee_id"); employeeIDStr != "" {
+		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
+		}
+		params.EmployeeID = sql.NullInt32{Int32: int32(employeeID), Valid: true}
+	}
+
+	if err := checkBaseRates(ctx, period); errors.Is(err, errMissingBaseRates) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	} else if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get exchange rates"})
+	}
+
+	rows, err := queries.GetCategoryStats(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get category statistics"})
+	}
+
+	response := categoryStatsResponse{
+		Period:     period.Label,
+		Scope:      scope.Label,
+		Currency:   rates.Base(),
+		Categories: make([]categoryStats, 0, len(rows)),
+	}
+	for _, row := range rows {
+		revenue, err := money.Parse(row.TotalRevenue)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to get category statistics"})
+		}
+		average, err := parseNullAmount(row.AvgSaleValue)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to get category statistics"})
+		}
+		response.TotalSales += row.TotalSales
+		response.TotalRevenue += revenue
+		response.Categories = append(response.Categories, categoryStats{
+			CategoryID:   row.ID,
+			Category:     row.Name,
+			TotalSales:   row.TotalSales,
+			TotalRevenue: revenue,
+			AvgSaleValue: average,
+		})
+	}
+	for i := range response.Categories {
+		if response.TotalRevenue > 0 {
+			response.Categories[i].Share = response.Categories[i].TotalRevenue.Float64() / response.TotalRevenue.Float64() * 100
+		}
+	}
+
+	return c.JSON(200, response)
+}
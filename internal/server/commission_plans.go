@@ -0,0 +1,461 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type commissionTierRequest struct {
+	Threshold money.Amount `json:"threshold"` // net revenue in the plan currency from which rate applies
+	Rate      money.Amount `json:"rate"`
+}
+
+type commissionCategoryRateRequest struct {
+	Category string       `json:"category"`
+	Rate     money.Amount `json:"rate"`
+}
+
+type commissionPlanRequest struct {
+	Name            string                           `json:"name"`
+	Description     *string                          `json:"description"`
+	Currency        string                           `json:"currency"`
+	BaseRate        *money.Amount                    `json:"base_rate"`
+	AcceleratorRate *money.Amount                    `json:"accelerator_rate"`
+	Tiers           *[]commissionTierRequest         `json:"tiers"`          // replaces all tiers when given
+	CategoryRates   *[]commissionCategoryRateRequest `json:"category_rates"` // replaces all category rates when given
+}
+
+// commissionPlanResponse is a plan together with its tiers and category rates
+type commissionPlanResponse struct {
+	internals.CommissionPlan
+	Tiers         []internals.CommissionTier         `json:"tiers"`
+	CategoryRates []internals.CommissionCategoryRate `json:"category_rates"`
+}
+
+// Commission rates are percentages handled like tax rates, in hundredths of a percent
+func validateCommissionRate(rate money.Amount) error {
+	if rate < 0 || rate > 10000 {
+		return errors.New("Commission rates must be between 0 and 100")
+	}
+	return nil
+}
+
+// Helper function to apply a request to a plan, fields left out keep their value.
+// The tiers and category rates are checked and returned, they are nil when not given.
+func (req commissionPlanRequest) apply(ctx context.Context, plan *internals.CommissionPlan) ([]internals.CreateCommissionTierParams, []internals.CreateCommissionCategoryRateParams, error) {
+	if req.Name != "" {
+		plan.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Description != nil {
+		plan.Description = sql.NullString{String: *req.Description, Valid: *req.Description != ""}
+	}
+	if req.Currency != "" {
+		currency := strings.ToUpper(req.Currency)
+		// The thresholds of the stored tiers are amounts in the old currency
+		if plan.ID != 0 && currency != plan.Currency && req.Tiers == nil {
+			return nil, nil, errors.New("Tiers must be given again when the currency changes")
+		}
+		plan.Currency = currency
+	}
+	for _, rate := range []struct {
+		value *money.Amount
+		to    *string
+	}{{req.BaseRate, &plan.BaseRate}, {req.AcceleratorRate, &plan.AcceleratorRate}} {
+		if rate.value == nil {
+			continue
+		}
+		if err := validateCommissionRate(*rate.value); err != nil {
+			return nil, nil, err
+		}
+		*rate.to = rate.value.String()
+	}
+
+	if plan.Name == "" || len(plan.Name) > 100 {
+		return nil, nil, errors.New("Name must be 1-100 characters")
+	}
+	currency, err := getSaleCurrency(ctx, plan.Currency)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var tiers []internals.CreateCommissionTierParams
+	if req.Tiers != nil {
+		tiers = []internals.CreateCommissionTierParams{}
+		seen := map[money.Amount]bool{}
+		for _, tier := range *req.Tiers {
+			if tier.Threshold < 0 || !tier.Threshold.HasDecimals(int(currency.MinorUnits)) {
+				return nil, nil, fmt.Errorf("Invalid tier threshold %s %s", tier.Threshold, currency.Code)
+			}
+			if seen[tier.Threshold] {
+				return nil, nil, fmt.Errorf("Tier threshold %s is given twice", tier.Threshold)
+			}
+			seen[tier.Threshold] = true
+			if err := validateCommissionRate(tier.Rate); err != nil {
+				return nil, nil, err
+			}
+			tiers = append(tiers, internals.CreateCommissionTierParams{PlanID: plan.ID, Threshold: tier.Threshold, Rate: tier.Rate.String()})
+		}
+	}
+
+	var categoryRates []internals.CreateCommissionCategoryRateParams
+	if req.CategoryRates != nil {
+		categoryRates = []internals.CreateCommissionCategoryRateParams{}
+		seen := map[string]bool{}
+		for _, categoryRate := range *req.CategoryRates {
+			category, err := getSaleCategory(ctx, categoryRate.Category)
+			if err != nil {
+				return nil, nil, err
+			}
+			if seen[category.Name] {
+				return nil, nil, fmt.Errorf("Category %s is given twice", category.Name)
+			}
+			seen[category.Name] = true
+			if err := validateCommissionRate(categoryRate.Rate); err != nil {
+				return nil, nil, err
+			}
+			categoryRates = append(categoryRates, internals.CreateCommissionCategoryRateParams{PlanID: plan.ID, Category: category.Name, Rate: categoryRate.Rate.String()})
+		}
+	}
+	return tiers, categoryRates, nil
+}
+
+// Helper function to replace the tiers and category rates of a plan that were given,
+// it runs inside the transaction of the plan
+func saveCommissionRates(ctx context.Context, q *internals.Queries, planID int32, tiers []internals.CreateCommissionTierParams, categoryRates []internals.CreateCommissionCategoryRateParams) error {
+	if tiers != nil {
+		if err := q.DeleteCommissionTiers(ctx, planID); err != nil {
+			return err
+		}
+		for _, tier := range tiers {
+			tier.PlanID = planID
+			if _, err := q.CreateCommissionTier(ctx, tier); err != nil {
+				return err
+			}
+		}
+	}
+	if categoryRates != nil {
+		if err := q.DeleteCommissionCategoryRates(ctx, planID); err != nil {
+			return err
+		}
+		for _, categoryRate := range categoryRates {
+			categoryRate.PlanID = planID
+			if _, err := q.CreateCommissionCategoryRate(ctx, categoryRate); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Helper function to load a plan with its tiers and category rates
+func loadCommissionPlan(ctx context.Context, q *internals.Queries, id int32) (commissionPlanResponse, error) {
+	plan, err := q.GetCommissionPlan(ctx, id)
+	if err != nil {
+		return commissionPlanResponse{}, err
+	}
+	response := commissionPlanResponse{CommissionPlan: plan}
+	if response.Tiers, err = q.GetCommissionTiers(ctx, id); err != nil {
+		return commissionPlanResponse{}, err
+	}
+	if response.CategoryRates, err = q.GetCommissionCategoryRates(ctx, id); err != nil {
+		return commissionPlanResponse{}, err
+	}
+	if response.Tiers == nil {
+		response.Tiers = []internals.CommissionTier{}
+	}
+	if response.CategoryRates == nil {
+		response.CategoryRates = []internals.CommissionCategoryRate{}
+	}
+	return response, nil
+}
+
+func GetAllCommissionPlans(c echo.Context) error {
+	ctx := c.Request().Context()
+	plans, err := queries.GetCommissionPlans(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get commission plans"})
+	}
+	response := make([]commissionPlanResponse, 0, len(plans))
+	for _, plan := range plans {
+		loaded, err := loadCommissionPlan(ctx, queries, plan.ID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to get commission plans"})
+		}
+		response = append(response, loaded)
+	}
+	return c.JSON(200, response)
+}
+
+func GetCommissionPlan(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Commission plan ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	plan, err := loadCommissionPlan(ctx, queries, int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Commission plan not found"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get commission plan"})
+	}
+	return c.JSON(http.StatusOK, plan)
+}
+
+// CreateCommissionPlan adds a plan, e.g. {"name": "Standard", "base_rate": 2, "tiers": [{"threshold": 20000, "rate": 3}],
+// "category_rates": [{"category": "Software", "rate": 5}], "accelerator_rate": 1.5}
+func CreateCommissionPlan(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req commissionPlanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid commission plan data"})
+	}
+
+	zero := money.Amount(0).String()
+	plan := internals.CommissionPlan{Currency: "PLN", BaseRate: zero, AcceleratorRate: zero}
+	tiers, categoryRates, err := req.apply(ctx, &plan)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	var created internals.CommissionPlan
+	err = withTx(ctx, func(q *internals.Queries) error {
+		var err error
+		created, err = q.CreateCommissionPlan(ctx, internals.CreateCommissionPlanParams{
+			Name:            plan.Name,
+			Description:     plan.Description,
+			Currency:        plan.Currency,
+			BaseRate:        plan.BaseRate,
+			AcceleratorRate: plan.AcceleratorRate,
+		})
+		if err != nil {
+			return err
+		}
+		return saveCommissionRates(ctx, q, created.ID, tiers, categoryRates)
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Commission plan with this name already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create commission plan"})
+	}
+
+	response, err := loadCommissionPlan(ctx, queries, created.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get commission plan"})
+	}
+	return c.JSON(http.StatusCreated, response)
+}
+
+// UpdateCommissionPlan changes a plan. Commissions are always computed with the current
+// version of a plan, also for past periods.
+func UpdateCommissionPlan(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Commission plan ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	plan, err := queries.GetCommissionPlan(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Commission plan not found"})
+	}
+
+	var req commissionPlanRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid commission plan data"})
+	}
+	tiers, categoryRates, err := req.apply(ctx, &plan)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	err = withTx(ctx, func(q *internals.Queries) error {
+		_, err := q.UpdateCommissionPlan(ctx, internals.UpdateCommissionPlanParams{
+			ID:              plan.ID,
+			Name:            plan.Name,
+			Description:     plan.Description,
+			Currency:        plan.Currency,
+			BaseRate:        plan.BaseRate,
+			AcceleratorRate: plan.AcceleratorRate,
+		})
+		if err != nil {
+			return err
+		}
+		return saveCommissionRates(ctx, q, plan.ID, tiers, categoryRates)
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Commission plan with this name already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update commission plan"})
+	}
+
+	response, err := loadCommissionPlan(ctx, queries, plan.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get commission plan"})
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// DeleteCommissionPlan removes a plan that was never assigned to anybody
+func DeleteCommissionPlan(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Commission plan ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetCommissionPlan(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Commission plan not found"})
+	}
+	err = queries.DeleteCommissionPlan(ctx, int32(id))
+	if isForeignKeyViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Commission plan is assigned to employees"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete commission plan"})
+	}
+	return c.JSON(200, map[string]string{"message": "Commission plan deleted successfully"})
+}
+
+// Helper function to read the first day a plan assignment is in force, it defaults to the current month
+func parseValidFrom(validFromStr string) (time.Time, error) {
+	if validFromStr == "" {
+		now := time.Now().UTC()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	validFrom, err := parseDate(validFromStr)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid valid_from format")
+	}
+	return time.Date(validFrom.Year(), validFrom.Month(), validFrom.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// AssignCommissionPlan puts an employee on a plan from valid_from on, from JSON or ?plan_id=&valid_from=.
+// An assignment from the same day is replaced.
+func AssignCommissionPlan(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+
+	type AssignCommissionPlanRequest struct {
+		PlanID    int32  `json:"plan_id"`
+		ValidFrom string `json:"valid_from"`
+	}
+
+	var req AssignCommissionPlanRequest
+	_ = c.Bind(&req)
+	if planIDStr := c.QueryParam("plan_id"); planIDStr != "" {
+		planID, err := strconv.ParseInt(planIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid plan_id format"})
+		}
+		req.PlanID = int32(planID)
+	}
+	if validFrom := c.QueryParam("valid_from"); validFrom != "" {
+		req.ValidFrom = validFrom
+	}
+	if req.PlanID == 0 {
+		return c.JSON(400, map[string]string{"error": "plan_id is required"})
+	}
+	validFrom, err := parseValidFrom(req.ValidFrom)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	if _, err := queries.GetEmployee(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if _, err := queries.GetCommissionPlan(ctx, req.PlanID); err != nil {
+		return c.JSON(400, map[string]string{"error": "Commission plan not found"})
+	}
+
+	assignment, err := queries.SetCommissionAssignment(ctx, internals.SetCommissionAssignmentParams{
+		EmployeeID: int32(id),
+		PlanID:     req.PlanID,
+		ValidFrom:  validFrom,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to assign commission plan"})
+	}
+	return c.JSON(http.StatusOK, assignment)
+}
+
+// GetCommissionAssignments lists the plans an employee has been on, oldest first
+func GetCommissionAssignments(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+	if _, err := queries.GetEmployee(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+
+	assignments, err := queries.GetCommissionAssignments(ctx, int32(id))
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get commission plans"})
+	}
+	if assignments == nil {
+		assignments = []internals.CommissionAssignment{}
+	}
+	return c.JSON(200, assignments)
+}
+
+// DeleteCommissionAssignment removes the assignment of an employee starting on ?valid_from=
+func DeleteCommissionAssignment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+	validFromStr := c.QueryParam("valid_from")
+	if validFromStr == "" {
+		return c.JSON(400, map[string]string{"error": "valid_from is required"})
+	}
+	validFrom, err := parseValidFrom(validFromStr)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	deleted, err := queries.DeleteCommissionAssignment(ctx, internals.DeleteCommissionAssignmentParams{
+		EmployeeID: int32(id),
+		ValidFrom:  validFrom,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete commission plan assignment"})
+	}
+	if deleted == 0 {
+		return c.JSON(404, map[string]string{"error": "Commission plan assignment not found"})
+	}
+	return c.JSON(200, map[string]string{"message": "Commission plan assignment deleted successfully"})
+}
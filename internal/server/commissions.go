@@ -0,0 +1,432 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/exchange"
+	"WorkRESTAPI/internal/money"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phpdave11/gofpdf"
+)
+
+// errNoCommissionPlan is returned when an employee has no plan in force for a period
+var errNoCommissionPlan = errors.New("Employee has no commission plan in force")
+
+var errCommissionEmployeeNotFound = errors.New("Employee not found")
+
+// commissionCategory is the net revenue of one category and its commission,
+// Rate is nil for revenue that goes through the tiers
+type commissionCategory struct {
+	Category   string        `json:"category"`
+	Revenue    money.Amount  `json:"revenue"`
+	Refunded   money.Amount  `json:"refunded"`
+	NetRevenue money.Amount  `json:"net_revenue"`
+	Rate       *money.Amount `json:"rate"`
+	Commission money.Amount  `json:"commission"`
+}
+
+// commissionBand is the part of the tiered revenue that falls between two thresholds
+type commissionBand struct {
+	From       money.Amount  `json:"from"`
+	To         *money.Amount `json:"to"` // nil for the last band
+	Rate       money.Amount  `json:"rate"`
+	Revenue    money.Amount  `json:"revenue"`
+	Commission money.Amount  `json:"commission"`
+}
+
+// commissionStatement is the commission of an employee for a period, all amounts are in the plan currency
+type commissionStatement struct {
+	EmployeeID            int32                `json:"employee_id"`
+	EmployeeName          string               `json:"employee_name"`
+	PlanID                int32                `json:"plan_id"`
+	PlanName              string               `json:"plan_name"`
+	Period                string               `json:"period"`
+	Currency              string               `json:"currency"`
+	Categories            []commissionCategory `json:"categories"`
+	TieredRevenue         money.Amount         `json:"tiered_revenue"`
+	Bands                 []commissionBand     `json:"bands"`
+	NetRevenue            money.Amount         `json:"net_revenue"`
+	Quota                 *money.Amount        `json:"quota"` // the sales target of the period, nil without one
+	AboveQuota            money.Amount         `json:"above_quota"`
+	AcceleratorRate       money.Amount         `json:"accelerator_rate"`
+	AcceleratorCommission money.Amount         `json:"accelerator_commission"`
+	Commission            money.Amount         `json:"commission"`
+
+	period reportPeriod
+}
+
+// Helper function to take a percentage in hundredths of a percent of an amount,
+// rounding half away from zero
+func percentOf(amount, rate money.Amount) money.Amount {
+	product := int64(amount) * int64(rate)
+	if product < 0 {
+		return -money.Amount((-product + 5000) / 10000)
+	}
+	return money.Amount((product + 5000) / 10000)
+}
+
+// Helper function to split tiered revenue into bands: the base rate applies below the
+// first threshold and every tier rate from its threshold up. Negative revenue (more
+// refunds than sales) is charged back at the base rate.
+func commissionBands(revenue, baseRate money.Amount, tiers []internals.CommissionTier) ([]commissionBand, error) {
+	bands := []commissionBand{{From: 0, Rate: baseRate}}
+	for _, tier := range tiers {
+		rate, err := parseTaxRate(tier.Rate)
+		if err != nil {
+			return nil, err
+		}
+		if tier.Threshold == 0 {
+			bands[0].Rate = rate
+			continue
+		}
+		threshold := tier.Threshold
+		bands[len(bands)-1].To = &threshold
+		bands = append(bands, commissionBand{From: tier.Threshold, Rate: rate})
+	}
+
+	for i := range bands {
+		band := &bands[i]
+		switch {
+		case i == 0 && revenue < 0:
+			band.Revenue = revenue
+		case revenue <= band.From:
+			continue
+		case band.To == nil || revenue < *band.To:
+			band.Revenue = revenue - band.From
+		default:
+			band.Revenue = *band.To - band.From
+		}
+		band.Commission = percentOf(band.Revenue, band.Rate)
+	}
+	return bands, nil
+}
+
+// Helper function to get the sales target of a monthly or quarterly period in the plan currency.
+// It returns nil when the period has no target.
+func commissionQuota(ctx context.Context, employeeID int32, kind string, period reportPeriod, currency string) (*money.Amount, error) {
+	if kind != "month" && kind != "quarter" {
+		return nil, nil
+	}
+	target, err := queries.GetEmployeeSalesTarget(ctx, internals.GetEmployeeSalesTargetParams{
+		EmployeeID:  employeeID,
+		PeriodType:  kind,
+		PeriodStart: period.From,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	quota, _, err := rates.Convert(ctx, target.Amount, target.Currency, currency, period.rateDate())
+	if err != nil {
+		return nil, err
+	}
+	return &quota, nil
+}
+
+// computeCommission works out the commission of an employee for a period with the plan in
+// force on its first day. Category rates take precedence over the tiers, the accelerator is
+// paid on the net revenue above the sales target of the period.
+func computeCommission(ctx context.Context, employee internals.Employee, kind string, period reportPeriod) (*commissionStatement, error) {
+	assignments, err := queries.GetCommissionAssignmentsOn(ctx, internals.GetCommissionAssignmentsOnParams{
+		OnDate:     period.From,
+		EmployeeID: sql.NullInt32{Int32: employee.ID, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(assignments) == 0 {
+		return nil, errNoCommissionPlan
+	}
+	plan, err := loadCommissionPlan(ctx, queries, assignments[0].PlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &commissionStatement{
+		EmployeeID:   employee.ID,
+		EmployeeName: employee.Name + " " + employee.Surname,
+		PlanID:       plan.ID,
+		PlanName:     plan.Name,
+		Period:       period.Label,
+		Currency:     plan.Currency,
+		Categories:   []commissionCategory{},
+		period:       period,
+	}
+
+	categoryRates := map[string]money.Amount{}
+	for _, categoryRate := range plan.CategoryRates {
+		if categoryRates[categoryRate.Category], err = parseTaxRate(categoryRate.Rate); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := queries.GetCommissionBase(ctx, internals.GetCommissionBaseParams{
+		PlanCurrency: plan.Currency,
+		EmployeeID:   employee.ID,
+		FromDate:     period.From,
+		ToDate:       period.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.MissingRates != "" {
+			return nil, fmt.Errorf("%w: %s/%s for sales of %s", exchange.ErrRateNotFound,
+				strings.ReplaceAll(row.MissingRates, ",", ", "), plan.Currency, period.Label)
+		}
+		line := commissionCategory{Category: row.Category}
+		if line.Revenue, err = money.Parse(row.Revenue); err != nil {
+			return nil, err
+		}
+		if line.Refunded, err = money.Parse(row.Refunded); err != nil {
+			return nil, err
+		}
+		line.NetRevenue = line.Revenue - line.Refunded
+		if rate, ok := categoryRates[row.Category]; ok {
+			line.Rate = &rate
+			line.Commission = percentOf(line.NetRevenue, rate)
+			statement.Commission += line.Commission
+		} else {
+			statement.TieredRevenue += line.NetRevenue
+		}
+		statement.NetRevenue += line.NetRevenue
+		statement.Categories = append(statement.Categories, line)
+	}
+
+	baseRate, err := parseTaxRate(plan.BaseRate)
+	if err != nil {
+		return nil, err
+	}
+	if statement.Bands, err = commissionBands(statement.TieredRevenue, baseRate, plan.Tiers); err != nil {
+		return nil, err
+	}
+	for _, band := range statement.Bands {
+		statement.Commission += band.Commission
+	}
+
+	if statement.AcceleratorRate, err = parseTaxRate(plan.AcceleratorRate); err != nil {
+		return nil, err
+	}
+	if statement.Quota, err = commissionQuota(ctx, employee.ID, kind, period, plan.Currency); err != nil {
+		return nil, err
+	}
+	if statement.Quota != nil && statement.NetRevenue > *statement.Quota {
+		statement.AboveQuota = statement.NetRevenue - *statement.Quota
+		statement.AcceleratorCommission = percentOf(statement.AboveQuota, statement.AcceleratorRate)
+		statement.Commission += statement.AcceleratorCommission
+	}
+	return statement, nil
+}
+
+// Helper function to read the period of a commission request: ?period=month (default),
+// quarter, year or range with the same parameters as the reports
+func parseCommissionPeriod(c echo.Context) (string, reportPeriod, error) {
+	kind := c.QueryParam("period")
+	if kind == "" {
+		kind = "month"
+	}
+	if kind != "month" && kind != "quarter" && kind != "year" && kind != "range" {
+		return "", reportPeriod{}, errors.New("period must be month, quarter, year or range")
+	}
+	period, err := parseReportPeriod(c, kind)
+	return kind, period, err
+}
+
+// Helper function to compute the commission of the employee in the path.
+// Errors are answered with commissionError.
+func employeeCommission(c echo.Context) (*commissionStatement, error) {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return nil, rejection("Invalid employee ID")
+	}
+	kind, period, err := parseCommissionPeriod(c)
+	if err != nil {
+		return nil, rejection(err.Error())
+	}
+	employee, err := queries.GetEmployee(ctx, int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errCommissionEmployeeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statement, err := computeCommission(ctx, employee, kind, period)
+	if errors.Is(err, errNoCommissionPlan) {
+		return nil, fmt.Errorf("%w on %s", err, period.From.Format("2006-01-02"))
+	}
+	return statement, err
+}
+
+// Helper function to answer an error of employeeCommission
+func commissionError(c echo.Context, err error) error {
+	var r rejection
+	switch {
+	case errors.As(err, &r):
+		return c.JSON(400, map[string]string{"error": r.Error()})
+	case errors.Is(err, errCommissionEmployeeNotFound), errors.Is(err, errNoCommissionPlan):
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	case errors.Is(err, exchange.ErrRateNotFound):
+		return conversionError(c, err)
+	}
+	return c.JSON(500, map[string]string{"error": "Failed to compute commission"})
+}
+
+// GetEmployeeCommission returns the commission of an employee, e.g. ?period=quarter&year=2025&quarter=1
+func GetEmployeeCommission(c echo.Context) error {
+	statement, err := employeeCommission(c)
+	if err != nil {
+		return commissionError(c, err)
+	}
+	return c.JSON(http.StatusOK, statement)
+}
+
+// GetEmployeeCommissionStatement returns the commission of an employee as a PDF statement
+func GetEmployeeCommissionStatement(c echo.Context) error {
+	statement, err := employeeCommission(c)
+	if err != nil {
+		return commissionError(c, err)
+	}
+
+	formats, err := loadCurrencyFormats(c.Request().Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get currencies"})
+	}
+
+	employee := strings.Fields(statement.EmployeeName)
+	return streamPDF(c, fmt.Sprintf("prowizja_%s_%s.pdf", strings.Join(employee, "_"), statement.period.FileName),
+		generateCommissionPDF(statement, formats))
+}
+
+// GetAllCommissions returns the commission of every employee with a plan in force for the period,
+// ?department_id= and ?manager_id= narrow it to a department or a manager's team
+func GetAllCommissions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	kind, period, err := parseCommissionPeriod(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	scope, err := parseOrgScope(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	assignments, err := queries.GetCommissionAssignmentsOn(ctx, internals.GetCommissionAssignmentsOnParams{
+		OnDate:       period.From,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get commission plans"})
+	}
+
+	statements := make([]*commissionStatement, 0, len(assignments))
+	for _, assignment := range assignments {
+		employee, err := queries.GetEmployee(ctx, assignment.EmployeeID)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to get employee"})
+		}
+		statement, err := computeCommission(ctx, employee, kind, period)
+		if errors.Is(err, exchange.ErrRateNotFound) {
+			return conversionError(c, err)
+		}
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to compute commission"})
+		}
+		statements = append(statements, statement)
+	}
+	return c.JSON(200, statements)
+}
+
+// generateCommissionPDF lists the revenue per category, the tier bands and the accelerator
+// that make up the commission of the period
+func generateCommissionPDF(statement *commissionStatement, formats currencyFormats) *bytes.Buffer {
+	pdf := newReportPDF("Commission statement - "+statement.EmployeeName, statement.period)
+	format := func(amount money.Amount) string {
+		return formats.format(amount, statement.Currency)
+	}
+
+	pdf.Cell(0, 10, fmt.Sprintf("Commission plan: %s (%s)", statement.PlanName, statement.Currency))
+	pdf.Ln(5)
+	pdf.Cell(0, 10, "Net revenue: "+format(statement.NetRevenue))
+	pdf.Ln(10)
+
+	// Categories
+	pdf.SetFont(reportFont, "B", 10)
+	for _, header := range []struct {
+		width float64
+		title string
+	}{{50, "Category"}, {35, "Revenue"}, {30, "Refunds"}, {35, "Net"}, {15, "Rate"}, {25, "Commission"}} {
+		pdf.CellFormat(header.width, 8, header.title, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont(reportFont, "", 9)
+	for _, line := range statement.Categories {
+		rate := "tiers"
+		if line.Rate != nil {
+			rate = formatTaxRate(line.Rate.String())
+		}
+		pdf.CellFormat(50, 7, line.Category, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(35, 7, format(line.Revenue), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 7, format(line.Refunded), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(35, 7, format(line.NetRevenue), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(15, 7, rate, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(25, 7, format(line.Commission), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+	pdf.Ln(5)
+
+	writeCommissionBands(pdf, statement, format)
+
+	// Accelerator
+	pdf.SetFont(reportFont, "", 12)
+	if statement.Quota != nil {
+		pdf.Cell(0, 10, "Quota: "+format(*statement.Quota))
+		pdf.Ln(5)
+		if statement.AboveQuota > 0 {
+			pdf.Cell(0, 10, fmt.Sprintf("Accelerator: %s of %s above quota = %s", formatTaxRate(statement.AcceleratorRate.String()),
+				format(statement.AboveQuota), format(statement.AcceleratorCommission)))
+			pdf.Ln(5)
+		}
+	}
+
+	pdf.SetFont(reportFont, "B", 12)
+	pdf.Cell(0, 10, "Total commission: "+format(statement.Commission))
+	pdf.Ln(5)
+
+	return outputPDF(pdf)
+}
+
+// Helper function to write how the revenue without a category rate went through the tiers
+func writeCommissionBands(pdf *gofpdf.Fpdf, statement *commissionStatement, format func(money.Amount) string) {
+	pdf.SetFont(reportFont, "B", 12)
+	pdf.Cell(0, 10, "Tiered revenue: "+format(statement.TieredRevenue))
+	pdf.Ln(8)
+	pdf.SetFont(reportFont, "", 9)
+	for _, band := range statement.Bands {
+		if band.Revenue == 0 {
+			continue
+		}
+		upTo := "and above"
+		if band.To != nil {
+			upTo = "to " + format(*band.To)
+		}
+		pdf.Cell(0, 10, fmt.Sprintf("From %s %s: %s at %s = %s",
+			format(band.From), upTo, format(band.Revenue), formatTaxRate(band.Rate.String()), format(band.Commission)))
+		pdf.Ln(5)
+	}
+	pdf.Ln(5)
+}
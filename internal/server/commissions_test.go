@@ -0,0 +1,162 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"reflect"
+	"testing"
+)
+
+func TestPercentOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount money.Amount
+		rate   money.Amount
+		want   money.Amount
+	}{
+		{"whole percent", 1000000, 500, 50000},             // 5% of 10000.00
+		{"fractional percent", 1000000, 250, 25000},        // 2.5% of 10000.00
+		{"half a cent rounds up", 1, 5000, 1},              // 50% of 0.01
+		{"below half a cent rounds down", 1, 4999, 0},      // 49.99% of 0.01
+		{"negative half a cent rounds away", -3, 5000, -2}, // 50% of -0.03
+		{"negative amount", -1000000, 250, -25000},
+		{"zero rate", 1000000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentOf(tt.amount, tt.rate); got != tt.want {
+				t.Errorf("percentOf(%s, %s) = %s, want %s", tt.amount, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommissionBands(t *testing.T) {
+	amount := func(a money.Amount) *money.Amount { return &a }
+	tiers := []internals.CommissionTier{
+		{Threshold: 1000000, Rate: "5"},   // 5% from 10000.00
+		{Threshold: 2000000, Rate: "7.5"}, // 7.5% from 20000.00
+	}
+
+	tests := []struct {
+		name     string
+		revenue  money.Amount
+		baseRate money.Amount
+		tiers    []internals.CommissionTier
+		want     []commissionBand
+		wantErr  bool
+	}{
+		{
+			name:     "no tiers",
+			revenue:  1234500,
+			baseRate: 200,
+			want: []commissionBand{
+				{From: 0, Rate: 200, Revenue: 1234500, Commission: 24690},
+			},
+		},
+		{
+			name:     "below the first threshold",
+			revenue:  500000,
+			baseRate: 200,
+			tiers:    tiers,
+			want: []commissionBand{
+				{From: 0, To: amount(1000000), Rate: 200, Revenue: 500000, Commission: 10000},
+				{From: 1000000, To: amount(2000000), Rate: 500},
+				{From: 2000000, Rate: 750},
+			},
+		},
+		{
+			name:     "exactly on a threshold",
+			revenue:  1000000,
+			baseRate: 200,
+			tiers:    tiers,
+			want: []commissionBand{
+				{From: 0, To: amount(1000000), Rate: 200, Revenue: 1000000, Commission: 20000},
+				{From: 1000000, To: amount(2000000), Rate: 500},
+				{From: 2000000, Rate: 750},
+			},
+		},
+		{
+			name:     "one cent above a threshold",
+			revenue:  2000001,
+			baseRate: 200,
+			tiers:    tiers,
+			want: []commissionBand{
+				{From: 0, To: amount(1000000), Rate: 200, Revenue: 1000000, Commission: 20000},
+				{From: 1000000, To: amount(2000000), Rate: 500, Revenue: 1000000, Commission: 50000},
+				{From: 2000000, Rate: 750, Revenue: 1, Commission: 0},
+			},
+		},
+		{
+			name:     "through every tier",
+			revenue:  2500000,
+			baseRate: 200,
+			tiers:    tiers,
+			want: []commissionBand{
+				{From: 0, To: amount(1000000), Rate: 200, Revenue: 1000000, Commission: 20000},
+				{From: 1000000, To: amount(2000000), Rate: 500, Revenue: 1000000, Commission: 50000},
+				{From: 2000000, Rate: 750, Revenue: 500000, Commission: 37500},
+			},
+		},
+		{
+			name:     "tier at threshold 0 replaces the base rate",
+			revenue:  1500000,
+			baseRate: 200,
+			tiers:    []internals.CommissionTier{{Threshold: 0, Rate: "3"}, {Threshold: 1000000, Rate: "5"}},
+			want: []commissionBand{
+				{From: 0, To: amount(1000000), Rate: 300, Revenue: 1000000, Commission: 30000},
+				{From: 1000000, Rate: 500, Revenue: 500000, Commission: 25000},
+			},
+		},
+		{
+			name:     "negative net revenue is charged back at the base rate",
+			revenue:  -300000,
+			baseRate: 200,
+			tiers:    tiers,
+			want: []commissionBand{
+				{From: 0, To: amount(1000000), Rate: 200, Revenue: -300000, Commission: -6000},
+				{From: 1000000, To: amount(2000000), Rate: 500},
+				{From: 2000000, Rate: 750},
+			},
+		},
+		{
+			name:     "negative net revenue with a tier at threshold 0",
+			revenue:  -300000,
+			baseRate: 200,
+			tiers:    []internals.CommissionTier{{Threshold: 0, Rate: "3"}},
+			want: []commissionBand{
+				{From: 0, Rate: 300, Revenue: -300000, Commission: -9000},
+			},
+		},
+		{
+			name:     "zero revenue",
+			revenue:  0,
+			baseRate: 200,
+			tiers:    tiers[:1],
+			want: []commissionBand{
+				{From: 0, To: amount(1000000), Rate: 200},
+				{From: 1000000, Rate: 500},
+			},
+		},
+		{
+			name:     "invalid tier rate",
+			revenue:  1000000,
+			baseRate: 200,
+			tiers:    []internals.CommissionTier{{Threshold: 1000000, Rate: "abc"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := commissionBands(tt.revenue, tt.baseRate, tt.tiers)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("commissionBands() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("commissionBands() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
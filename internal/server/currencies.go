@@ -0,0 +1,191 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ISO 4217 currency code validation regex
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+type currencyRequest struct {
+	Code       string `json:"code"`
+	Name       string `json:"name"`
+	MinorUnits *int16 `json:"minor_units"`
+	Symbol     string `json:"symbol"`
+	Enabled    *bool  `json:"enabled"`
+}
+
+// Helper function to look up the registered, enabled currency a sale is written in
+func getSaleCurrency(ctx context.Context, code string) (internals.Currency, error) {
+	currency, err := queries.GetCurrency(ctx, code)
+	if err != nil {
+		return internals.Currency{}, fmt.Errorf("Unknown currency %q. Use an ISO 4217 code from /currencies", code)
+	}
+	if !currency.Enabled {
+		return internals.Currency{}, fmt.Errorf("Currency %s is disabled", code)
+	}
+	return currency, nil
+}
+
+// currencyFormats formats report amounts with the minor units and symbol of each currency
+type currencyFormats map[string]internals.Currency
+
+func loadCurrencyFormats(ctx context.Context) (currencyFormats, error) {
+	currencies, err := queries.GetCurrencies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	formats := currencyFormats{}
+	for _, currency := range currencies {
+		formats[currency.Code] = currency
+	}
+	return formats, nil
+}
+
+// format prints an amount like "4500.00 zł", unknown currencies use 2 decimals and their code
+func (formats currencyFormats) format(amount money.Amount, code string) string {
+	currency, ok := formats[code]
+	if !ok {
+		return amount.String() + " " + code
+	}
+	return amount.StringFixed(int(currency.MinorUnits)) + " " + currency.Symbol
+}
+
+func GetAllCurrencies(c echo.Context) error {
+	ctx := c.Request().Context()
+	currencies, err := queries.GetCurrencies(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get currencies"})
+	}
+	return c.JSON(200, currencies)
+}
+
+func GetCurrency(c echo.Context) error {
+	ctx := c.Request().Context()
+	code := strings.ToUpper(c.QueryParam("code"))
+	if code == "" {
+		return c.JSON(400, map[string]string{"error": "Currency code is required"})
+	}
+	currency, err := queries.GetCurrency(ctx, code)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Currency not found"})
+	}
+	return c.JSON(http.StatusOK, currency)
+}
+
+func CreateCurrency(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req currencyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid currency data"})
+	}
+
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	if !currencyCodeRegex.MatchString(req.Code) {
+		return c.JSON(400, map[string]string{"error": "Currency code must be a 3-letter ISO 4217 code"})
+	}
+	if req.Name == "" || req.Symbol == "" {
+		return c.JSON(400, map[string]string{"error": "Currency name and symbol are required"})
+	}
+	if len(req.Name) > 100 || len(req.Symbol) > 10 {
+		return c.JSON(400, map[string]string{"error": "Name must be at most 100 and symbol at most 10 characters long"})
+	}
+
+	params := internals.CreateCurrencyParams{
+		Code:       req.Code,
+		Name:       req.Name,
+		MinorUnits: 2,
+		Symbol:     req.Symbol,
+		Enabled:    true,
+	}
+	if req.MinorUnits != nil {
+		params.MinorUnits = *req.MinorUnits
+	}
+	// amounts are stored with money.Scale decimals, a currency cannot have more
+	if params.MinorUnits < 0 || params.MinorUnits > money.Scale {
+		return c.JSON(400, map[string]string{"error": fmt.Sprintf("Minor units must be between 0 and %d", money.Scale)})
+	}
+	if req.Enabled != nil {
+		params.Enabled = *req.Enabled
+	}
+
+	if _, err := queries.GetCurrency(ctx, params.Code); err == nil {
+		return c.JSON(409, map[string]string{"error": "Currency already exists"})
+	}
+
+	currency, err := queries.CreateCurrency(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create currency"})
+	}
+	return c.JSON(http.StatusCreated, currency)
+}
+
+func UpdateCurrency(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	code := strings.ToUpper(c.Param("code"))
+	if code == "" {
+		return c.JSON(400, map[string]string{"error": "Currency code is required"})
+	}
+
+	current, err := queries.GetCurrency(ctx, code)
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Currency not found"})
+	}
+
+	params := internals.UpdateCurrencyParams{
+		Code:       current.Code,
+		Name:       current.Name,
+		MinorUnits: current.MinorUnits,
+		Symbol:     current.Symbol,
+		Enabled:    current.Enabled,
+	}
+
+	var req currencyRequest
+	if err := c.Bind(&req); err == nil {
+		if req.Name != "" {
+			params.Name = req.Name
+		}
+		if req.Symbol != "" {
+			params.Symbol = req.Symbol
+		}
+		if req.MinorUnits != nil {
+			params.MinorUnits = *req.MinorUnits
+		}
+		if req.Enabled != nil {
+			params.Enabled = *req.Enabled
+		}
+	}
+
+	if enabledStr := c.QueryParam("enabled"); enabledStr != "" {
+		enabled, err := strconv.ParseBool(enabledStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid enabled format"})
+		}
+		params.Enabled = enabled
+	}
+
+	// amounts are stored with money.Scale decimals, a currency cannot have more
+	if params.MinorUnits < 0 || params.MinorUnits > money.Scale {
+		return c.JSON(400, map[string]string{"error": fmt.Sprintf("Minor units must be between 0 and %d", money.Scale)})
+	}
+	if len(params.Name) > 100 || len(params.Symbol) > 10 {
+		return c.JSON(400, map[string]string{"error": "Name must be at most 100 and symbol at most 10 characters long"})
+	}
+
+	currency, err := queries.UpdateCurrency(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update currency"})
+	}
+	return c.JSON(http.StatusOK, currency)
+}
@@ -0,0 +1,376 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Tax IDs of foreign customers are EU VAT numbers, e.g. DE123456789
+var foreignTaxIDRegex = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{2,18}$`)
+
+var customerSorts = []string{"id", "-id", "name", "-name"}
+
+type customerRequest struct {
+	Name    string  `json:"name"`
+	Company *string `json:"company"` // "" removes the value
+	TaxID   *string `json:"tax_id"`
+	Email   *string `json:"email"`
+	Address *string `json:"address"`
+}
+
+// Helper function to apply a request to a customer, fields left out keep their value
+func (req customerRequest) apply(customer *internals.Customer) {
+	if req.Name != "" {
+		customer.Name = req.Name
+	}
+	optional := func(value *string, field *sql.NullString) {
+		if value != nil {
+			trimmed := strings.TrimSpace(*value)
+			*field = sql.NullString{String: trimmed, Valid: trimmed != ""}
+		}
+	}
+	optional(req.Company, &customer.Company)
+	optional(req.TaxID, &customer.TaxID)
+	optional(req.Email, &customer.Email)
+	optional(req.Address, &customer.Address)
+}
+
+// Helper function to validate a customer and normalize its tax ID
+func validateCustomer(customer *internals.Customer) error {
+	customer.Name = strings.TrimSpace(customer.Name)
+	if customer.Name == "" {
+		return errors.New("Name is required")
+	}
+	if customer.Email.Valid && !isValidEmail(customer.Email.String) {
+		return errors.New("Invalid email format")
+	}
+	if customer.TaxID.Valid {
+		taxID, err := normalizeTaxID(customer.TaxID.String)
+		if err != nil {
+			return err
+		}
+		customer.TaxID.String = taxID
+	}
+	return nil
+}
+
+// Helper function to normalize a tax ID. A Polish NIP is stored as its 10 digits and
+// has to pass the checksum, e.g. "PL 123-456-32-18" becomes "1234563218".
+func normalizeTaxID(taxID string) (string, error) {
+	taxID = strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(taxID))
+	nip := strings.TrimPrefix(taxID, "PL")
+	if nip == taxID && foreignTaxIDRegex.MatchString(taxID) {
+		return taxID, nil
+	}
+	if len(nip) != 10 || strings.Trim(nip, "0123456789") != "" {
+		return "", errors.New("Tax ID must be a 10 digit NIP or an EU VAT number")
+	}
+	sum := 0
+	for i, weight := range []int{6, 5, 7, 2, 3, 4, 5, 6, 7} {
+		sum += weight * int(nip[i]-'0')
+	}
+	if sum%11 != int(nip[9]-'0') {
+		return "", errors.New("Invalid NIP checksum")
+	}
+	return nip, nil
+}
+
+// GetAllCustomers lists customers page by page, e.g. /customers?search=acme&sort=name&limit=20.
+// search matches the name, company, tax ID and email.
+func GetAllCustomers(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var search sql.NullString
+	if searchStr := strings.TrimSpace(c.QueryParam("search")); searchStr != "" {
+		// % and _ are wildcards in ILIKE, search for them literally
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(searchStr)
+		search = sql.NullString{String: escaped, Valid: true}
+	}
+
+	limit, err := parsePageLimit(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	sort, err := parsePageSort(c, customerSorts, "id")
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	cursor, err := parsePageCursor(c, sort)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	params := internals.ListCustomersParams{
+		Search:    search,
+		Sort:      sort,
+		PageLimit: limit + 1, // one extra row tells whether there is a next page
+	}
+	if cursor != nil {
+		params.CursorID = sql.NullInt32{Int32: cursor.ID, Valid: true}
+		params.CursorValue = sql.NullString{String: cursor.Value, Valid: true}
+	}
+
+	customers, err := queries.ListCustomers(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get customers"})
+	}
+	total, err := queries.CountCustomers(ctx, search)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to count customers"})
+	}
+
+	result := page[internals.Customer]{
+		Data: make([]internals.Customer, 0, len(customers)),
+		Meta: pageMeta{Total: total, Limit: limit, Sort: sort},
+	}
+	if len(customers) > int(limit) {
+		customers = customers[:limit]
+		last := customers[len(customers)-1]
+		next := pageCursor{Sort: sort, ID: last.ID}
+		if strings.TrimPrefix(sort, "-") == "name" {
+			next.Value = last.Name
+		}
+		result.Meta.NextCursor = next.encode()
+	}
+	result.Data = append(result.Data, customers...)
+
+	return c.JSON(200, result)
+}
+
+// GetCustomer finds a customer by ?id= or ?tax_id=
+func GetCustomer(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if taxIDStr := c.QueryParam("tax_id"); taxIDStr != "" {
+		taxID, err := normalizeTaxID(taxIDStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+		customer, err := queries.GetCustomerByTaxID(ctx, sql.NullString{String: taxID, Valid: true})
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "Customer not found"})
+		}
+		return c.JSON(http.StatusOK, customer)
+	}
+
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Customer ID or tax_id is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	customer, err := queries.GetCustomer(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Customer not found"})
+	}
+	return c.JSON(http.StatusOK, customer)
+}
+
+func CreateCustomer(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req customerRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid customer data"})
+	}
+
+	var customer internals.Customer
+	req.apply(&customer)
+	if err := validateCustomer(&customer); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	created, err := queries.CreateCustomer(ctx, internals.CreateCustomerParams{
+		Name:    customer.Name,
+		Company: customer.Company,
+		TaxID:   customer.TaxID,
+		Email:   customer.Email,
+		Address: customer.Address,
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Customer with this tax ID already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create customer"})
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+func UpdateCustomer(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Customer ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	customer, err := queries.GetCustomer(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Customer not found"})
+	}
+
+	var req customerRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid customer data"})
+	}
+	req.apply(&customer)
+	if err := validateCustomer(&customer); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	updated, err := queries.UpdateCustomer(ctx, internals.UpdateCustomerParams{
+		ID:      customer.ID,
+		Name:    customer.Name,
+		Company: customer.Company,
+		TaxID:   customer.TaxID,
+		Email:   customer.Email,
+		Address: customer.Address,
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Customer with this tax ID already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update customer"})
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeleteCustomer removes a customer, their sales are kept without a customer
+func DeleteCustomer(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Customer ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetCustomer(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Customer not found"})
+	}
+	if err := queries.DeleteCustomer(ctx, int32(id)); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete customer"})
+	}
+	return c.JSON(200, map[string]string{"message": "Customer deleted successfully"})
+}
+
+// GetCustomerSales is the purchase history of a customer, paged and sorted like /sales
+func GetCustomerSales(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid customer ID"})
+	}
+	if _, err := queries.GetCustomer(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Customer not found"})
+	}
+	return listSales(c, internals.CountSalesParams{CustomerID: sql.NullInt32{Int32: int32(id), Valid: true}})
+}
+
+// customerCurrencyTotal is what a customer bought and got refunded in one currency
+type customerCurrencyTotal struct {
+	Currency      string       `json:"currency"`
+	TotalSales    int64        `json:"total_sales"`
+	TotalRevenue  money.Amount `json:"total_revenue"`
+	TotalRefunded money.Amount `json:"total_refunded"`
+	NetRevenue    money.Amount `json:"net_revenue"`
+}
+
+// customerValue is the lifetime value of a customer: all their sales less all their refunds,
+// each converted to the base currency at the rate of its own day
+type customerValue struct {
+	Customer      internals.Customer      `json:"customer"`
+	Currency      string                  `json:"currency"`
+	TotalSales    int64                   `json:"total_sales"`
+	TotalRevenue  money.Amount            `json:"total_revenue"`
+	TotalRefunded money.Amount            `json:"total_refunded"`
+	LifetimeValue money.Amount            `json:"lifetime_value"`
+	AverageSale   money.Amount            `json:"average_sale"`
+	FirstPurchase *time.Time              `json:"first_purchase,omitempty"`
+	LastPurchase  *time.Time              `json:"last_purchase,omitempty"`
+	ByCurrency    []customerCurrencyTotal `json:"by_currency"`
+}
+
+// GetCustomerValue answers the lifetime value of a customer in the base currency
+func GetCustomerValue(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid customer ID"})
+	}
+	customer, err := queries.GetCustomer(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Customer not found"})
+	}
+
+	rows, err := queries.GetCustomerRevenueByCurrency(ctx, internals.GetCustomerRevenueByCurrencyParams{
+		BaseCurrency: rates.Base(),
+		CustomerID:   customer.ID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get customer value"})
+	}
+
+	value := customerValue{Customer: customer, Currency: rates.Base(), ByCurrency: []customerCurrencyTotal{}}
+	var missing []string
+	for _, row := range rows {
+		total := customerCurrencyTotal{Currency: row.Currency, TotalSales: row.TotalSales}
+		var baseRevenue, baseRefunded money.Amount
+		for _, field := range []struct {
+			value string
+			to    *money.Amount
+		}{
+			{row.TotalRevenue, &total.TotalRevenue},
+			{row.TotalRefunded, &total.TotalRefunded},
+			{row.BaseRevenue, &baseRevenue},
+			{row.BaseRefunded, &baseRefunded},
+		} {
+			if *field.to, err = money.Parse(field.value); err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to get customer value"})
+			}
+		}
+		total.NetRevenue = total.TotalRevenue - total.TotalRefunded
+		if row.MissingRates > 0 {
+			missing = append(missing, row.Currency)
+		}
+
+		value.ByCurrency = append(value.ByCurrency, total)
+		value.TotalSales += row.TotalSales
+		value.TotalRevenue += baseRevenue
+		value.TotalRefunded += baseRefunded
+		if row.FirstSaleDate.Valid && (value.FirstPurchase == nil || row.FirstSaleDate.Time.Before(*value.FirstPurchase)) {
+			value.FirstPurchase = &row.FirstSaleDate.Time
+		}
+		if row.LastSaleDate.Valid && (value.LastPurchase == nil || row.LastSaleDate.Time.After(*value.LastPurchase)) {
+			value.LastPurchase = &row.LastSaleDate.Time
+		}
+	}
+	if len(missing) > 0 {
+		return c.JSON(400, map[string]string{"error": fmt.Sprintf("%s to %s for: %s", errMissingBaseRates, rates.Base(), strings.Join(missing, ", "))})
+	}
+
+	value.LifetimeValue = value.TotalRevenue - value.TotalRefunded
+	if value.TotalSales > 0 {
+		value.AverageSale = value.TotalRevenue / money.Amount(value.TotalSales)
+	}
+	return c.JSON(200, value)
+}
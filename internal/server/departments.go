@@ -0,0 +1,225 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type departmentRequest struct {
+	Name        string  `json:"name"`
+	Description *string `json:"description"` // "" removes the value
+	ParentID    *int32  `json:"parent_id"`   // 0 makes it a top-level department
+}
+
+// departmentNode is a department with the teams below it, as returned by /departments/tree
+type departmentNode struct {
+	internals.Department
+	EmployeeCount int               `json:"employee_count"` // employees of the department itself, not of its teams
+	Teams         []*departmentNode `json:"teams"`
+}
+
+// Helper function to apply a request to a department, fields left out keep their value.
+// The parent has to exist and must not be the department itself or one of its teams,
+// q is the transaction holding LockDepartmentChanges when an existing department moves.
+func (req departmentRequest) apply(ctx context.Context, q *internals.Queries, department *internals.Department) error {
+	if req.Name != "" {
+		department.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		department.Description = sql.NullString{String: description, Valid: description != ""}
+	}
+	if department.Name == "" || len(department.Name) > 100 {
+		return errors.New("Name must be 1-100 characters")
+	}
+
+	if req.ParentID == nil {
+		return nil
+	}
+	department.ParentID = sql.NullInt32{Int32: *req.ParentID, Valid: *req.ParentID != 0}
+	if !department.ParentID.Valid {
+		return nil
+	}
+	if _, err := q.GetDepartment(ctx, *req.ParentID); err != nil {
+		return errors.New("Parent department not found")
+	}
+	if department.ID != 0 {
+		below, err := q.IsSubDepartment(ctx, internals.IsSubDepartmentParams{
+			RootID:       department.ID,
+			DepartmentID: *req.ParentID,
+		})
+		if err != nil {
+			return err
+		}
+		if below {
+			return errors.New("A department cannot be placed below itself or one of its teams")
+		}
+	}
+	return nil
+}
+
+func GetAllDepartments(c echo.Context) error {
+	departments, err := queries.GetDepartments(c.Request().Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get departments"})
+	}
+	if departments == nil {
+		departments = []internals.Department{}
+	}
+	return c.JSON(200, departments)
+}
+
+func GetDepartment(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Department ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	department, err := queries.GetDepartment(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Department not found"})
+	}
+	return c.JSON(http.StatusOK, department)
+}
+
+// GetDepartmentTree answers /departments/tree with the top-level departments and their teams, nested
+func GetDepartmentTree(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	departments, err := queries.GetDepartments(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get departments"})
+	}
+	employees, err := queries.GetEmployees(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get employees"})
+	}
+
+	nodes := make(map[int32]*departmentNode, len(departments))
+	for _, department := range departments {
+		nodes[department.ID] = &departmentNode{Department: department, Teams: []*departmentNode{}}
+	}
+	for _, employee := range employees {
+		if node, ok := nodes[employee.DepartmentID.Int32]; ok && employee.DepartmentID.Valid {
+			node.EmployeeCount++
+		}
+	}
+	roots := []*departmentNode{}
+	for _, department := range departments {
+		node := nodes[department.ID]
+		if parent, ok := nodes[department.ParentID.Int32]; ok && department.ParentID.Valid {
+			parent.Teams = append(parent.Teams, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return c.JSON(200, roots)
+}
+
+// CreateDepartment adds a department, or a team inside one with "parent_id"
+func CreateDepartment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req departmentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid department data"})
+	}
+	var department internals.Department
+	if err := req.apply(ctx, queries, &department); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	created, err := queries.CreateDepartment(ctx, internals.CreateDepartmentParams{
+		Name:        department.Name,
+		Description: department.Description,
+		ParentID:    department.ParentID,
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Department with this name already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create department"})
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+func UpdateDepartment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	var req departmentRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid department data"})
+	}
+
+	// the cycle check and the update run under the lock, so that concurrent moves
+	// such as A below B and B below A cannot both pass the check
+	var updated internals.Department
+	err = withTx(ctx, func(q *internals.Queries) error {
+		if err := q.LockDepartmentChanges(ctx); err != nil {
+			return err
+		}
+		department, err := q.GetDepartment(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if err := req.apply(ctx, q, &department); err != nil {
+			return rejection(err.Error())
+		}
+
+		updated, err = q.UpdateDepartment(ctx, internals.UpdateDepartmentParams{
+			ID:          department.ID,
+			Name:        department.Name,
+			Description: department.Description,
+			ParentID:    department.ParentID,
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Department not found"})
+	}
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Department with this name already exists"})
+	}
+	if err != nil {
+		return rejectionError(c, err, "Failed to update department")
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeleteDepartment removes a department without teams, its employees are left without a department
+func DeleteDepartment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	department, err := queries.GetDepartment(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Department not found"})
+	}
+	err = queries.DeleteDepartment(ctx, department.ID)
+	if isForeignKeyViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Department has teams, move or delete them first"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete department"})
+	}
+	return c.JSON(200, map[string]string{"message": "Department deleted successfully"})
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// discountRequest is the discount asked for on a sale: discount_percent, discount_amount
+// in the sale currency or a promo_code. A percent or amount of 0 removes the discount.
+type discountRequest struct {
+	Percent   *money.Amount
+	Amount    *money.Amount
+	PromoCode string
+}
+
+func (request discountRequest) given() bool {
+	return request.Percent != nil || request.Amount != nil || request.PromoCode != ""
+}
+
+// saleDiscount is the discount taken off the lines of a sale. Percentages are kept in
+// hundredths of a percent like tax rates, so that 1050 is 10.5%.
+type saleDiscount struct {
+	Percent     money.Amount
+	Amount      money.Amount // fixed amount in the sale currency
+	PromotionID sql.NullInt32
+}
+
+// Helper function to validate the discount of a sale in the given currency on the day of the sale
+func resolveDiscount(ctx context.Context, request discountRequest, currency internals.Currency, saleDate time.Time) (saleDiscount, error) {
+	given := 0
+	for _, ok := range []bool{request.Percent != nil, request.Amount != nil, request.PromoCode != ""} {
+		if ok {
+			given++
+		}
+	}
+	if given > 1 {
+		return saleDiscount{}, errors.New("Give only one of discount_percent, discount_amount and promo_code")
+	}
+
+	switch {
+	case request.Percent != nil:
+		if err := validateDiscountPercent(*request.Percent); err != nil {
+			return saleDiscount{}, err
+		}
+		return saleDiscount{Percent: *request.Percent}, nil
+	case request.Amount != nil:
+		if *request.Amount < 0 {
+			return saleDiscount{}, errors.New("Discount amount cannot be negative")
+		}
+		if !request.Amount.HasDecimals(int(currency.MinorUnits)) {
+			return saleDiscount{}, fmt.Errorf("Discount in %s can have at most %d decimal places", currency.Code, currency.MinorUnits)
+		}
+		return saleDiscount{Amount: *request.Amount}, nil
+	case request.PromoCode != "":
+		return promotionDiscount(ctx, request.PromoCode, currency, saleDate)
+	}
+	return saleDiscount{}, nil
+}
+
+func validateDiscountPercent(percent money.Amount) error {
+	if percent < 0 || percent >= 10000 {
+		return errors.New("Discount percent must be at least 0 and lower than 100")
+	}
+	return nil
+}
+
+// Helper function to look up a promotion code and check that it can be used on the sale
+func promotionDiscount(ctx context.Context, code string, currency internals.Currency, saleDate time.Time) (saleDiscount, error) {
+	promotion, err := queries.GetPromotionByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return saleDiscount{}, errors.New("Promotion code not found")
+	}
+	if !promotion.Active {
+		return saleDiscount{}, fmt.Errorf("Promotion %s is not active", promotion.Code)
+	}
+
+	// Validity dates are whole days, both inclusive
+	day := time.Date(saleDate.Year(), saleDate.Month(), saleDate.Day(), 0, 0, 0, 0, time.UTC)
+	if (promotion.ValidFrom.Valid && day.Before(promotion.ValidFrom.Time)) || (promotion.ValidTo.Valid && day.After(promotion.ValidTo.Time)) {
+		return saleDiscount{}, fmt.Errorf("Promotion %s is not valid on %s", promotion.Code, day.Format("2006-01-02"))
+	}
+
+	discount := saleDiscount{PromotionID: sql.NullInt32{Int32: promotion.ID, Valid: true}}
+	if promotion.DiscountAmount > 0 {
+		if promotion.Currency.String != currency.Code {
+			return saleDiscount{}, fmt.Errorf("Promotion %s is in %s, the sale is in %s", promotion.Code, promotion.Currency.String, currency.Code)
+		}
+		discount.Amount = promotion.DiscountAmount
+		return discount, nil
+	}
+	if discount.Percent, err = money.Parse(promotion.DiscountPercent); err != nil {
+		return saleDiscount{}, err
+	}
+	return discount, nil
+}
+
+// Helper function to keep the discount of a stored sale when its lines are priced again.
+// A fixed discount cannot follow the sale into another currency.
+func storedDiscount(sale internals.Sale, currency internals.Currency) (saleDiscount, error) {
+	discount := saleDiscount{PromotionID: sale.PromotionID}
+	percent, err := money.Parse(sale.DiscountPercent)
+	if err != nil {
+		return saleDiscount{}, err
+	}
+	if percent > 0 {
+		discount.Percent = percent
+		return discount, nil
+	}
+	if sale.DiscountAmount > 0 && sale.Currency != currency.Code {
+		return saleDiscount{}, fmt.Errorf("Sale has a discount in %s, give discount_amount in %s", sale.Currency, currency.Code)
+	}
+	discount.Amount = sale.DiscountAmount
+	return discount, nil
+}
+
+var errDiscountTooHigh = errors.New("Discount must be lower than the sale total")
+
+// Helper function to spread the discount over the lines of a sale and tax what is left of each line.
+// A percentage is taken off every line, a fixed amount is shared in proportion to the line totals.
+// Discounts are rounded to the minor units of the currency.
+func applyDiscount(lines []internals.CreateSaleItemParams, discount saleDiscount, currency internals.Currency) error {
+	// step is the smallest amount of the currency, e.g. 1.00 for currencies without minor units
+	step := money.Amount(1)
+	for units := int(currency.MinorUnits); units < 2; units++ {
+		step *= 10
+	}
+
+	gross := make([]money.Amount, len(lines))
+	var total money.Amount
+	for i, line := range lines {
+		gross[i] = line.UnitPrice * money.Amount(line.Quantity)
+		total += gross[i]
+	}
+
+	shares := make([]money.Amount, len(lines))
+	switch {
+	case discount.Percent > 0:
+		for i := range lines {
+			// Round half up to whole steps
+			shares[i] = (2*gross[i]*discount.Percent + 10000*step) / (2 * 10000 * step) * step
+		}
+	case discount.Amount > 0:
+		if discount.Amount >= total {
+			return errDiscountTooHigh
+		}
+		// Every line gets its share rounded down, the steps left over go to the first lines
+		var shared money.Amount
+		for i := range lines {
+			hi, lo := bits.Mul64(uint64(discount.Amount), uint64(gross[i]))
+			share, _ := bits.Div64(hi, lo, uint64(total))
+			shares[i] = money.Amount(share) / step * step
+			shared += shares[i]
+		}
+		for i := 0; shared < discount.Amount; i = (i + 1) % len(lines) {
+			if shares[i]+step <= gross[i] {
+				shares[i] += step
+				shared += step
+			}
+		}
+	}
+
+	var discounted money.Amount
+	for _, share := range shares {
+		discounted += share
+	}
+	if discounted >= total {
+		return errDiscountTooHigh
+	}
+
+	for i := range lines {
+		rate, err := parseTaxRate(lines[i].TaxRate)
+		if err != nil {
+			return err
+		}
+		lines[i].DiscountAmount = shares[i]
+		lines[i].NetAmount, lines[i].TaxAmount = splitGross(gross[i]-shares[i], rate)
+	}
+	return nil
+}
+
+// Helper function to read the discount from ?discount_percent=, ?discount_amount= or ?promo_code=
+func parseDiscountQuery(c echo.Context) (discountRequest, error) {
+	request := discountRequest{PromoCode: c.QueryParam("promo_code")}
+	if percentStr := c.QueryParam("discount_percent"); percentStr != "" {
+		percent, err := money.Parse(percentStr)
+		if err != nil {
+			return request, errors.New("Invalid discount_percent format. Use at most 2 decimal places")
+		}
+		request.Percent = &percent
+	}
+	if amountStr := c.QueryParam("discount_amount"); amountStr != "" {
+		amount, err := money.Parse(amountStr)
+		if err != nil {
+			return request, errors.New("Invalid discount_amount format. Use at most 2 decimal places")
+		}
+		request.Amount = &amount
+	}
+	return request, nil
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestApplyDiscount(t *testing.T) {
+	pln := internals.Currency{Code: "PLN", MinorUnits: 2}
+	jpy := internals.Currency{Code: "JPY", MinorUnits: 0}
+
+	// line is a sale line of quantity items at unitPrice, taxed at 23%
+	line := func(unitPrice money.Amount, quantity int32) internals.CreateSaleItemParams {
+		return internals.CreateSaleItemParams{UnitPrice: unitPrice, Quantity: quantity, TaxRate: "23"}
+	}
+
+	tests := []struct {
+		name     string
+		lines    []internals.CreateSaleItemParams
+		discount saleDiscount
+		currency internals.Currency
+		want     []money.Amount // discount of every line
+		wantErr  error
+	}{
+		{
+			name:     "no discount",
+			lines:    []internals.CreateSaleItemParams{line(12300, 1)},
+			currency: pln,
+			want:     []money.Amount{0},
+		},
+		{
+			name:     "percent rounds half up to the cent",
+			lines:    []internals.CreateSaleItemParams{line(3333, 1), line(5, 1), line(4, 1)},
+			discount: saleDiscount{Percent: 1000}, // 10%
+			currency: pln,
+			want:     []money.Amount{333, 1, 0},
+		},
+		{
+			name:     "percent rounds to whole units without minor units",
+			lines:    []internals.CreateSaleItemParams{line(1500, 1), line(1400, 1)},
+			discount: saleDiscount{Percent: 1000},
+			currency: jpy,
+			want:     []money.Amount{200, 100},
+		},
+		{
+			name:     "percent of a line with a quantity",
+			lines:    []internals.CreateSaleItemParams{line(1999, 3)},
+			discount: saleDiscount{Percent: 1250}, // 12.5% of 59.97 is 7.49625
+			currency: pln,
+			want:     []money.Amount{750},
+		},
+		{
+			name:     "amount is shared in proportion to the line totals",
+			lines:    []internals.CreateSaleItemParams{line(1000, 3), line(1000, 1)},
+			discount: saleDiscount{Amount: 400},
+			currency: pln,
+			want:     []money.Amount{300, 100},
+		},
+		{
+			name:     "leftover cents go to the first lines",
+			lines:    []internals.CreateSaleItemParams{line(1000, 1), line(1000, 1), line(1000, 1)},
+			discount: saleDiscount{Amount: 200},
+			currency: pln,
+			want:     []money.Amount{67, 67, 66},
+		},
+		{
+			name:     "leftover whole units go to the first lines without minor units",
+			lines:    []internals.CreateSaleItemParams{line(1000, 1), line(1000, 1), line(1000, 1)},
+			discount: saleDiscount{Amount: 200},
+			currency: jpy,
+			want:     []money.Amount{100, 100, 0},
+		},
+		{
+			name:     "amount just below the total",
+			lines:    []internals.CreateSaleItemParams{line(1, 1), line(999, 1)},
+			discount: saleDiscount{Amount: 999},
+			currency: pln,
+			want:     []money.Amount{1, 998},
+		},
+		{
+			name:     "amount equal to the total",
+			lines:    []internals.CreateSaleItemParams{line(600, 1), line(400, 1)},
+			discount: saleDiscount{Amount: 1000},
+			currency: pln,
+			wantErr:  errDiscountTooHigh,
+		},
+		{
+			name:     "amount above the total",
+			lines:    []internals.CreateSaleItemParams{line(600, 1)},
+			discount: saleDiscount{Amount: 601},
+			currency: pln,
+			wantErr:  errDiscountTooHigh,
+		},
+		{
+			name:     "percent rounded up to the total",
+			lines:    []internals.CreateSaleItemParams{line(1, 1)},
+			discount: saleDiscount{Percent: 9999}, // 99.99% of 0.01
+			currency: pln,
+			wantErr:  errDiscountTooHigh,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyDiscount(tt.lines, tt.discount, tt.currency)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("applyDiscount() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			got := make([]money.Amount, len(tt.lines))
+			for i, line := range tt.lines {
+				got[i] = line.DiscountAmount
+				gross := line.UnitPrice * money.Amount(line.Quantity)
+				if line.NetAmount+line.TaxAmount != gross-line.DiscountAmount {
+					t.Errorf("line %d: net %s + tax %s, want %s", i, line.NetAmount, line.TaxAmount, gross-line.DiscountAmount)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("applyDiscount() discounts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errAlreadyTerminated = errors.New("Employee is already terminated")
+
+// employeeRequest is the JSON body of POST /employee and PUT /employee/:id
+type employeeRequest struct {
+	Name    string `json:"name"`
+	Surname string `json:"surname"`
+	Email   string `json:"email"`
+	employmentRequest
+}
+
+// employmentRequest are the employment details of an employee, fields left out keep their value
+type employmentRequest struct {
+	HireDate string  `json:"hire_date"`
+	JobTitle *string `json:"job_title"` // "" removes the value
+	Status   string  `json:"status"`    // active or inactive, making an employee active again clears the termination date
+}
+
+// Helper function to read the employment details given as query parameters, they take precedence over the JSON body
+func (req *employmentRequest) fromQuery(c echo.Context) {
+	if hireDate := c.QueryParam("hire_date"); hireDate != "" {
+		req.HireDate = hireDate
+	}
+	if values, ok := c.QueryParams()["job_title"]; ok && len(values) > 0 {
+		req.JobTitle = &values[0]
+	}
+	if status := c.QueryParam("status"); status != "" {
+		req.Status = status
+	}
+}
+
+// Helper function to apply the employment details to an employee.
+// The hire date must not be after the termination date.
+func (req employmentRequest) apply(employee *internals.Employee) error {
+	if req.HireDate != "" {
+		hireDate, err := parseDate(req.HireDate)
+		if err != nil {
+			return errors.New("Invalid hire_date format. Use YYYY-MM-DD")
+		}
+		employee.HireDate = hireDate.Truncate(24 * time.Hour)
+	}
+	if req.JobTitle != nil {
+		jobTitle := strings.TrimSpace(*req.JobTitle)
+		if len(jobTitle) > 100 {
+			return errors.New("Job title must be at most 100 characters long")
+		}
+		employee.JobTitle = sql.NullString{String: jobTitle, Valid: jobTitle != ""}
+	}
+	switch req.Status {
+	case "":
+	case "active":
+		employee.Status = req.Status
+		employee.TerminationDate = sql.NullTime{}
+	case "inactive":
+		employee.Status = req.Status
+	default:
+		return errors.New("Invalid status (active, inactive)")
+	}
+	if employee.TerminationDate.Valid && employee.TerminationDate.Time.Before(employee.HireDate) {
+		return errors.New("Hire date cannot be after the termination date")
+	}
+	return nil
+}
+
+// Helper function to fill the employment details of a new employee, who always starts active
+func (req employmentRequest) createParams(params *internals.CreateEmployeeParams) error {
+	if req.Status != "" && req.Status != "active" {
+		return errors.New("New employees are active, use /employee/:id/terminate for leavers")
+	}
+	var employee internals.Employee
+	if err := req.apply(&employee); err != nil {
+		return err
+	}
+	params.HireDate = sql.NullTime{Time: employee.HireDate, Valid: !employee.HireDate.IsZero()}
+	params.JobTitle = employee.JobTitle
+	return nil
+}
+
+// Helper function to check that an employee may make a sale on the given date
+func checkEmployeeCanSell(employee internals.Employee, saleDate time.Time) error {
+	if employee.Status != "active" {
+		return errors.New("Employee is inactive and cannot make sales")
+	}
+	if saleDate.Before(employee.HireDate) {
+		return errors.New("Sale date is before the employee's hire date")
+	}
+	if employee.TerminationDate.Valid && !saleDate.Before(employee.TerminationDate.Time.AddDate(0, 0, 1)) {
+		return errors.New("Sale date is after the employee's termination date")
+	}
+	return nil
+}
+
+// Helper function to read the last working day of an employee, today when it is not given.
+// It can be neither in the future nor before the hire date.
+func parseTerminationDate(terminationDateStr string, employee internals.Employee) (time.Time, error) {
+	terminationDate, err := parseDate(terminationDateStr)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid termination_date format. Use YYYY-MM-DD")
+	}
+	terminationDate = terminationDate.Truncate(24 * time.Hour)
+	if terminationDate.After(time.Now()) {
+		return time.Time{}, errors.New("Termination date cannot be in the future")
+	}
+	if terminationDate.Before(employee.HireDate) {
+		return time.Time{}, errors.New("Termination date cannot be before the hire date")
+	}
+	return terminationDate, nil
+}
+
+// TerminateEmployee ends the employment of a leaver on ?termination_date= or {"termination_date": ...},
+// today by default. The employee is kept with their sales but becomes inactive.
+func TerminateEmployee(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+
+	terminationDateStr := c.QueryParam("termination_date")
+	if terminationDateStr == "" {
+		var body struct {
+			TerminationDate string `json:"termination_date"`
+		}
+		if err := c.Bind(&body); err == nil {
+			terminationDateStr = body.TerminationDate
+		}
+	}
+
+	var terminated internals.Employee
+	err = withTx(ctx, func(q *internals.Queries) error {
+		employee, err := q.GetEmployeeForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if employee.TerminationDate.Valid {
+			return errAlreadyTerminated
+		}
+		terminationDate, err := parseTerminationDate(terminationDateStr, employee)
+		if err != nil {
+			return rejection(err.Error())
+		}
+
+		terminated, err = q.TerminateEmployee(ctx, internals.TerminateEmployeeParams{
+			ID:              employee.ID,
+			TerminationDate: terminationDate,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{
+			Entity: auditEmployee, EntityID: employee.ID, Action: "update",
+			Before: employee, After: terminated,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if errors.Is(err, errAlreadyTerminated) {
+		return c.JSON(409, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return rejectionError(c, err, "Failed to terminate employee")
+	}
+	return c.JSON(http.StatusOK, terminated)
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/exchange"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/labstack/echo/v4"
+)
+
+type exchangeRateRequest struct {
+	FromCurrency string  `json:"from_currency"`
+	ToCurrency   string  `json:"to_currency"`
+	RateDate     string  `json:"rate_date"`
+	Rate         float64 `json:"rate"`
+}
+
+// Helper function to check if a database error is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// Helper function to check if a database error is a foreign key violation
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
+
+func GetExchangeRate(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Exchange rate ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	rate, err := queries.GetExchangeRate(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Exchange rate not found"})
+	}
+	return c.JSON(http.StatusOK, rate)
+}
+
+func GetAllExchangeRates(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params internals.GetExchangeRatesParams
+	if from := c.QueryParam("from_currency"); from != "" {
+		params.FromCurrency = sql.NullString{String: strings.ToUpper(from), Valid: true}
+	}
+	if to := c.QueryParam("to_currency"); to != "" {
+		params.ToCurrency = sql.NullString{String: strings.ToUpper(to), Valid: true}
+	}
+
+	rates, err := queries.GetExchangeRates(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get exchange rates"})
+	}
+	return c.JSON(200, rates)
+}
+
+func CreateExchangeRate(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req exchangeRateRequest
+	if err := c.Bind(&req); err != nil || req.FromCurrency == "" {
+		req = exchangeRateRequest{
+			FromCurrency: c.QueryParam("from_currency"),
+			ToCurrency:   c.QueryParam("to_currency"),
+			RateDate:     c.QueryParam("rate_date"),
+		}
+		if rateStr := c.QueryParam("rate"); rateStr != "" {
+			rate, err := strconv.ParseFloat(rateStr, 64)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid rate format"})
+			}
+			req.Rate = rate
+		}
+	}
+
+	if req.FromCurrency == "" || req.ToCurrency == "" || req.RateDate == "" || req.Rate == 0 {
+		return c.JSON(400, map[string]string{
+			"error": "Provide exchange rate data either as JSON body or query parameters (from_currency, to_currency, rate_date, rate)",
+		})
+	}
+
+	rateDate, err := parseDate(req.RateDate)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid rate_date format"})
+	}
+	rate := exchange.Rate{From: req.FromCurrency, To: req.ToCurrency, Date: rateDate, Rate: req.Rate}
+	if err := rate.Validate(); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	created, err := queries.CreateExchangeRate(ctx, internals.CreateExchangeRateParams{
+		FromCurrency: rate.From,
+		ToCurrency:   rate.To,
+		RateDate:     rate.Date,
+		Rate:         strconv.FormatFloat(rate.Rate, 'f', -1, 64),
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Exchange rate for this currency pair and date already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create exchange rate"})
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+func UpdateExchangeRate(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Exchange rate ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	current, err := queries.GetExchangeRate(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Exchange rate not found"})
+	}
+	currentRate, err := strconv.ParseFloat(current.Rate, 64)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to read exchange rate"})
+	}
+	rate := exchange.Rate{From: current.FromCurrency, To: current.ToCurrency, Date: current.RateDate, Rate: currentRate}
+
+	var req exchangeRateRequest
+	if err := c.Bind(&req); err == nil {
+		if req.FromCurrency != "" {
+			rate.From = req.FromCurrency
+		}
+		if req.ToCurrency != "" {
+			rate.To = req.ToCurrency
+		}
+		if req.RateDate != "" {
+			rateDate, err := parseDate(req.RateDate)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid rate_date format"})
+			}
+			rate.Date = rateDate
+		}
+		if req.Rate != 0 {
+			rate.Rate = req.Rate
+		}
+	}
+
+	if from := c.QueryParam("from_currency"); from != "" {
+		rate.From = from
+	}
+	if to := c.QueryParam("to_currency"); to != "" {
+		rate.To = to
+	}
+	if rateDateStr := c.QueryParam("rate_date"); rateDateStr != "" {
+		rateDate, err := parseDate(rateDateStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid rate_date format"})
+		}
+		rate.Date = rateDate
+	}
+	if rateStr := c.QueryParam("rate"); rateStr != "" {
+		value, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid rate format"})
+		}
+		rate.Rate = value
+	}
+
+	if err := rate.Validate(); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	updated, err := queries.UpdateExchangeRate(ctx, internals.UpdateExchangeRateParams{
+		ID:           current.ID,
+		FromCurrency: rate.From,
+		ToCurrency:   rate.To,
+		RateDate:     rate.Date,
+		Rate:         strconv.FormatFloat(rate.Rate, 'f', -1, 64),
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Exchange rate for this currency pair and date already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update exchange rate"})
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+func DeleteExchangeRate(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Exchange rate ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetExchangeRate(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Exchange rate not found"})
+	}
+	if err := queries.DeleteExchangeRate(ctx, int32(id)); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete exchange rate"})
+	}
+	return c.JSON(200, map[string]string{"message": "Exchange rate deleted successfully"})
+}
+
+// ImportExchangeRates loads rates from an uploaded CSV/JSON file ("file" form field)
+// or from the raw request body. Existing rates for the same pair and date are replaced.
+func ImportExchangeRates(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var body io.Reader = c.Request().Body
+	format := strings.ToLower(c.QueryParam("format"))
+
+	if file, err := c.FormFile("file"); err == nil {
+		src, err := file.Open()
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Failed to read uploaded file"})
+		}
+		defer src.Close()
+		body = src
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+		}
+	}
+	if format == "" {
+		contentType := c.Request().Header.Get(echo.HeaderContentType)
+		switch {
+		case strings.Contains(contentType, "json"):
+			format = "json"
+		case strings.Contains(contentType, "csv"):
+			format = "csv"
+		}
+	}
+
+	var parsed []exchange.Rate
+	var err error
+	switch format {
+	case "csv":
+		parsed, err = exchange.ParseCSV(body)
+	case "json":
+		parsed, err = exchange.ParseJSON(body)
+	default:
+		return c.JSON(400, map[string]string{"error": "Unknown rates format. Upload a .csv/.json file or pass ?format=csv|json"})
+	}
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	imported, err := rates.Import(ctx, parsed)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to import exchange rates, none of them was stored"})
+	}
+	return c.JSON(200, map[string]int{"imported": imported})
+}
@@ -0,0 +1,249 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errSalesRemain = errors.New("Employee still has sales outside the filter, reassign all of them to delete the employee")
+
+type offboardingRequest struct {
+	ReassignTo      int32  `json:"reassign_to"` // 0 moves the sales to the unassigned pool
+	From            string `json:"from"`        // the sales to reassign, all of them when no filter is given
+	To              string `json:"to"`
+	Category        string `json:"category"`
+	CustomerID      int32  `json:"customer_id"`
+	Action          string `json:"action"`           // deactivate (the default) or delete
+	TerminationDate string `json:"termination_date"` // last working day when deactivating, today by default
+	PerformedBy     string `json:"performed_by"`
+}
+
+type offboardingResponse struct {
+	Offboarding       internals.Offboarding `json:"offboarding"`
+	Employee          *internals.Employee   `json:"employee,omitempty"` // omitted when the employee was deleted
+	ReassignedSaleIDs []int32               `json:"reassigned_sale_ids"`
+}
+
+// Helper function to validate an offboarding request and turn it into the sales to reassign
+func (req offboardingRequest) params(c echo.Context, employee internals.Employee) (internals.ReassignEmployeeSalesParams, error) {
+	ctx := c.Request().Context()
+
+	params := internals.ReassignEmployeeSalesParams{EmployeeID: employee.ID}
+	if req.ReassignTo != 0 {
+		if req.ReassignTo == employee.ID {
+			return params, errors.New("Sales cannot be reassigned to the same employee")
+		}
+		target, err := queries.GetEmployee(ctx, req.ReassignTo)
+		if err != nil {
+			return params, errors.New("Employee to reassign the sales to not found")
+		}
+		if target.Status != "active" {
+			return params, errors.New("Sales can only be reassigned to an active employee")
+		}
+		params.ToEmployeeID = sql.NullInt32{Int32: target.ID, Valid: true}
+	}
+	if req.From != "" {
+		from, err := parseDate(req.From)
+		if err != nil {
+			return params, errors.New("Invalid from date format. Use YYYY-MM-DD")
+		}
+		params.FromDate = sql.NullTime{Time: from.Truncate(24 * time.Hour), Valid: true}
+	}
+	if req.To != "" {
+		to, err := parseDate(req.To)
+		if err != nil {
+			return params, errors.New("Invalid to date format. Use YYYY-MM-DD")
+		}
+		// to is inclusive
+		params.ToDate = sql.NullTime{Time: to.Truncate(24*time.Hour).AddDate(0, 0, 1), Valid: true}
+	}
+	if params.FromDate.Valid && params.ToDate.Valid && !params.FromDate.Time.Before(params.ToDate.Time) {
+		return params, errors.New("from must not be after to")
+	}
+	if req.Category != "" {
+		params.Category = sql.NullString{String: req.Category, Valid: true}
+	}
+	if req.CustomerID != 0 {
+		params.CustomerID = sql.NullInt32{Int32: req.CustomerID, Valid: true}
+	}
+	return params, nil
+}
+
+// OffboardEmployee hands over the sales of a leaver, all of them or those matching from, to, category
+// and customer_id, to another employee or to the unassigned pool, and then deactivates or deletes the employee.
+// All of it happens in one transaction and is recorded with performed_by in the offboardings.
+func OffboardEmployee(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+	var req offboardingRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid offboarding data"})
+	}
+
+	req.PerformedBy = strings.TrimSpace(req.PerformedBy)
+	if req.PerformedBy == "" || len(req.PerformedBy) > 255 {
+		return c.JSON(400, map[string]string{"error": "performed_by is required and must be at most 255 characters long"})
+	}
+	if req.Action == "" {
+		req.Action = "deactivate"
+	}
+	if req.Action != "deactivate" && req.Action != "delete" {
+		return c.JSON(400, map[string]string{"error": "Invalid action (deactivate, delete)"})
+	}
+
+	employee, err := queries.GetEmployee(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	params, err := req.params(c, employee)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	response := offboardingResponse{}
+	err = withTx(ctx, func(q *internals.Queries) error {
+		// the leaver is read again and locked, the audit entries start from that row
+		employee, err := q.GetEmployeeForUpdate(ctx, employee.ID)
+		if err != nil {
+			return err
+		}
+		var terminationDate time.Time
+		if req.Action == "deactivate" && !employee.TerminationDate.Valid {
+			if terminationDate, err = parseTerminationDate(req.TerminationDate, employee); err != nil {
+				return rejection(err.Error())
+			}
+		}
+
+		reassigned, err := q.ReassignEmployeeSales(ctx, params)
+		if err != nil {
+			return err
+		}
+		if params.ToEmployeeID.Valid {
+			// the new employee is locked and has to have worked here on the date of every sale handed over
+			target, err := q.GetEmployeeForUpdate(ctx, params.ToEmployeeID.Int32)
+			if errors.Is(err, sql.ErrNoRows) {
+				return rejection("Employee to reassign the sales to not found")
+			}
+			if err != nil {
+				return err
+			}
+			for _, sale := range reassigned {
+				if err := checkEmployeeCanSell(target, sale.SaleDate); err != nil {
+					return rejection(fmt.Sprintf("Sale %d cannot be reassigned: %s", sale.ID, err))
+				}
+			}
+		}
+		changes := make([]auditChange, 0, len(reassigned)+1)
+		for _, before := range reassigned {
+			after, err := q.GetSale(ctx, before.ID)
+			if err != nil {
+				return err
+			}
+			response.ReassignedSaleIDs = append(response.ReassignedSaleIDs, before.ID)
+			changes = append(changes, auditChange{
+				Entity: auditSale, EntityID: before.ID, Action: "update",
+				Before: before, After: after, Actor: req.PerformedBy,
+			})
+		}
+
+		switch {
+		case req.Action == "delete":
+			remaining, err := q.CountSales(ctx, internals.CountSalesParams{
+				EmployeeID: sql.NullInt32{Int32: employee.ID, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			if remaining > 0 {
+				return errSalesRemain
+			}
+			if _, err := q.DeleteEmployee(ctx, employee.ID); err != nil {
+				return err
+			}
+			changes = append(changes, auditChange{
+				Entity: auditEmployee, EntityID: employee.ID, Action: "delete",
+				Before: employee, Actor: req.PerformedBy,
+			})
+		case employee.TerminationDate.Valid:
+			// already terminated, only the sales are handed over
+			response.Employee = &employee
+		default:
+			terminated, err := q.TerminateEmployee(ctx, internals.TerminateEmployeeParams{
+				ID:              employee.ID,
+				TerminationDate: terminationDate,
+			})
+			if err != nil {
+				return err
+			}
+			response.Employee = &terminated
+			changes = append(changes, auditChange{
+				Entity: auditEmployee, EntityID: employee.ID, Action: "update",
+				Before: employee, After: terminated, Actor: req.PerformedBy,
+			})
+		}
+		if err := recordAudit(c, q, changes...); err != nil {
+			return err
+		}
+
+		response.Offboarding, err = q.CreateOffboarding(ctx, internals.CreateOffboardingParams{
+			EmployeeID:      employee.ID,
+			EmployeeName:    employee.Name,
+			EmployeeSurname: employee.Surname,
+			EmployeeEmail:   employee.Email,
+			ReassignedTo:    params.ToEmployeeID,
+			FromDate:        params.FromDate,
+			ToDate:          sql.NullTime{Time: params.ToDate.Time.AddDate(0, 0, -1), Valid: params.ToDate.Valid},
+			Category:        params.Category,
+			CustomerID:      params.CustomerID,
+			SalesReassigned: int32(len(response.ReassignedSaleIDs)),
+			Action:          req.Action,
+			PerformedBy:     req.PerformedBy,
+		})
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if errors.Is(err, errSalesRemain) {
+		return c.JSON(409, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return rejectionError(c, err, "Failed to offboard employee")
+	}
+	if response.ReassignedSaleIDs == nil {
+		response.ReassignedSaleIDs = []int32{}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetAllOffboardings lists the offboardings, latest first. ?employee_id= returns those of one employee.
+func GetAllOffboardings(c echo.Context) error {
+	var employeeID sql.NullInt32
+	if employeeIDStr := c.QueryParam("employee_id"); employeeIDStr != "" {
+		id, err := strconv.ParseInt(employeeIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
+		}
+		employeeID = sql.NullInt32{Int32: int32(id), Valid: true}
+	}
+
+	offboardings, err := queries.ListOffboardings(c.Request().Context(), employeeID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get offboardings"})
+	}
+	if offboardings == nil {
+		offboardings = []internals.Offboarding{}
+	}
+	return c.JSON(200, offboardings)
+}
@@ -0,0 +1,255 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errManagerCycle = errors.New("An employee cannot report to themselves or to someone reporting to them")
+
+// orgScope narrows statistics and reports to a department with the teams below it,
+// or to a manager with everybody reporting to them directly or indirectly
+type orgScope struct {
+	DepartmentID sql.NullInt32
+	ManagerID    sql.NullInt32
+	Label        string // e.g. "Department Sales" or "Team of Jan Kowalski", empty for the whole company
+}
+
+// orgNode is an employee with the people reporting to them, as returned by /org-chart
+type orgNode struct {
+	ID           int32      `json:"id"`
+	Name         string     `json:"name"`
+	Surname      string     `json:"surname"`
+	Email        string     `json:"email"`
+	DepartmentID *int32     `json:"department_id"`
+	Reports      []*orgNode `json:"reports"`
+}
+
+// Helper function to read ?department_id= and ?manager_id= of statistics and reports.
+// Both may be given, the scope is then the part of the manager's subtree in the department.
+func parseOrgScope(c echo.Context) (orgScope, error) {
+	ctx := c.Request().Context()
+
+	var scope orgScope
+	if departmentIDStr := c.QueryParam("department_id"); departmentIDStr != "" {
+		departmentID, err := strconv.ParseInt(departmentIDStr, 10, 32)
+		if err != nil {
+			return orgScope{}, errors.New("Invalid department_id format")
+		}
+		department, err := queries.GetDepartment(ctx, int32(departmentID))
+		if err != nil {
+			return orgScope{}, errors.New("Department not found")
+		}
+		scope.DepartmentID = sql.NullInt32{Int32: department.ID, Valid: true}
+		scope.Label = "Department " + department.Name
+	}
+	if managerIDStr := c.QueryParam("manager_id"); managerIDStr != "" {
+		managerID, err := strconv.ParseInt(managerIDStr, 10, 32)
+		if err != nil {
+			return orgScope{}, errors.New("Invalid manager_id format")
+		}
+		manager, err := queries.GetEmployee(ctx, int32(managerID))
+		if err != nil {
+			return orgScope{}, errors.New("Manager not found")
+		}
+		scope.ManagerID = sql.NullInt32{Int32: manager.ID, Valid: true}
+		team := fmt.Sprintf("Team of %s %s", manager.Name, manager.Surname)
+		if scope.Label != "" {
+			scope.Label += ", " + team
+		} else {
+			scope.Label = team
+		}
+	}
+	return scope, nil
+}
+
+// GetOrgChart answers /org-chart with the employees nested under their managers.
+// ?manager_id= returns only that manager's subtree.
+func GetOrgChart(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var rootID int32
+	if managerIDStr := c.QueryParam("manager_id"); managerIDStr != "" {
+		managerID, err := strconv.ParseInt(managerIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid manager_id format"})
+		}
+		rootID = int32(managerID)
+	}
+
+	employees, err := queries.GetEmployees(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get employees"})
+	}
+
+	nodes := make(map[int32]*orgNode, len(employees))
+	for _, employee := range employees {
+		node := &orgNode{
+			ID:      employee.ID,
+			Name:    employee.Name,
+			Surname: employee.Surname,
+			Email:   employee.Email,
+			Reports: []*orgNode{},
+		}
+		if employee.DepartmentID.Valid {
+			node.DepartmentID = &employee.DepartmentID.Int32
+		}
+		nodes[employee.ID] = node
+	}
+	roots := []*orgNode{}
+	for _, employee := range employees {
+		node := nodes[employee.ID]
+		if manager, ok := nodes[employee.ManagerID.Int32]; ok && employee.ManagerID.Valid {
+			manager.Reports = append(manager.Reports, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	if rootID != 0 {
+		root, ok := nodes[rootID]
+		if !ok {
+			return c.JSON(404, map[string]string{"error": "Manager not found"})
+		}
+		return c.JSON(200, root)
+	}
+	return c.JSON(200, roots)
+}
+
+// Helper function to read an ID given as JSON {"<name>": 1} or as ?<name>=1, 0 removes it
+func parseOrgID(c echo.Context, name string) (sql.NullInt32, error) {
+	var value int64
+	if valueStr := c.QueryParam(name); valueStr != "" {
+		var err error
+		if value, err = strconv.ParseInt(valueStr, 10, 32); err != nil {
+			return sql.NullInt32{}, fmt.Errorf("Invalid %s format", name)
+		}
+	} else {
+		body := map[string]*int32{}
+		if err := c.Bind(&body); err != nil || body[name] == nil {
+			return sql.NullInt32{}, fmt.Errorf("%s is required", name)
+		}
+		value = int64(*body[name])
+	}
+	return sql.NullInt32{Int32: int32(value), Valid: value != 0}, nil
+}
+
+// SetEmployeeDepartment moves an employee to a department or team, department_id 0 removes it
+func SetEmployeeDepartment(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+	departmentID, err := parseOrgID(c, "department_id")
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	if departmentID.Valid {
+		if _, err := queries.GetDepartment(ctx, departmentID.Int32); err != nil {
+			return c.JSON(400, map[string]string{"error": "Department not found"})
+		}
+	}
+
+	var employee internals.Employee
+	err = withTx(ctx, func(q *internals.Queries) error {
+		current, err := q.GetEmployeeForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		employee, err = q.SetEmployeeDepartment(ctx, internals.SetEmployeeDepartmentParams{
+			ID:           current.ID,
+			DepartmentID: departmentID,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{
+			Entity: auditEmployee, EntityID: employee.ID, Action: "update",
+			Before: current, After: employee,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update employee"})
+	}
+	return c.JSON(http.StatusOK, employee)
+}
+
+// SetEmployeeManager sets who an employee reports to, manager_id 0 removes it.
+// The manager must not report to the employee, directly or indirectly.
+func SetEmployeeManager(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+	managerID, err := parseOrgID(c, "manager_id")
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	if managerID.Valid {
+		if _, err := queries.GetEmployee(ctx, managerID.Int32); err != nil {
+			return c.JSON(400, map[string]string{"error": "Manager not found"})
+		}
+	}
+
+	var employee internals.Employee
+	err = withTx(ctx, func(q *internals.Queries) error {
+		// the cycle check and the update run under the lock, so that concurrent changes
+		// such as A reporting to B and B reporting to A cannot both pass the check
+		if err := q.LockManagerChanges(ctx); err != nil {
+			return err
+		}
+		current, err := q.GetEmployeeForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if managerID.Valid {
+			below, err := q.IsInManagerSubtree(ctx, internals.IsInManagerSubtreeParams{
+				ManagerID:  current.ID,
+				EmployeeID: managerID.Int32,
+			})
+			if err != nil {
+				return err
+			}
+			if below {
+				return errManagerCycle
+			}
+		}
+
+		employee, err = q.SetEmployeeManager(ctx, internals.SetEmployeeManagerParams{
+			ID:        current.ID,
+			ManagerID: managerID,
+		})
+		if err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{
+			Entity: auditEmployee, EntityID: employee.ID, Action: "update",
+			Before: current, After: employee,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if errors.Is(err, errManagerCycle) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update employee"})
+	}
+	return c.JSON(http.StatusOK, employee)
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// pageMeta describes one page of a keyset-paginated list
+type pageMeta struct {
+	Total      int64  `json:"total"` // rows matching the filters, on all pages
+	Limit      int32  `json:"limit"`
+	Sort       string `json:"sort"`
+	NextCursor string `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+type page[T any] struct {
+	Data []T      `json:"data"`
+	Meta pageMeta `json:"meta"`
+}
+
+// pageCursor points at the last row of a page: its sort key value and its ID as a tie breaker.
+// It is passed to clients as an opaque base64 string.
+type pageCursor struct {
+	Sort  string `json:"s"`
+	ID    int32  `json:"id"`
+	Value string `json:"v,omitempty"`
+}
+
+func (cursor pageCursor) encode() string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Helper function to read ?limit, it defaults to 50 and cannot exceed 200
+func parsePageLimit(c echo.Context) (int32, error) {
+	limitStr := c.QueryParam("limit")
+	if limitStr == "" {
+		return defaultPageLimit, nil
+	}
+	limit, err := strconv.ParseInt(limitStr, 10, 32)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, errors.New("Invalid limit. Use a number between 1 and 200")
+	}
+	return int32(limit), nil
+}
+
+// Helper function to read ?sort, allowed holds the accepted values, "-" prefix means descending
+func parsePageSort(c echo.Context, allowed []string, fallback string) (string, error) {
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		return fallback, nil
+	}
+	for _, value := range allowed {
+		if sort == value {
+			return sort, nil
+		}
+	}
+	return "", errors.New("Invalid sort. Use one of: " + strings.Join(allowed, ", "))
+}
+
+// Helper function to read ?cursor, a cursor is only valid for the sort it was created with
+func parsePageCursor(c echo.Context, sort string) (*pageCursor, error) {
+	cursorStr := c.QueryParam("cursor")
+	if cursorStr == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == 0 {
+		return nil, errors.New("Invalid cursor")
+	}
+	if cursor.Sort != sort {
+		return nil, errors.New("Cursor does not match sort, start again without a cursor")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,284 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// SKU validation regex, SKUs are stored upper-case
+var skuRegex = regexp.MustCompile(`^[A-Z0-9][A-Z0-9._-]{0,63}$`)
+
+type productRequest struct {
+	Sku      string        `json:"sku"`
+	Name     string        `json:"name"`
+	Category string        `json:"category"`
+	Price    money.Amount  `json:"price"`
+	Currency string        `json:"currency"`
+	Active   *bool         `json:"active"`
+	TaxRate  *money.Amount `json:"tax_rate"` // overrides the rate of the category
+}
+
+// Helper function to look up the active catalog product a sale refers to, by ID or else by SKU
+func getSaleProduct(ctx context.Context, id int32, sku string) (internals.Product, error) {
+	var product internals.Product
+	var err error
+	if id != 0 {
+		product, err = queries.GetProduct(ctx, id)
+	} else {
+		product, err = queries.GetProductBySku(ctx, strings.ToUpper(strings.TrimSpace(sku)))
+	}
+	if err != nil {
+		return internals.Product{}, errors.New("Product not found")
+	}
+	if !product.Active {
+		return internals.Product{}, fmt.Errorf("Product %s is not active", product.Sku)
+	}
+	return product, nil
+}
+
+// Helper function to validate a product and normalize its SKU, category and currency
+func validateProduct(ctx context.Context, product *internals.Product) error {
+	product.Sku = strings.ToUpper(strings.TrimSpace(product.Sku))
+	if !skuRegex.MatchString(product.Sku) {
+		return errors.New("SKU must be 1-64 letters, digits, dots, dashes or underscores")
+	}
+	product.Name = strings.TrimSpace(product.Name)
+	if product.Name == "" || len(product.Name) > 255 {
+		return errors.New("Product name is required and must be at most 255 characters long")
+	}
+	if product.Price <= 0 {
+		return errors.New("Price must be greater than 0")
+	}
+
+	category, err := getSaleCategory(ctx, product.Category)
+	if err != nil {
+		return err
+	}
+	product.Category = category.Name
+
+	currency, err := getSaleCurrency(ctx, strings.ToUpper(product.Currency))
+	if err != nil {
+		return err
+	}
+	product.Currency = currency.Code
+	if !product.Price.HasDecimals(int(currency.MinorUnits)) {
+		return fmt.Errorf("Price in %s can have at most %d decimal places", currency.Code, currency.MinorUnits)
+	}
+	if product.TaxRate.Valid {
+		if _, err := parseTaxRate(product.TaxRate.String); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAllProducts lists the catalog, optionally filtered with ?active=true|false and ?category=
+func GetAllProducts(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params internals.GetProductsParams
+	if activeStr := c.QueryParam("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid active format"})
+		}
+		params.Active = sql.NullBool{Bool: active, Valid: true}
+	}
+	if category := c.QueryParam("category"); category != "" {
+		if managed, err := queries.GetCategoryByName(ctx, category); err == nil {
+			category = managed.Name
+		}
+		params.Category = sql.NullString{String: category, Valid: true}
+	}
+
+	products, err := queries.GetProducts(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get products"})
+	}
+	if products == nil {
+		products = []internals.Product{}
+	}
+	return c.JSON(200, products)
+}
+
+// GetProduct finds a product by ?id= or ?sku=
+func GetProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if sku := c.QueryParam("sku"); sku != "" {
+		product, err := queries.GetProductBySku(ctx, strings.ToUpper(strings.TrimSpace(sku)))
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "Product not found"})
+		}
+		return c.JSON(http.StatusOK, product)
+	}
+
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Product ID or SKU is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	product, err := queries.GetProduct(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Product not found"})
+	}
+	return c.JSON(http.StatusOK, product)
+}
+
+func CreateProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req productRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid product data"})
+	}
+
+	product := internals.Product{
+		Sku:      req.Sku,
+		Name:     req.Name,
+		Category: req.Category,
+		Price:    req.Price,
+		Currency: req.Currency,
+		Active:   true,
+	}
+	if product.Currency == "" {
+		product.Currency = "PLN"
+	}
+	if req.Active != nil {
+		product.Active = *req.Active
+	}
+	if req.TaxRate != nil {
+		product.TaxRate = sql.NullString{String: req.TaxRate.String(), Valid: true}
+	}
+	if err := validateProduct(ctx, &product); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	created, err := queries.CreateProduct(ctx, internals.CreateProductParams{
+		Sku:      product.Sku,
+		Name:     product.Name,
+		Category: product.Category,
+		Price:    product.Price,
+		Currency: product.Currency,
+		Active:   product.Active,
+		TaxRate:  product.TaxRate,
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Product with this SKU already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create product"})
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// UpdateProduct changes a catalog product, sales already made keep their own name and price
+func UpdateProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Product ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	product, err := queries.GetProduct(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Product not found"})
+	}
+
+	var req productRequest
+	err = c.Bind(&req)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return c.JSON(400, map[string]string{"error": "Invalid price or tax_rate format. Use at most 2 decimal places"})
+	}
+	if err == nil {
+		if req.Sku != "" {
+			product.Sku = req.Sku
+		}
+		if req.Name != "" {
+			product.Name = req.Name
+		}
+		if req.Category != "" {
+			product.Category = req.Category
+		}
+		if req.Price != 0 {
+			product.Price = req.Price
+		}
+		if req.Currency != "" {
+			product.Currency = req.Currency
+		}
+		if req.Active != nil {
+			product.Active = *req.Active
+		}
+		if req.TaxRate != nil {
+			product.TaxRate = sql.NullString{String: req.TaxRate.String(), Valid: true}
+		}
+	}
+
+	if activeStr := c.QueryParam("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid active format"})
+		}
+		product.Active = active
+	}
+
+	if err := validateProduct(ctx, &product); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	updated, err := queries.UpdateProduct(ctx, internals.UpdateProductParams{
+		ID:       product.ID,
+		Sku:      product.Sku,
+		Name:     product.Name,
+		Category: product.Category,
+		Price:    product.Price,
+		Currency: product.Currency,
+		Active:   product.Active,
+		TaxRate:  product.TaxRate,
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Product with this SKU already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update product"})
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeleteProduct removes a product from the catalog, its sales stay with their product name
+func DeleteProduct(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Product ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetProduct(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Product not found"})
+	}
+	if err := queries.DeleteProduct(ctx, int32(id)); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete product"})
+	}
+	return c.JSON(200, map[string]string{"message": "Product deleted successfully"})
+}
@@ -0,0 +1,295 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Promotion code validation regex, codes are stored upper-case
+var promoCodeRegex = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{0,49}$`)
+
+type promotionRequest struct {
+	Code            string        `json:"code"`
+	Description     *string       `json:"description"`
+	DiscountPercent *money.Amount `json:"discount_percent"`
+	DiscountAmount  *money.Amount `json:"discount_amount"`
+	Currency        string        `json:"currency"`   // currency of discount_amount
+	ValidFrom       *string       `json:"valid_from"` // a day, "" removes the limit
+	ValidTo         *string       `json:"valid_to"`
+	Active          *bool         `json:"active"`
+}
+
+// Helper function to apply a request to a promotion, fields left out keep their value
+func (req promotionRequest) apply(promotion *internals.Promotion) error {
+	if req.DiscountPercent != nil && req.DiscountAmount != nil {
+		return errors.New("Give either discount_percent or discount_amount")
+	}
+	if req.Code != "" {
+		promotion.Code = req.Code
+	}
+	if req.Description != nil {
+		promotion.Description = sql.NullString{String: *req.Description, Valid: *req.Description != ""}
+	}
+	if req.DiscountPercent != nil {
+		promotion.DiscountPercent = req.DiscountPercent.String()
+		promotion.DiscountAmount = 0
+	}
+	if req.DiscountAmount != nil {
+		promotion.DiscountAmount = *req.DiscountAmount
+		promotion.DiscountPercent = money.Amount(0).String()
+	}
+	if req.Currency != "" {
+		promotion.Currency = sql.NullString{String: req.Currency, Valid: true}
+	}
+	if req.ValidFrom != nil {
+		day, err := parsePromotionDay(*req.ValidFrom)
+		if err != nil {
+			return errors.New("Invalid valid_from format")
+		}
+		promotion.ValidFrom = day
+	}
+	if req.ValidTo != nil {
+		day, err := parsePromotionDay(*req.ValidTo)
+		if err != nil {
+			return errors.New("Invalid valid_to format")
+		}
+		promotion.ValidTo = day
+	}
+	if req.Active != nil {
+		promotion.Active = *req.Active
+	}
+	return nil
+}
+
+// Helper function to read a day of a validity window, any time of the day is dropped
+func parsePromotionDay(s string) (sql.NullTime, error) {
+	if s == "" {
+		return sql.NullTime{}, nil
+	}
+	t, err := parseDate(s)
+	if err != nil {
+		return sql.NullTime{}, err
+	}
+	return sql.NullTime{Time: time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), Valid: true}, nil
+}
+
+// Helper function to validate a promotion and normalize its code and currency
+func validatePromotion(ctx context.Context, promotion *internals.Promotion) error {
+	promotion.Code = strings.ToUpper(strings.TrimSpace(promotion.Code))
+	if !promoCodeRegex.MatchString(promotion.Code) {
+		return errors.New("Code must be 1-50 letters, digits, dashes or underscores")
+	}
+
+	percent, err := money.Parse(promotion.DiscountPercent)
+	if err != nil {
+		return err
+	}
+	if err := validateDiscountPercent(percent); err != nil {
+		return err
+	}
+	if promotion.DiscountAmount < 0 {
+		return errors.New("Discount amount cannot be negative")
+	}
+	if (percent > 0) == (promotion.DiscountAmount > 0) {
+		return errors.New("Promotion needs either discount_percent or discount_amount greater than 0")
+	}
+
+	// Only fixed amounts have a currency
+	if promotion.DiscountAmount == 0 {
+		promotion.Currency = sql.NullString{}
+	} else {
+		if !promotion.Currency.Valid {
+			promotion.Currency = sql.NullString{String: "PLN", Valid: true}
+		}
+		currency, err := getSaleCurrency(ctx, strings.ToUpper(promotion.Currency.String))
+		if err != nil {
+			return err
+		}
+		promotion.Currency.String = currency.Code
+		if !promotion.DiscountAmount.HasDecimals(int(currency.MinorUnits)) {
+			return fmt.Errorf("Discount in %s can have at most %d decimal places", currency.Code, currency.MinorUnits)
+		}
+	}
+
+	if promotion.ValidFrom.Valid && promotion.ValidTo.Valid && promotion.ValidTo.Time.Before(promotion.ValidFrom.Time) {
+		return errors.New("valid_to cannot be before valid_from")
+	}
+	return nil
+}
+
+// GetAllPromotions lists promotion codes, optionally filtered with ?active=true|false
+func GetAllPromotions(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var active sql.NullBool
+	if activeStr := c.QueryParam("active"); activeStr != "" {
+		value, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid active format"})
+		}
+		active = sql.NullBool{Bool: value, Valid: true}
+	}
+
+	promotions, err := queries.GetPromotions(ctx, active)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get promotions"})
+	}
+	if promotions == nil {
+		promotions = []internals.Promotion{}
+	}
+	return c.JSON(200, promotions)
+}
+
+// GetPromotion finds a promotion by ?id= or ?code=
+func GetPromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if code := c.QueryParam("code"); code != "" {
+		promotion, err := queries.GetPromotionByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+		if err != nil {
+			return c.JSON(404, map[string]string{"error": "Promotion not found"})
+		}
+		return c.JSON(http.StatusOK, promotion)
+	}
+
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Promotion ID or code is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	promotion, err := queries.GetPromotion(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Promotion not found"})
+	}
+	return c.JSON(http.StatusOK, promotion)
+}
+
+func CreatePromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req promotionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid promotion data"})
+	}
+
+	promotion := internals.Promotion{DiscountPercent: money.Amount(0).String(), Active: true}
+	if err := req.apply(&promotion); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err := validatePromotion(ctx, &promotion); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	created, err := queries.CreatePromotion(ctx, internals.CreatePromotionParams{
+		Code:            promotion.Code,
+		Description:     promotion.Description,
+		DiscountPercent: promotion.DiscountPercent,
+		DiscountAmount:  promotion.DiscountAmount,
+		Currency:        promotion.Currency,
+		ValidFrom:       promotion.ValidFrom,
+		ValidTo:         promotion.ValidTo,
+		Active:          promotion.Active,
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Promotion with this code already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create promotion"})
+	}
+	return c.JSON(http.StatusCreated, created)
+}
+
+// UpdatePromotion changes a promotion, sales that already used it keep their discount
+func UpdatePromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Promotion ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	promotion, err := queries.GetPromotion(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Promotion not found"})
+	}
+
+	var req promotionRequest
+	err = c.Bind(&req)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return c.JSON(400, map[string]string{"error": "Invalid discount format. Use at most 2 decimal places"})
+	}
+	if err == nil {
+		if err := req.apply(&promotion); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		}
+	}
+
+	if activeStr := c.QueryParam("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid active format"})
+		}
+		promotion.Active = active
+	}
+
+	if err := validatePromotion(ctx, &promotion); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	updated, err := queries.UpdatePromotion(ctx, internals.UpdatePromotionParams{
+		ID:              promotion.ID,
+		Code:            promotion.Code,
+		Description:     promotion.Description,
+		DiscountPercent: promotion.DiscountPercent,
+		DiscountAmount:  promotion.DiscountAmount,
+		Currency:        promotion.Currency,
+		ValidFrom:       promotion.ValidFrom,
+		ValidTo:         promotion.ValidTo,
+		Active:          promotion.Active,
+	})
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Promotion with this code already exists"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to update promotion"})
+	}
+	return c.JSON(http.StatusOK, updated)
+}
+
+// DeletePromotion removes a promotion code, its sales keep their discount
+func DeletePromotion(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Promotion ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetPromotion(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Promotion not found"})
+	}
+	if err := queries.DeletePromotion(ctx, int32(id)); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete promotion"})
+	}
+	return c.JSON(200, map[string]string{"message": "Promotion deleted successfully"})
+}
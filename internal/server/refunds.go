@@ -0,0 +1,260 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Helper function to sum the refunds of a sale, it locks nothing by itself
+func refundedAmount(ctx context.Context, q *internals.Queries, saleID int32) (money.Amount, error) {
+	total, err := q.GetRefundedAmount(ctx, saleID)
+	if err != nil {
+		return 0, err
+	}
+	return money.Parse(total)
+}
+
+// Helper function to check inside the transaction of a sale update that its refunds still fit.
+// current is the sale locked with GetSaleForUpdate, so that no refund can be added until the transaction ends.
+func checkRefundedSale(ctx context.Context, q *internals.Queries, current internals.Sale, update internals.UpdateSaleParams) error {
+	refunds, err := q.ListRefunds(ctx, internals.ListRefundsParams{SaleID: sql.NullInt32{Int32: update.ID, Valid: true}})
+	if err != nil || len(refunds) == 0 {
+		return err
+	}
+	// Refunds are listed by refund_date, the first one is the earliest
+	if update.SaleDate.After(refunds[0].RefundDate) {
+		return rejection(fmt.Sprintf("Sale has refunds from %s, its sale date cannot be later",
+			refunds[0].RefundDate.Format("2006-01-02")))
+	}
+	refunded, err := refundedAmount(ctx, q, update.ID)
+	if err != nil {
+		return err
+	}
+	if update.Currency != current.Currency {
+		return rejection("Sale has refunds, its currency cannot change")
+	}
+	if update.Price < refunded {
+		return rejection(fmt.Sprintf("Sale has refunds of %s, its price cannot be lower", refunded))
+	}
+	return nil
+}
+
+// GetAllRefunds lists refunds, optionally filtered with ?sale_id=, ?employee_id= and the days ?from= and ?to= (both inclusive)
+func GetAllRefunds(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params internals.ListRefundsParams
+	if saleIDStr := c.QueryParam("sale_id"); saleIDStr != "" {
+		saleID, err := strconv.ParseInt(saleIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid sale_id format"})
+		}
+		params.SaleID = sql.NullInt32{Int32: int32(saleID), Valid: true}
+	}
+	if employeeIDStr := c.QueryParam("employee_id"); employeeIDStr != "" {
+		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
+		}
+		params.EmployeeID = sql.NullInt32{Int32: int32(employeeID), Valid: true}
+	}
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid from format. Use YYYY-MM-DD"})
+		}
+		params.FromDate = sql.NullTime{Time: from, Valid: true}
+	}
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid to format. Use YYYY-MM-DD"})
+		}
+		params.ToDate = sql.NullTime{Time: to.AddDate(0, 0, 1), Valid: true}
+	}
+
+	refunds, err := queries.ListRefunds(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get refunds"})
+	}
+	if refunds == nil {
+		refunds = []internals.Refund{}
+	}
+	return c.JSON(200, refunds)
+}
+
+func GetRefund(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Refund ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	refund, err := queries.GetRefund(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Refund not found"})
+	}
+	return c.JSON(http.StatusOK, refund)
+}
+
+// CreateRefund refunds a sale in full or in part, from JSON or ?amount=&reason=&refund_date=.
+// Without an amount everything not refunded yet is refunded. The refund date defaults to now.
+func CreateRefund(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Sale ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+
+	type CreateRefundRequest struct {
+		Amount     money.Amount `json:"amount"`
+		Reason     string       `json:"reason"`
+		RefundDate time.Time    `json:"refund_date"`
+	}
+
+	var req CreateRefundRequest
+	err = c.Bind(&req)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return c.JSON(400, map[string]string{"error": "Invalid amount format. Use at most 2 decimal places"})
+	}
+	if amountStr := c.QueryParam("amount"); amountStr != "" {
+		amount, err := money.Parse(amountStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid amount format. Use at most 2 decimal places"})
+		}
+		req.Amount = amount
+	}
+	if reason := c.QueryParam("reason"); reason != "" {
+		req.Reason = reason
+	}
+	if refundDateStr := c.QueryParam("refund_date"); refundDateStr != "" {
+		refundDate, err := parseDate(refundDateStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid refund_date format. Supported formats: 2025-07-10T10:23:54+02:00, 2025-07-10, 10/07/2025, 10-07-2025, 10.07.2025"})
+		}
+		req.RefundDate = refundDate
+	}
+
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return c.JSON(400, map[string]string{"error": "Reason is required"})
+	}
+	if req.Amount < 0 {
+		return c.JSON(400, map[string]string{"error": "Amount must be greater than 0"})
+	}
+	if req.RefundDate.IsZero() {
+		req.RefundDate = time.Now()
+	}
+	if req.RefundDate.After(time.Now()) {
+		return c.JSON(400, map[string]string{"error": "Refund date cannot be in the future"})
+	}
+	if _, err := queries.GetSale(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Sale not found"})
+	}
+
+	var refund internals.Refund
+	err = withTx(ctx, func(q *internals.Queries) error {
+		// The lock keeps concurrent refunds of the same sale from exceeding its price together
+		sale, err := q.GetSaleForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if req.RefundDate.Before(sale.SaleDate) {
+			return rejection("Refund date cannot be before the sale date")
+		}
+
+		refunded, err := refundedAmount(ctx, q, sale.ID)
+		if err != nil {
+			return err
+		}
+		left := sale.Price - refunded
+		if left <= 0 {
+			return rejection("Sale is already fully refunded")
+		}
+		if req.Amount == 0 {
+			req.Amount = left
+		}
+		if req.Amount > left {
+			return rejection(fmt.Sprintf("Refund cannot exceed the %s %s left to refund", left, sale.Currency))
+		}
+		currency, err := q.GetCurrency(ctx, sale.Currency)
+		if err != nil {
+			return err
+		}
+		if !req.Amount.HasDecimals(int(currency.MinorUnits)) {
+			return rejection(fmt.Sprintf("Amount in %s can have at most %d decimal places", currency.Code, currency.MinorUnits))
+		}
+
+		refund, err = q.CreateRefund(ctx, internals.CreateRefundParams{
+			SaleID:     sale.ID,
+			Amount:     req.Amount,
+			Reason:     req.Reason,
+			RefundDate: req.RefundDate,
+		})
+		return err
+	})
+	if err != nil {
+		return rejectionError(c, err, "Failed to create refund")
+	}
+	return c.JSON(http.StatusCreated, refund)
+}
+
+// DeleteRefund removes a refund entered by mistake
+func DeleteRefund(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Refund ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetRefund(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Refund not found"})
+	}
+	if err := queries.DeleteRefund(ctx, int32(id)); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete refund"})
+	}
+	return c.JSON(200, map[string]string{"message": "Refund deleted successfully"})
+}
+
+// Helper function to take the refunds of a period off the per-currency totals.
+// A currency can have refunds of sales from earlier periods without any sales of its own.
+func addRefunds(totals []currencyTotal, refunds []internals.GetRefundsByEmployeeAndDateRangeRow) []currencyTotal {
+	byCurrency := map[string]int{}
+	for i, total := range totals {
+		byCurrency[total.Currency] = i
+	}
+	for _, refund := range refunds {
+		i, ok := byCurrency[refund.Currency]
+		if !ok {
+			i = len(totals)
+			byCurrency[refund.Currency] = i
+			totals = append(totals, currencyTotal{Currency: refund.Currency})
+		}
+		totals[i].TotalRefunded += refund.Amount
+		totals[i].NetRevenue = totals[i].TotalRevenue - totals[i].TotalRefunded
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	return totals
+}
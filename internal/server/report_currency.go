@@ -0,0 +1,208 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/exchange"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phpdave11/gofpdf"
+)
+
+// currencyTotal is the number of sales, the revenue and the discounts given in a single currency.
+// Refunds of the period, also of sales made before it, are taken off the revenue in NetRevenue.
+type currencyTotal struct {
+	Currency      string       `json:"currency"`
+	TotalSales    int64        `json:"total_sales"`
+	TotalRevenue  money.Amount `json:"total_revenue"`
+	TotalDiscount money.Amount `json:"total_discount"`
+	TotalRefunded money.Amount `json:"total_refunded"`
+	NetRevenue    money.Amount `json:"net_revenue"`
+}
+
+// appliedRate is the rate used to convert one currency of a report
+type appliedRate struct {
+	Currency string    `json:"currency"`
+	Rate     float64   `json:"rate"`
+	RateDate time.Time `json:"rate_date"`
+}
+
+// reportConversion converts per-currency totals into one reporting currency.
+// Rates passed in the query take precedence over the exchange_rates table.
+type reportConversion struct {
+	Currency string             `json:"currency"`
+	RateDate time.Time          `json:"rate_date"`
+	Rates    []appliedRate      `json:"rates"`
+	Total    money.Amount       `json:"total"`
+	given    map[string]float64 // rates passed in the query
+}
+
+// errMissingBaseRates is returned when some sales cannot be converted to the base currency
+var errMissingBaseRates = errors.New("Missing exchange rates")
+
+// Helper function to sum sales per currency, ordered by currency code
+func totalsByCurrency(sales []internals.Sale) []currencyTotal {
+	byCurrency := map[string]*currencyTotal{}
+	for _, sale := range sales {
+		total, ok := byCurrency[sale.Currency]
+		if !ok {
+			total = &currencyTotal{Currency: sale.Currency}
+			byCurrency[sale.Currency] = total
+		}
+		total.TotalSales++
+		total.TotalRevenue += sale.Price
+		total.TotalDiscount += sale.DiscountAmount
+		total.NetRevenue += sale.Price
+	}
+
+	totals := make([]currencyTotal, 0, len(byCurrency))
+	for _, total := range byCurrency {
+		totals = append(totals, *total)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Currency < totals[j].Currency })
+	return totals
+}
+
+// Helper function to read the optional reporting currency from the query,
+// e.g. ?currency=PLN&rate_date=2025-01-31&rates=EUR:4.27,USD:3.98.
+// Rates not given in the query are taken from the exchange_rates table as of rate_date,
+// which defaults to the last day of the period (or today for a period that is not over yet).
+// It returns nil when no reporting currency was requested.
+func parseReportConversion(c echo.Context, period reportPeriod) (*reportConversion, error) {
+	currency := strings.ToUpper(c.QueryParam("currency"))
+	if currency == "" {
+		return nil, nil
+	}
+	if len(currency) != 3 {
+		return nil, errors.New("Invalid currency")
+	}
+
+	conversion := &reportConversion{
+		Currency: currency,
+		RateDate: period.rateDate(),
+		given:    map[string]float64{},
+	}
+
+	if ratesStr := c.QueryParam("rates"); ratesStr != "" {
+		for _, pair := range strings.Split(ratesStr, ",") {
+			code, rateStr, found := strings.Cut(pair, ":")
+			if !found {
+				return nil, errors.New("Invalid rates format. Expected rates=EUR:4.27,USD:3.98")
+			}
+			rate, err := strconv.ParseFloat(rateStr, 64)
+			if err != nil || rate <= 0 {
+				return nil, fmt.Errorf("Invalid exchange rate for %s", code)
+			}
+			conversion.given[strings.ToUpper(strings.TrimSpace(code))] = rate
+		}
+	}
+
+	if rateDateStr := c.QueryParam("rate_date"); rateDateStr != "" {
+		rateDate, err := parseDate(rateDateStr)
+		if err != nil {
+			return nil, errors.New("Invalid rate_date format")
+		}
+		conversion.RateDate = rateDate
+	}
+
+	return conversion, nil
+}
+
+// convert sets the grand total of the given per-currency totals in the reporting currency,
+// each converted subtotal is rounded to the grosz before it is added.
+// It returns an error wrapping exchange.ErrRateNotFound when a rate is unknown.
+func (conversion *reportConversion) convert(ctx context.Context, totals []currencyTotal) error {
+	conversion.Total = 0
+	conversion.Rates = nil
+	for _, total := range totals {
+		if total.Currency == conversion.Currency {
+			conversion.Total += total.NetRevenue
+			continue
+		}
+
+		applied := appliedRate{Currency: total.Currency, RateDate: conversion.RateDate}
+		if rate, ok := conversion.given[total.Currency]; ok {
+			applied.Rate = rate
+		} else {
+			rate, err := rates.Rate(ctx, total.Currency, conversion.Currency, conversion.RateDate)
+			if err != nil {
+				return err
+			}
+			applied.Rate = rate.Rate
+			applied.RateDate = rate.Date
+		}
+		conversion.Rates = append(conversion.Rates, applied)
+		conversion.Total += total.NetRevenue.Mul(applied.Rate)
+	}
+	return nil
+}
+
+// Helper function to check that every sale of the period can be converted to the base currency.
+// The returned error wraps errMissingBaseRates and names the currencies without a rate.
+func checkBaseRates(ctx context.Context, period reportPeriod) error {
+	missing, err := queries.GetCurrenciesWithoutExchangeRate(ctx, internals.GetCurrenciesWithoutExchangeRateParams{
+		FromDate:     period.From,
+		ToDate:       period.To,
+		BaseCurrency: rates.Base(),
+	})
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w to %s for: %s", errMissingBaseRates, rates.Base(), strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Helper function to answer a failed conversion, a missing rate is the client's problem
+func conversionError(c echo.Context, err error) error {
+	if errors.Is(err, exchange.ErrRateNotFound) {
+		return c.JSON(400, map[string]string{"error": "Missing exchange rate: " + err.Error()})
+	}
+	return c.JSON(500, map[string]string{"error": "Failed to get exchange rates"})
+}
+
+// Helper function to print the per-currency totals and the optional converted grand total
+func writeCurrencyTotals(pdf *gofpdf.Fpdf, totals []currencyTotal, conversion *reportConversion, formats currencyFormats) {
+	if len(totals) == 0 {
+		pdf.Cell(0, 10, "Total revenue: "+money.Amount(0).String())
+		pdf.Ln(5)
+	}
+	for _, total := range totals {
+		pdf.Cell(0, 10, fmt.Sprintf("Total revenue (%s): %s - %d sales",
+			total.Currency, formats.format(total.TotalRevenue, total.Currency), total.TotalSales))
+		pdf.Ln(5)
+		if total.TotalDiscount > 0 {
+			pdf.Cell(0, 10, fmt.Sprintf("Discounts (%s): %s",
+				total.Currency, formats.format(total.TotalDiscount, total.Currency)))
+			pdf.Ln(5)
+		}
+		if total.TotalRefunded > 0 {
+			pdf.Cell(0, 10, fmt.Sprintf("Refunds (%s): %s, net revenue: %s", total.Currency,
+				formats.format(total.TotalRefunded, total.Currency), formats.format(total.NetRevenue, total.Currency)))
+			pdf.Ln(5)
+		}
+	}
+
+	if conversion != nil {
+		pdf.SetFont(reportFont, "B", 12)
+		pdf.Cell(0, 10, "Grand total: "+formats.format(conversion.Total, conversion.Currency))
+		pdf.Ln(5)
+		pdf.SetFont(reportFont, "", 9)
+
+		for _, rate := range conversion.Rates {
+			pdf.Cell(0, 10, fmt.Sprintf("Exchange rate: 1 %s = %.4f %s (%s)",
+				rate.Currency, rate.Rate, conversion.Currency, rate.RateDate.Format("2006-01-02")))
+			pdf.Ln(5)
+		}
+		pdf.SetFont(reportFont, "", 12)
+	}
+	pdf.Ln(5)
+}
@@ -0,0 +1,428 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/exchange"
+	"WorkRESTAPI/internal/money"
+	"bytes"
+	"database/sql"
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phpdave11/gofpdf"
+)
+
+// Reports use an embedded UTF-8 TrueType font, since gofpdf's core fonts
+// cannot render Polish characters such as ł, ś, ń or ż.
+const reportFont = "DejaVu"
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var reportFontRegular []byte
+
+//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+var reportFontBold []byte
+
+// reportPeriod describes the time window covered by a report.
+// From is inclusive and To is exclusive.
+type reportPeriod struct {
+	Title    string // e.g. "Monthly report"
+	Label    string // human readable period, e.g. "January 2025"
+	FileName string // period part of the PDF file name, e.g. "2025_1"
+	From     time.Time
+	To       time.Time
+}
+
+// rateDate is the day whose exchange rates convert the period: its last day,
+// or today for a period that is not over yet
+func (period reportPeriod) rateDate() time.Time {
+	rateDate := period.To.AddDate(0, 0, -1)
+	if today := time.Now().UTC().Truncate(24 * time.Hour); rateDate.After(today) {
+		return today
+	}
+	return rateDate
+}
+
+func monthlyPeriod(year, month int) reportPeriod {
+	from := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return reportPeriod{
+		Title:    "Monthly report",
+		Label:    fmt.Sprintf("%s %d", time.Month(month).String(), year),
+		FileName: fmt.Sprintf("%d_%d", year, month),
+		From:     from,
+		To:       from.AddDate(0, 1, 0),
+	}
+}
+
+func quarterlyPeriod(year, quarter int) reportPeriod {
+	startMonth := (quarter-1)*3 + 1
+	from := time.Date(year, time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC)
+	return reportPeriod{
+		Title:    "Quarterly report",
+		Label:    fmt.Sprintf("Q%d %d", quarter, year),
+		FileName: fmt.Sprintf("%d_Q%d", year, quarter),
+		From:     from,
+		To:       from.AddDate(0, 3, 0),
+	}
+}
+
+func yearlyPeriod(year int) reportPeriod {
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return reportPeriod{
+		Title:    "Annual report",
+		Label:    strconv.Itoa(year),
+		FileName: strconv.Itoa(year),
+		From:     from,
+		To:       from.AddDate(1, 0, 0),
+	}
+}
+
+// rangePeriod covers every whole day from "from" to "to", both inclusive.
+func rangePeriod(from, to time.Time) reportPeriod {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return reportPeriod{
+		Title:    "Sales report",
+		Label:    fmt.Sprintf("%s - %s", from.Format("2006-01-02"), to.Format("2006-01-02")),
+		FileName: fmt.Sprintf("%s_%s", from.Format("20060102"), to.Format("20060102")),
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+	}
+}
+
+// Helper function to check that a report year is within the employee's working time,
+// from the year they were hired until the year they left or the current one
+func isValidReportYear(employee internals.Employee, year int) bool {
+	lastYear := time.Now().Year()
+	if employee.TerminationDate.Valid {
+		lastYear = employee.TerminationDate.Time.Year()
+	}
+	return year >= employee.HireDate.Year() && year <= lastYear
+}
+
+// Helper function to build the report period of the given kind ("month", "quarter",
+// "year" or "range") from the request query parameters
+func parseReportPeriod(c echo.Context, kind string) (reportPeriod, error) {
+	if kind == "range" {
+		fromStr, toStr := c.QueryParam("from"), c.QueryParam("to")
+		if fromStr == "" || toStr == "" {
+			return reportPeriod{}, errors.New("from and to are required")
+		}
+		from, err := parseDate(fromStr)
+		if err != nil {
+			return reportPeriod{}, errors.New("Invalid from date format")
+		}
+		to, err := parseDate(toStr)
+		if err != nil {
+			return reportPeriod{}, errors.New("Invalid to date format")
+		}
+		if to.Before(from) {
+			return reportPeriod{}, errors.New("from date must not be after to date")
+		}
+		return rangePeriod(from, to), nil
+	}
+
+	yearStr := c.QueryParam("year")
+	if yearStr == "" || (kind != "year" && c.QueryParam(kind) == "") {
+		if kind == "year" {
+			return reportPeriod{}, errors.New("year is required")
+		}
+		return reportPeriod{}, fmt.Errorf("year and %s are required", kind)
+	}
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		return reportPeriod{}, errors.New("Invalid year")
+	}
+
+	switch kind {
+	case "month":
+		month, err := strconv.Atoi(c.QueryParam("month"))
+		if err != nil || month < 1 || month > 12 {
+			return reportPeriod{}, errors.New("Invalid month (1-12)")
+		}
+		return monthlyPeriod(year, month), nil
+	case "quarter":
+		quarter, err := strconv.Atoi(c.QueryParam("quarter"))
+		if err != nil || quarter < 1 || quarter > 4 {
+			return reportPeriod{}, errors.New("Invalid quarter (1-4)")
+		}
+		return quarterlyPeriod(year, quarter), nil
+	default:
+		return yearlyPeriod(year), nil
+	}
+}
+
+func GenerateEmployeeMonthlyReport(c echo.Context) error {
+	return generateEmployeeReport(c, "month")
+}
+
+func GenerateEmployeeQuarterlyReport(c echo.Context) error {
+	return generateEmployeeReport(c, "quarter")
+}
+
+func GenerateEmployeeYearlyReport(c echo.Context) error {
+	return generateEmployeeReport(c, "year")
+}
+
+func GenerateEmployeeRangeReport(c echo.Context) error {
+	return generateEmployeeReport(c, "range")
+}
+
+func generateEmployeeReport(c echo.Context, kind string) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Employee ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+
+	period, err := parseReportPeriod(c, kind)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	conversion, err := parseReportConversion(c, period)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	employee, err := queries.GetEmployee(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if (kind == "quarter" || kind == "year") && !isValidReportYear(employee, period.From.Year()) {
+		return c.JSON(400, map[string]string{"error": "Invalid year"})
+	}
+
+	sales, err := queries.GetSalesByEmployeeAndDateRange(ctx, internals.GetSalesByEmployeeAndDateRangeParams{
+		EmployeeID: employee.ID,
+		SaleDate:   period.From,
+		SaleDate_2: period.To,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+	}
+	items, err := queries.GetSaleItemsByEmployeeAndDateRange(ctx, internals.GetSaleItemsByEmployeeAndDateRangeParams{
+		EmployeeID: employee.ID,
+		FromDate:   period.From,
+		ToDate:     period.To,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+	}
+	taxes, err := getTaxBreakdown(ctx, period, sql.NullInt32{Int32: employee.ID, Valid: true}, orgScope{})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+	}
+	refunds, err := queries.GetRefundsByEmployeeAndDateRange(ctx, internals.GetRefundsByEmployeeAndDateRangeParams{
+		EmployeeID: employee.ID,
+		FromDate:   period.From,
+		ToDate:     period.To,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get refunds"})
+	}
+
+	totals := addRefunds(totalsByCurrency(sales), refunds)
+	if conversion != nil {
+		if err := conversion.convert(ctx, totals); err != nil {
+			return conversionError(c, err)
+		}
+	}
+
+	// Monthly and quarterly reports show how far the target of the period has been reached
+	var attainment *targetAttainment
+	if kind == "month" || kind == "quarter" {
+		target, err := queries.GetEmployeeSalesTarget(ctx, internals.GetEmployeeSalesTargetParams{
+			EmployeeID:  employee.ID,
+			PeriodType:  kind,
+			PeriodStart: period.From,
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return c.JSON(500, map[string]string{"error": "Failed to get sales target"})
+		}
+		if err == nil {
+			var given map[string]float64
+			if conversion != nil {
+				given = conversion.given
+			}
+			attainment, err = attainTarget(ctx, target, totals, given)
+			if errors.Is(err, exchange.ErrRateNotFound) {
+				// the report is still useful without the attainment, it only shows the target
+				attainment = &targetAttainment{Target: target.Amount, Currency: target.Currency, MissingRate: err.Error()}
+			} else if err != nil {
+				return conversionError(c, err)
+			}
+		}
+	}
+
+	formats, err := loadCurrencyFormats(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get currencies"})
+	}
+
+	// GENERATE PDF
+	pdf := generateReportPDF(employee, sales, itemsBySale(items), taxes, refunds, period, totals, conversion, attainment, formats)
+
+	// RETURNS PDF
+	return streamPDF(c, fmt.Sprintf("raport_%s_%s_%s.pdf", employee.Name, employee.Surname, period.FileName), pdf)
+}
+
+// Helper function to send a generated PDF as a file download.
+// Non-ASCII file names (e.g. Polish surnames) are passed in the RFC 5987 filename* parameter.
+func streamPDF(c echo.Context, fileName string, pdf *bytes.Buffer) error {
+	asciiName := strings.Map(func(r rune) rune {
+		if r > 127 || r == '"' {
+			return '_'
+		}
+		return r
+	}, fileName)
+
+	c.Response().Header().Set("Content-Type", "application/pdf")
+	c.Response().Header().Set("Content-Disposition",
+		fmt.Sprintf("attachment; filename=\"%s\"; filename*=UTF-8''%s", asciiName, url.PathEscape(fileName)))
+	return c.Stream(200, "application/pdf", pdf)
+}
+
+// Helper function to start a new report document with its title and period
+func newReportPDF(title string, period reportPeriod) *gofpdf.Fpdf {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(reportFont, "", reportFontRegular)
+	pdf.AddUTF8FontFromBytes(reportFont, "B", reportFontBold)
+	pdf.AddPage()
+
+	// header
+	pdf.SetFont(reportFont, "B", 16)
+	pdf.Cell(0, 10, title)
+	pdf.Ln(10)
+
+	pdf.SetFont(reportFont, "", 12)
+	pdf.Cell(0, 10, fmt.Sprintf("Period: %s", period.Label))
+	pdf.Ln(10)
+
+	return pdf
+}
+
+// Helper function to render a finished report document
+func outputPDF(pdf *gofpdf.Fpdf) *bytes.Buffer {
+	var buf bytes.Buffer
+	pdf.Output(&buf)
+	return &buf
+}
+
+// Helper function to group the lines of several sales by sale ID
+func itemsBySale(items []internals.SaleItem) map[int32][]internals.SaleItem {
+	bySale := map[int32][]internals.SaleItem{}
+	for _, item := range items {
+		bySale[item.SaleID] = append(bySale[item.SaleID], item)
+	}
+	return bySale
+}
+
+// generateReportPDF lists every line of every sale after the tax breakdown of the period,
+// followed by the refunds made in the period. A sale without stored lines is shown as a single line.
+// attainment is nil when the period has no sales target.
+func generateReportPDF(employee internals.Employee, sales []internals.Sale, items map[int32][]internals.SaleItem, taxes []taxBreakdown, refunds []internals.GetRefundsByEmployeeAndDateRangeRow, period reportPeriod, totals []currencyTotal, conversion *reportConversion, attainment *targetAttainment, formats currencyFormats) *bytes.Buffer {
+	pdf := newReportPDF(fmt.Sprintf("%s - %s %s", period.Title, employee.Name, employee.Surname), period)
+
+	// Statistics
+	pdf.Cell(0, 10, fmt.Sprintf("Number of sales: %d", len(sales)))
+	pdf.Ln(5)
+	writeCurrencyTotals(pdf, totals, conversion, formats)
+	writeTargetAttainment(pdf, attainment, formats)
+	writeTaxBreakdown(pdf, taxes, formats)
+
+	// Sales table, prices are gross list prices and the discount of a sale is taken off its total
+	if len(sales) > 0 {
+		pdf.SetFont(reportFont, "B", 10)
+		pdf.Cell(25, 10, "Date")
+		pdf.Cell(50, 10, "Product")
+		pdf.Cell(30, 10, "Category")
+		pdf.Cell(15, 10, "Qty")
+		pdf.Cell(25, 10, "Unit price")
+		pdf.Cell(15, 10, "VAT")
+		pdf.Cell(30, 10, "Total")
+		pdf.Ln(10)
+
+		for _, sale := range sales {
+			lines := items[sale.ID]
+			if len(lines) == 0 {
+				lines = []internals.SaleItem{{
+					ProductName: sale.ProductName,
+					Category:    sale.Category,
+					Quantity:    1,
+					UnitPrice:   sale.Price + sale.DiscountAmount,
+				}}
+			}
+
+			pdf.SetFont(reportFont, "", 9)
+			for i, line := range lines {
+				date := ""
+				if i == 0 {
+					date = sale.SaleDate.Format("2006-01-02")
+				}
+				taxRate := ""
+				if line.TaxRate != "" {
+					taxRate = formatTaxRate(line.TaxRate)
+				}
+				pdf.Cell(25, 8, date)
+				pdf.Cell(50, 8, line.ProductName)
+				pdf.Cell(30, 8, line.Category)
+				pdf.Cell(15, 8, strconv.Itoa(int(line.Quantity)))
+				pdf.Cell(25, 8, formats.format(line.UnitPrice, sale.Currency))
+				pdf.Cell(15, 8, taxRate)
+				pdf.Cell(30, 8, formats.format(line.UnitPrice*money.Amount(line.Quantity), sale.Currency))
+				pdf.Ln(8)
+			}
+
+			if sale.DiscountAmount > 0 {
+				pdf.Cell(120, 8, "")
+				pdf.Cell(40, 8, "Discount")
+				pdf.Cell(30, 8, formats.format(-sale.DiscountAmount, sale.Currency))
+				pdf.Ln(8)
+			}
+
+			// An order with several lines or a discount gets its own total
+			if len(lines) > 1 || sale.DiscountAmount > 0 {
+				pdf.SetFont(reportFont, "B", 9)
+				pdf.Cell(120, 8, "")
+				pdf.Cell(40, 8, "Sale total")
+				pdf.Cell(30, 8, formats.format(sale.Price, sale.Currency))
+				pdf.Ln(8)
+			}
+		}
+		pdf.Ln(5)
+	}
+
+	// Refunds table, a refund can belong to a sale of an earlier period
+	if len(refunds) > 0 {
+		pdf.SetFont(reportFont, "B", 10)
+		pdf.Cell(25, 10, "Refunded")
+		pdf.Cell(20, 10, "Sale")
+		pdf.Cell(55, 10, "Product")
+		pdf.Cell(60, 10, "Reason")
+		pdf.Cell(30, 10, "Amount")
+		pdf.Ln(10)
+
+		pdf.SetFont(reportFont, "", 9)
+		for _, refund := range refunds {
+			pdf.Cell(25, 8, refund.RefundDate.Format("2006-01-02"))
+			pdf.Cell(20, 8, fmt.Sprintf("#%d", refund.SaleID))
+			pdf.Cell(55, 8, refund.ProductName)
+			pdf.Cell(60, 8, refund.Reason)
+			pdf.Cell(30, 8, formats.format(refund.Amount, refund.Currency))
+			pdf.Ln(8)
+		}
+	}
+
+	return outputPDF(pdf)
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"bytes"
+	"compress/zlib"
+	"database/sql"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+var pdfStreamRegex = regexp.MustCompile(`(?s)stream\r?\n(.*?)\r?\nendstream`)
+
+// pdfText returns the uncompressed content of every stream in the document
+func pdfText(t *testing.T, doc []byte) []byte {
+	t.Helper()
+	var text []byte
+	for _, match := range pdfStreamRegex.FindAllSubmatch(doc, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(match[1]))
+		if err != nil {
+			text = append(text, match[1]...)
+			continue
+		}
+		data, _ := io.ReadAll(r)
+		text = append(text, data...)
+	}
+	return text
+}
+
+// utf16BE encodes s the way gofpdf writes text set in a UTF-8 font
+func utf16BE(s string) []byte {
+	var out []byte
+	for _, u := range utf16.Encode([]rune(s)) {
+		out = append(out, byte(u>>8), byte(u))
+	}
+	return out
+}
+
+func TestGenerateReportPDFPolishCharacters(t *testing.T) {
+	employee := internals.Employee{ID: 3, Name: "Piotr", Surname: "Wiśniewski", Email: "piotr.wisniewski@firma.pl"}
+	sales := []internals.Sale{{
+		ID:          1,
+		ProductName: "Słuchawki Sony",
+		Category:    "Elektronika użytkowa",
+		Currency:    "PLN",
+		Price:       79900, // 799.00
+		SaleDate:    time.Date(2025, time.February, 20, 12, 10, 0, 0, time.UTC),
+		EmployeeID:  sql.NullInt32{Int32: 3, Valid: true},
+	}}
+
+	doc := generateReportPDF(employee, sales, nil, nil, nil, monthlyPeriod(2025, 2), totalsByCurrency(sales), nil, nil, nil).Bytes()
+	if !bytes.HasPrefix(doc, []byte("%PDF")) {
+		t.Fatal("report is not a PDF document")
+	}
+	if !bytes.Contains(doc, []byte("/FontFile2")) {
+		t.Error("report does not embed the UTF-8 report font")
+	}
+	if bytes.Contains(doc, []byte("Helvetica")) {
+		t.Error("report still uses a core font")
+	}
+
+	text := pdfText(t, doc)
+	for _, want := range []string{"Wiśniewski", "Słuchawki Sony", "Elektronika użytkowa"} {
+		if !bytes.Contains(text, utf16BE(want)) {
+			t.Errorf("report does not contain %q", want)
+		}
+	}
+}
@@ -2,18 +2,25 @@ package server
 
 import (
 	internals "WorkRESTAPI/internal"
-	"bytes"
+	"WorkRESTAPI/internal/exchange"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
-	"github.com/phpdave11/gofpdf"
 )
 
+var db *sql.DB
 var queries *internals.Queries
+var rates *exchange.Service
 
 // Email validation regex
 var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
@@ -36,8 +43,43 @@ func isEmailExists(ctx echo.Context, email string, excludeID ...int32) (bool, er
 	return true, nil
 }
 
-func RegisterRoutes(e *echo.Echo, q *internals.Queries) {
+// Helper function to run fn in a transaction, which is rolled back when fn returns an error
+func withTx(ctx context.Context, fn func(q *internals.Queries) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rejection is why a change is refused inside its transaction, the client has to correct it
+type rejection string
+
+func (r rejection) Error() string {
+	return string(r)
+}
+
+// Helper function to answer the error of a transaction, a rejection is the client's problem
+func rejectionError(c echo.Context, err error, message string) error {
+	var r rejection
+	if errors.As(err, &r) {
+		return c.JSON(400, map[string]string{"error": r.Error()})
+	}
+	return c.JSON(500, map[string]string{"error": message})
+}
+
+func RegisterRoutes(e *echo.Echo, d *sql.DB, q *internals.Queries, r *exchange.Service) {
+	db = d
 	queries = q
+	rates = r
+
+	// X-Actor and X-Request-ID go to the audit log, they are checked before any change is made
+	e.Use(checkAuditHeaders)
 
 	//routes for employee
 	e.GET("/employee", GetEmployee)
@@ -45,6 +87,21 @@ func RegisterRoutes(e *echo.Echo, q *internals.Queries) {
 	e.POST("/employee", CreateEmployee)
 	e.PUT("/employee/:id", UpdateEmployee)
 	e.DELETE("/employee/:id", DeleteEmployee)
+	e.POST("/employee/:id/terminate", TerminateEmployee)
+	e.POST("/employee/:id/offboard", OffboardEmployee)
+	e.GET("/offboardings", GetAllOffboardings)
+	e.POST("/employee/:id/restore", RestoreEmployee)
+
+	//routes for departments and the org chart
+	e.GET("/department", GetDepartment)
+	e.GET("/departments", GetAllDepartments)
+	e.GET("/departments/tree", GetDepartmentTree)
+	e.POST("/department", CreateDepartment)
+	e.PUT("/department/:id", UpdateDepartment)
+	e.DELETE("/department/:id", DeleteDepartment)
+	e.PUT("/employee/:id/department", SetEmployeeDepartment)
+	e.PUT("/employee/:id/manager", SetEmployeeManager)
+	e.GET("/org-chart", GetOrgChart)
 
 	//routes for employee sales
 	e.GET("/sale", GetSales)
@@ -52,9 +109,102 @@ func RegisterRoutes(e *echo.Echo, q *internals.Queries) {
 	e.POST("/sale", CreateSale)
 	e.PUT("/sale/:id", UpdateSale)
 	e.DELETE("/sale/:id", DeleteSale)
+	e.POST("/sale/:id/restore", RestoreSale)
 
+	//routes for employee reports
+	e.GET("/employee/:id/report", GenerateEmployeeRangeReport)
 	e.GET("/employee/:id/report/month", GenerateEmployeeMonthlyReport)
 	e.GET("/employee/:id/report/quarter", GenerateEmployeeQuarterlyReport)
+	e.GET("/employee/:id/report/year", GenerateEmployeeYearlyReport)
+
+	//routes for sales targets
+	e.GET("/target", GetSalesTarget)
+	e.GET("/targets", GetAllSalesTargets)
+	e.PUT("/employee/:id/target", SetSalesTarget)
+	e.DELETE("/target/:id", DeleteSalesTarget)
+
+	//routes for commissions
+	e.GET("/commission-plan", GetCommissionPlan)
+	e.GET("/commission-plans", GetAllCommissionPlans)
+	e.POST("/commission-plan", CreateCommissionPlan)
+	e.PUT("/commission-plan/:id", UpdateCommissionPlan)
+	e.DELETE("/commission-plan/:id", DeleteCommissionPlan)
+	e.GET("/employee/:id/commission-plans", GetCommissionAssignments)
+	e.PUT("/employee/:id/commission-plan", AssignCommissionPlan)
+	e.DELETE("/employee/:id/commission-plan", DeleteCommissionAssignment)
+	e.GET("/employee/:id/commission", GetEmployeeCommission)
+	e.GET("/employee/:id/commission/statement", GetEmployeeCommissionStatement)
+	e.GET("/commissions", GetAllCommissions)
+
+	//routes for sales statistics
+	e.GET("/employees/stats", GetEmployeesStats)
+	e.GET("/employee/:id/stats", GetEmployeeStats)
+
+	//routes for team reports
+	e.GET("/sales/report", GenerateTeamRangeReport)
+	e.GET("/sales/report/month", GenerateTeamMonthlyReport)
+	e.GET("/sales/report/quarter", GenerateTeamQuarterlyReport)
+	e.GET("/sales/report/year", GenerateTeamYearlyReport)
+
+	//routes for revenue over time
+	e.GET("/sales/timeseries", GetSalesTimeseries)
+
+	//routes for exchange rates
+	e.GET("/exchange-rate", GetExchangeRate)
+	e.GET("/exchange-rates", GetAllExchangeRates)
+	e.POST("/exchange-rate", CreateExchangeRate)
+	e.PUT("/exchange-rate/:id", UpdateExchangeRate)
+	e.DELETE("/exchange-rate/:id", DeleteExchangeRate)
+	e.POST("/exchange-rates/import", ImportExchangeRates)
+
+	//routes for currencies registry
+	e.GET("/currency", GetCurrency)
+	e.GET("/currencies", GetAllCurrencies)
+	e.POST("/currency", CreateCurrency)
+	e.PUT("/currency/:code", UpdateCurrency)
+
+	//routes for categories
+	e.GET("/category", GetCategory)
+	e.GET("/categories", GetAllCategories)
+	e.GET("/categories/stats", GetCategoriesStats)
+	e.POST("/category", CreateCategory)
+	e.PUT("/category/:id", UpdateCategory)
+	e.DELETE("/category/:id", DeleteCategory)
+
+	//routes for product catalog
+	e.GET("/product", GetProduct)
+	e.GET("/products", GetAllProducts)
+	e.POST("/product", CreateProduct)
+	e.PUT("/product/:id", UpdateProduct)
+	e.DELETE("/product/:id", DeleteProduct)
+
+	//routes for refunds
+	e.GET("/refund", GetRefund)
+	e.GET("/refunds", GetAllRefunds)
+	e.POST("/sale/:id/refund", CreateRefund)
+	e.DELETE("/refund/:id", DeleteRefund)
+
+	//routes for customers
+	e.GET("/customer", GetCustomer)
+	e.GET("/customers", GetAllCustomers)
+	e.POST("/customer", CreateCustomer)
+	e.PUT("/customer/:id", UpdateCustomer)
+	e.DELETE("/customer/:id", DeleteCustomer)
+	e.GET("/customer/:id/sales", GetCustomerSales)
+	e.GET("/customer/:id/lifetime-value", GetCustomerValue)
+
+	//routes for promotion codes
+	e.GET("/promotion", GetPromotion)
+	e.GET("/promotions", GetAllPromotions)
+	e.POST("/promotion", CreatePromotion)
+	e.PUT("/promotion/:id", UpdatePromotion)
+	e.DELETE("/promotion/:id", DeletePromotion)
+
+	//routes for the audit log
+	e.GET("/audit", GetAuditLog)
+
+	//routes for admins, see SetAdminToken
+	e.POST("/admin/purge", PurgeDeleted)
 }
 
 func GetEmployee(c echo.Context) error {
@@ -69,7 +219,11 @@ func GetEmployee(c echo.Context) error {
 	if err != nil {
 		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
 	}
-	employee, err := queries.GetEmployee(ctx, int32(id))
+	includeDeleted, err := parseIncludeDeleted(c)
+	if err != nil {
+		return adminError(c, err)
+	}
+	employee, err := getEmployee(ctx, int32(id), includeDeleted)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "Employee not found"})
 	}
@@ -80,10 +234,10 @@ func GetEmployee(c echo.Context) error {
 func CreateEmployee(c echo.Context) error {
 	// Logic to create an employee
 
-	ctx := c.Request().Context()
-	var employeeParams internals.CreateEmployeeParams
+	var req employeeRequest
 
-	if err := c.Bind(&employeeParams); err == nil {
+	if err := c.Bind(&req); err == nil {
+		employeeParams := internals.CreateEmployeeParams{Name: req.Name, Surname: req.Surname, Email: req.Email}
 		if employeeParams.Name != "" && employeeParams.Surname != "" && employeeParams.Email != "" {
 			// Validate email format
 			if !isValidEmail(employeeParams.Email) {
@@ -117,11 +271,13 @@ func CreateEmployee(c echo.Context) error {
 				return c.JSON(400, map[string]string{"error": "Email must be at most 255 characters long"})
 			}
 
-			employee, err := queries.CreateEmployee(ctx, employeeParams)
-			if err != nil {
-				return c.JSON(500, map[string]string{"error": "Failed to create employee"})
+			employment := req.employmentRequest
+			employment.fromQuery(c)
+			if err := employment.createParams(&employeeParams); err != nil {
+				return c.JSON(400, map[string]string{"error": err.Error()})
 			}
-			return c.JSON(http.StatusCreated, employee)
+
+			return createEmployee(c, employeeParams)
 		}
 	}
 	name := c.QueryParam("name")
@@ -161,22 +317,41 @@ func CreateEmployee(c echo.Context) error {
 			return c.JSON(400, map[string]string{"error": "Email must be at most 255 characters long"})
 		}
 
-		employeeParams = internals.CreateEmployeeParams{
+		employeeParams := internals.CreateEmployeeParams{
 			Name:    name,
 			Surname: surname,
 			Email:   email,
 		}
-
-		employee, err := queries.CreateEmployee(ctx, employeeParams)
-		if err != nil {
-			return c.JSON(500, map[string]string{"error": "Failed to create employee"})
+		var employment employmentRequest
+		employment.fromQuery(c)
+		if err := employment.createParams(&employeeParams); err != nil {
+			return c.JSON(400, map[string]string{"error": err.Error()})
 		}
-		return c.JSON(http.StatusCreated, employee)
+
+		return createEmployee(c, employeeParams)
 	}
 
 	return c.JSON(400, map[string]string{
-		"error": "Provide employee data either as JSON body or query parameters (name, surname, email)",
+		"error": "Provide employee data either as JSON body or query parameters (name, surname, email, optional: hire_date, job_title)",
+	})
+}
+
+// Helper function to add a validated employee and record it in the audit log
+func createEmployee(c echo.Context, params internals.CreateEmployeeParams) error {
+	ctx := c.Request().Context()
+
+	var employee internals.Employee
+	err := withTx(ctx, func(q *internals.Queries) error {
+		var err error
+		if employee, err = q.CreateEmployee(ctx, params); err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{Entity: auditEmployee, EntityID: employee.ID, Action: "create", After: employee})
 	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create employee"})
+	}
+	return c.JSON(http.StatusCreated, employee)
 }
 
 func UpdateEmployee(c echo.Context) error {
@@ -192,25 +367,16 @@ func UpdateEmployee(c echo.Context) error {
 		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
 	}
 
-	currentEmployee, err := queries.GetEmployee(ctx, int32(id))
-	if err != nil {
-		return c.JSON(404, map[string]string{"error": "Employee not found"})
-	}
-
-	updateParams := internals.UpdateEmployeeParams{
-		ID:      currentEmployee.ID,
-		Name:    currentEmployee.Name,
-		Surname: currentEmployee.Surname,
-		Email:   currentEmployee.Email,
-	}
+	// Changes asked for, empty fields keep the value of the employee
+	var changes internals.UpdateEmployeeParams
 
-	var jsonParams internals.UpdateEmployeeParams
+	var jsonParams employeeRequest
 	if err := c.Bind(&jsonParams); err == nil {
 		if jsonParams.Name != "" {
-			updateParams.Name = jsonParams.Name
+			changes.Name = jsonParams.Name
 		}
 		if jsonParams.Surname != "" {
-			updateParams.Surname = jsonParams.Surname
+			changes.Surname = jsonParams.Surname
 		}
 		if jsonParams.Email != "" {
 			// Validate email format
@@ -219,7 +385,7 @@ func UpdateEmployee(c echo.Context) error {
 			}
 
 			// Check if email already exists (excluding current employee)
-			emailExists, err := isEmailExists(c, jsonParams.Email, currentEmployee.ID)
+			emailExists, err := isEmailExists(c, jsonParams.Email, int32(id))
 			if err != nil {
 				return c.JSON(500, map[string]string{"error": "Failed to check email existence"})
 			}
@@ -227,15 +393,15 @@ func UpdateEmployee(c echo.Context) error {
 				return c.JSON(409, map[string]string{"error": "Email already exists"})
 			}
 
-			updateParams.Email = jsonParams.Email
+			changes.Email = jsonParams.Email
 		}
 	}
 
 	if name := c.QueryParam("name"); name != "" {
-		updateParams.Name = name
+		changes.Name = name
 	}
 	if surname := c.QueryParam("surname"); surname != "" {
-		updateParams.Surname = surname
+		changes.Surname = surname
 	}
 	if email := c.QueryParam("email"); email != "" {
 		// Validate email format
@@ -244,7 +410,7 @@ func UpdateEmployee(c echo.Context) error {
 		}
 
 		// Check if email already exists (excluding current employee)
-		emailExists, err := isEmailExists(c, email, currentEmployee.ID)
+		emailExists, err := isEmailExists(c, email, int32(id))
 		if err != nil {
 			return c.JSON(500, map[string]string{"error": "Failed to check email existence"})
 		}
@@ -252,14 +418,62 @@ func UpdateEmployee(c echo.Context) error {
 			return c.JSON(409, map[string]string{"error": "Email already exists"})
 		}
 
-		updateParams.Email = email
+		changes.Email = email
 	}
-	if updateParams.Name == "" && updateParams.Surname == "" && updateParams.Email == "" {
-		return c.JSON(400, map[string]string{"error": "No fields to update"})
+
+	employment := jsonParams.employmentRequest
+	employment.fromQuery(c)
+
+	// The employee is read and locked in the transaction, so that concurrent updates apply one after the other
+	// and every audit entry starts from the employee the previous one left
+	var updatedEmployee internals.Employee
+	err = withTx(ctx, func(q *internals.Queries) error {
+		currentEmployee, err := q.GetEmployeeForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+
+		updateParams := internals.UpdateEmployeeParams{
+			ID:      currentEmployee.ID,
+			Name:    currentEmployee.Name,
+			Surname: currentEmployee.Surname,
+			Email:   currentEmployee.Email,
+		}
+		if changes.Name != "" {
+			updateParams.Name = changes.Name
+		}
+		if changes.Surname != "" {
+			updateParams.Surname = changes.Surname
+		}
+		if changes.Email != "" {
+			updateParams.Email = changes.Email
+		}
+		if updateParams.Name == "" && updateParams.Surname == "" && updateParams.Email == "" {
+			return rejection("No fields to update")
+		}
+
+		employee := currentEmployee
+		if err := employment.apply(&employee); err != nil {
+			return rejection(err.Error())
+		}
+		updateParams.HireDate = employee.HireDate
+		updateParams.TerminationDate = employee.TerminationDate
+		updateParams.JobTitle = employee.JobTitle
+		updateParams.Status = employee.Status
+
+		if updatedEmployee, err = q.UpdateEmployee(ctx, updateParams); err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{
+			Entity: auditEmployee, EntityID: updatedEmployee.ID, Action: "update",
+			Before: currentEmployee, After: updatedEmployee,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
 	}
-	updatedEmployee, err := queries.UpdateEmployee(ctx, updateParams)
 	if err != nil {
-		return c.JSON(500, map[string]string{"error": "Failed to update employee"})
+		return rejectionError(c, err, "Failed to update employee")
 	}
 	return c.JSON(http.StatusOK, updatedEmployee)
 
@@ -280,26 +494,196 @@ func DeleteEmployee(c echo.Context) error {
 	sales, err := queries.GetSalesByEmployee(ctx, int32(id))
 	if err == nil && len(sales) > 0 {
 		return c.JSON(400, map[string]string{
-			"error": "Cannot delete employee with existing sales",
+			"error": "Cannot delete employee with existing sales, use /employee/:id/offboard to reassign them",
 		})
 	}
 
-	err = queries.DeleteEmployee(ctx, int32(id))
-	if err != nil {
+	// the employee is only marked as deleted, see RestoreEmployee and StartPurgeJob
+	err = withTx(ctx, func(q *internals.Queries) error {
+		employee, err := q.GetEmployeeForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if _, err := q.DeleteEmployee(ctx, employee.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{Entity: auditEmployee, EntityID: employee.ID, Action: "delete", Before: employee})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
 		return c.JSON(404, map[string]string{"error": "Employee not found"})
 	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete employee"})
+	}
 
 	return c.JSON(200, "Delete Employee")
 }
 
+// Sort orders accepted by GET /employees, "-" means descending
+var employeeSorts = []string{"id", "-id", "name", "-name", "surname", "-surname", "email", "-email"}
+
+// employeeSalesSummary is embedded in the employee list for ?with_sales=true
+type employeeSalesSummary struct {
+	TotalSales   int64        `json:"total_sales"`
+	TotalRevenue money.Amount `json:"total_revenue"` // in the base currency
+	Currency     string       `json:"currency"`
+	LastSaleDate *time.Time   `json:"last_sale_date"`
+}
+
+type employeeListItem struct {
+	internals.Employee
+	SalesSummary *employeeSalesSummary `json:"sales_summary,omitempty"`
+}
+
+// GetAllEmployees lists employees page by page, e.g. /employees?search=kowal&sort=surname&limit=20&with_sales=true.
+// search matches name, surname or email, ignoring case. ?department_id= and ?manager_id= narrow the list
+// to a department with its teams or to a manager and everybody below them, ?status=active|inactive to current staff or leavers.
+// Soft-deleted employees are left out unless an admin asks for ?include_deleted=true.
 func GetAllEmployees(c echo.Context) error {
 	ctx := c.Request().Context()
-	employees, err := queries.GetEmployees(ctx)
+
+	var search sql.NullString
+	if searchStr := strings.TrimSpace(c.QueryParam("search")); searchStr != "" {
+		// % and _ are wildcards in ILIKE, search for them literally
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(searchStr)
+		search = sql.NullString{String: escaped, Valid: true}
+	}
+
+	withSales := false
+	if withSalesStr := c.QueryParam("with_sales"); withSalesStr != "" {
+		var err error
+		withSales, err = strconv.ParseBool(withSalesStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid with_sales format"})
+		}
+	}
+
+	scope, err := parseOrgScope(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	var status sql.NullString
+	switch statusStr := c.QueryParam("status"); statusStr {
+	case "":
+	case "active", "inactive":
+		status = sql.NullString{String: statusStr, Valid: true}
+	default:
+		return c.JSON(400, map[string]string{"error": "Invalid status (active, inactive)"})
+	}
+
+	includeDeleted, err := parseIncludeDeleted(c)
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	limit, err := parsePageLimit(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	sort, err := parsePageSort(c, employeeSorts, "id")
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	cursor, err := parsePageCursor(c, sort)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	params := internals.ListEmployeesParams{
+		BaseCurrency:   rates.Base(),
+		WithSales:      withSales,
+		Search:         search,
+		DepartmentID:   scope.DepartmentID,
+		ManagerID:      scope.ManagerID,
+		Status:         status,
+		IncludeDeleted: includeDeleted,
+		Sort:           sort,
+		PageLimit:      limit + 1, // one extra row tells whether there is a next page
+	}
+	if cursor != nil {
+		params.CursorID = sql.NullInt32{Int32: cursor.ID, Valid: true}
+		params.CursorValue = sql.NullString{String: cursor.Value, Valid: true}
+	}
+
+	employees, err := queries.ListEmployees(ctx, params)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": "Failed to get employees"})
 	}
-	return c.JSON(200, employees)
+	total, err := queries.CountEmployees(ctx, internals.CountEmployeesParams{
+		Search:         search,
+		DepartmentID:   scope.DepartmentID,
+		ManagerID:      scope.ManagerID,
+		Status:         status,
+		IncludeDeleted: includeDeleted,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to count employees"})
+	}
+
+	result := page[employeeListItem]{
+		Data: make([]employeeListItem, 0, len(employees)),
+		Meta: pageMeta{Total: total, Limit: limit, Sort: sort},
+	}
+	if len(employees) > int(limit) {
+		employees = employees[:limit]
+		last := employees[len(employees)-1]
+		next := pageCursor{Sort: sort, ID: last.ID}
+		switch strings.TrimPrefix(sort, "-") {
+		case "name":
+			next.Value = last.Name
+		case "surname":
+			next.Value = last.Surname
+		case "email":
+			next.Value = last.Email
+		}
+		result.Meta.NextCursor = next.encode()
+	}
+
+	var missing []string // currencies of sales that cannot be converted to the base currency
+	for _, row := range employees {
+		item := employeeListItem{Employee: internals.Employee{
+			ID:              row.ID,
+			Name:            row.Name,
+			Surname:         row.Surname,
+			Email:           row.Email,
+			DepartmentID:    row.DepartmentID,
+			ManagerID:       row.ManagerID,
+			HireDate:        row.HireDate,
+			TerminationDate: row.TerminationDate,
+			JobTitle:        row.JobTitle,
+			Status:          row.Status,
+			CreatedAt:       row.CreatedAt,
+			UpdatedAt:       row.UpdatedAt,
+			DeletedAt:       row.DeletedAt,
+		}}
+		if withSales {
+			for _, currency := range strings.Split(row.MissingCurrencies, ",") {
+				if currency != "" && !slices.Contains(missing, currency) {
+					missing = append(missing, currency)
+				}
+			}
+			revenue, err := money.Parse(row.TotalRevenue)
+			if err != nil {
+				return c.JSON(500, map[string]string{"error": "Failed to get sales summary"})
+			}
+			item.SalesSummary = &employeeSalesSummary{
+				TotalSales:   row.TotalSales,
+				TotalRevenue: revenue,
+				Currency:     rates.Base(),
+			}
+			if row.LastSaleDate.Valid {
+				item.SalesSummary.LastSaleDate = &row.LastSaleDate.Time
+			}
+		}
+		result.Data = append(result.Data, item)
+	}
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		return c.JSON(400, map[string]string{"error": fmt.Sprintf("%s to %s for: %s", errMissingBaseRates, rates.Base(), strings.Join(missing, ", "))})
+	}
 
+	return c.JSON(200, result)
 }
 
 func GetSales(c echo.Context) error {
@@ -312,94 +696,125 @@ func GetSales(c echo.Context) error {
 	if err != nil {
 		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
 	}
-	sale, err := queries.GetSale(ctx, int32(id))
+	includeDeleted, err := parseIncludeDeleted(c)
+	if err != nil {
+		return adminError(c, err)
+	}
+	sale, err := getSale(ctx, int32(id), includeDeleted)
 	if err != nil {
 		return c.JSON(404, map[string]string{"error": "Sale not found"})
 	}
-	return c.JSON(200, sale)
+	items, err := queries.GetSaleItems(ctx, sale.ID)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sale items"})
+	}
+	if items == nil {
+		items = []internals.SaleItem{}
+	}
+	refunds, err := queries.ListRefunds(ctx, internals.ListRefundsParams{SaleID: sql.NullInt32{Int32: sale.ID, Valid: true}})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get refunds"})
+	}
+	if refunds == nil {
+		refunds = []internals.Refund{}
+	}
+	return c.JSON(200, saleResponse{Sale: sale, Items: items, Refunds: refunds})
 }
 
+// CreateSale adds a sale with its line items. A sale sent without items, as older clients do,
+// is a single line made of product_name, category, price (the unit price), quantity and product_id/sku.
+// discount_percent, discount_amount or promo_code take a discount off the whole sale, customer_id names the buyer.
 func CreateSale(c echo.Context) error {
-	ctx := c.Request().Context()
-
 	type CreateSaleRequest struct {
-		ProductName string    `json:"product_name"`
-		Category    string    `json:"category"`
-		Currency    string    `json:"currency"`
-		Price       float64   `json:"price"`
-		SaleDate    time.Time `json:"sale_date"`
-		EmployeeID  int32     `json:"employee_id"`
+		ProductName     string            `json:"product_name"`
+		Category        string            `json:"category"`
+		Currency        string            `json:"currency"`
+		Price           money.Amount      `json:"price"`
+		Quantity        int32             `json:"quantity"`
+		SaleDate        time.Time         `json:"sale_date"`
+		EmployeeID      int32             `json:"employee_id"`
+		CustomerID      int32             `json:"customer_id"`
+		ProductID       int32             `json:"product_id"`
+		SKU             string            `json:"sku"`
+		Items           []saleItemRequest `json:"items"`
+		DiscountPercent *money.Amount     `json:"discount_percent"`
+		DiscountAmount  *money.Amount     `json:"discount_amount"`
+		PromoCode       string            `json:"promo_code"`
 	}
 
 	var req CreateSaleRequest
-	if err := c.Bind(&req); err == nil {
-		if req.EmployeeID != 0 && req.Price > 0 {
-			_, err := queries.GetEmployee(ctx, req.EmployeeID)
-			if err != nil {
-				return c.JSON(400, map[string]string{"error": "Employee not found"})
-			}
-			if req.ProductName == "" {
-				return c.JSON(400, map[string]string{"error": "Product name is required"})
-			}
-			if req.SaleDate.After(time.Now()) {
-				return c.JSON(400, map[string]string{"error": "Sale date cannot be in the future"})
-			}
-			if req.Category == "" {
-				return c.JSON(400, map[string]string{"error": "Category is required"})
-			}
-
-			// Use current time if SaleDate is zero
-			if req.SaleDate.IsZero() {
-				req.SaleDate = time.Now()
-			}
-
-			saleParams := internals.CreateSaleParams{
+	err := c.Bind(&req)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return c.JSON(400, map[string]string{"error": "Invalid price or discount format. Use at most 2 decimal places"})
+	}
+	if err == nil && req.EmployeeID != 0 && (len(req.Items) > 0 || req.Price > 0 || req.ProductID != 0 || req.SKU != "") {
+		if len(req.Items) == 0 {
+			req.Items = []saleItemRequest{{
+				ProductID:   req.ProductID,
+				SKU:         req.SKU,
 				ProductName: req.ProductName,
 				Category:    req.Category,
-				Currency:    req.Currency,
-				Price:       fmt.Sprintf("%.2f", req.Price), // Converting float64 -> string
-				SaleDate:    req.SaleDate,
-				EmployeeID:  req.EmployeeID,
-			}
+				Quantity:    req.Quantity,
+				UnitPrice:   req.Price,
+			}}
+		}
+		if req.SaleDate.After(time.Now()) {
+			return c.JSON(400, map[string]string{"error": "Sale date cannot be in the future"})
+		}
 
-			sale, err := queries.CreateSale(ctx, saleParams)
-			if err != nil {
-				return c.JSON(500, map[string]string{"error": "Failed to create sale"})
-			}
-			return c.JSON(201, sale)
+		// Use current time if SaleDate is zero
+		if req.SaleDate.IsZero() {
+			req.SaleDate = time.Now()
 		}
+		discount := discountRequest{Percent: req.DiscountPercent, Amount: req.DiscountAmount, PromoCode: req.PromoCode}
+		customerID := sql.NullInt32{Int32: req.CustomerID, Valid: req.CustomerID != 0}
+		return createSale(c, req.EmployeeID, customerID, req.Currency, req.SaleDate, req.Items, discount)
 	}
 
 	productName := c.QueryParam("product_name")
 	category := c.QueryParam("category")
 	currency := c.QueryParam("currency")
 	priceStr := c.QueryParam("price")
+	quantityStr := c.QueryParam("quantity")
 	employeeIDStr := c.QueryParam("employee_id")
 	sale_dateStr := c.QueryParam("sale_date")
+	productIDStr := c.QueryParam("product_id")
+	sku := c.QueryParam("sku")
 
-	if productName != "" && category != "" && priceStr != "" && employeeIDStr != "" {
-		price, err := strconv.ParseFloat(priceStr, 64)
-		if err != nil {
-			return c.JSON(400, map[string]string{"error": "Invalid price format"})
-		}
+	// A catalog product given by product_id or sku fills in the missing parameters
+	if employeeIDStr != "" && (productIDStr != "" || sku != "" || (productName != "" && category != "" && priceStr != "")) {
+		item := saleItemRequest{SKU: sku, ProductName: productName, Category: category}
 
-		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 32)
-		if err != nil {
-			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
+		if productIDStr != "" {
+			productID, err := strconv.ParseInt(productIDStr, 10, 32)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid product_id format"})
+			}
+			item.ProductID = int32(productID)
 		}
 
-		if currency == "" {
-			currency = "PLN"
+		if priceStr != "" {
+			price, err := money.Parse(priceStr)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid price format. Use at most 2 decimal places"})
+			}
+			if price <= 0 {
+				return c.JSON(400, map[string]string{"error": "Price must be greater than 0"})
+			}
+			item.UnitPrice = price
 		}
 
-		if price <= 0 {
-			return c.JSON(400, map[string]string{"error": "Price must be greater than 0"})
+		if quantityStr != "" {
+			quantity, err := strconv.ParseInt(quantityStr, 10, 32)
+			if err != nil || quantity < 1 {
+				return c.JSON(400, map[string]string{"error": "Invalid quantity format"})
+			}
+			item.Quantity = int32(quantity)
 		}
 
-		// Check if employee exists
-		_, err = queries.GetEmployee(ctx, int32(employeeID))
+		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 32)
 		if err != nil {
-			return c.JSON(400, map[string]string{"error": "Employee not found"})
+			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
 		}
 
 		// Parse sale date if provided
@@ -417,397 +832,584 @@ func CreateSale(c echo.Context) error {
 			saleDate = time.Now()
 		}
 
-		saleParams := internals.CreateSaleParams{
-			ProductName: productName,
-			Category:    category,
-			Currency:    currency,
-			Price:       fmt.Sprintf("%.2f", price), // Converting float64 -> string
-			SaleDate:    saleDate,
-			EmployeeID:  int32(employeeID),
+		var customerID sql.NullInt32
+		if customerIDStr := c.QueryParam("customer_id"); customerIDStr != "" {
+			id, err := strconv.ParseInt(customerIDStr, 10, 32)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid customer_id format"})
+			}
+			customerID = sql.NullInt32{Int32: int32(id), Valid: true}
 		}
 
-		sale, err := queries.CreateSale(ctx, saleParams)
+		discount, err := parseDiscountQuery(c)
 		if err != nil {
-			return c.JSON(500, map[string]string{"error": "Failed to create sale"})
+			return c.JSON(400, map[string]string{"error": err.Error()})
 		}
-		return c.JSON(201, sale)
+
+		return createSale(c, int32(employeeID), customerID, currency, saleDate, []saleItemRequest{item}, discount)
 	}
 
 	return c.JSON(400, map[string]string{
-		"error": "Provide sale data either as JSON body or query parameters (product_name, category, price, employee_id, optional: sale_date)",
+		"error": "Provide sale data either as JSON body or query parameters (product_name, category, price or product_id/sku, employee_id, optional: quantity, sale_date, customer_id, discount_percent, discount_amount or promo_code)",
 	})
 }
 
-func UpdateSale(c echo.Context) error {
+// Helper function to validate a new sale and store it with its lines in one transaction
+func createSale(c echo.Context, employeeID int32, customerID sql.NullInt32, currencyCode string, saleDate time.Time, items []saleItemRequest, discountReq discountRequest) error {
 	ctx := c.Request().Context()
 
-	idStr := c.Param("id")
-	if idStr == "" {
-		return c.JSON(400, map[string]string{"error": "Sale ID is required"})
+	// Check if employee exists and still works here
+	employee, err := queries.GetEmployee(ctx, employeeID)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Employee not found"})
+	}
+	if err := checkEmployeeCanSell(employee, saleDate); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if customerID.Valid {
+		if _, err := queries.GetCustomer(ctx, customerID.Int32); err != nil {
+			return c.JSON(400, map[string]string{"error": "Customer not found"})
+		}
 	}
 
-	id, err := strconv.ParseInt(idStr, 10, 32)
+	lines, currency, err := resolveSaleItems(ctx, currencyCode, items)
 	if err != nil {
-		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+		return c.JSON(400, map[string]string{"error": err.Error()})
 	}
-
-	currentSale, err := queries.GetSale(ctx, int32(id))
+	discount, err := resolveDiscount(ctx, discountReq, currency, saleDate)
 	if err != nil {
-		return c.JSON(404, map[string]string{"error": "Sale not found"})
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err := applyDiscount(lines, discount, currency); err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	summary := summarizeSaleItems(lines)
+
+	var sale saleResponse
+	err = withTx(ctx, func(q *internals.Queries) error {
+		var err error
+		sale.Sale, err = q.CreateSale(ctx, internals.CreateSaleParams{
+			ProductName:     summary.ProductName,
+			Category:        summary.Category,
+			Currency:        currency.Code,
+			Price:           summary.Total,
+			SaleDate:        saleDate,
+			EmployeeID:      sql.NullInt32{Int32: employeeID, Valid: true},
+			ProductID:       summary.ProductID,
+			NetAmount:       summary.NetAmount,
+			TaxAmount:       summary.TaxAmount,
+			DiscountAmount:  summary.Discount,
+			DiscountPercent: discount.Percent.String(),
+			PromotionID:     discount.PromotionID,
+			CustomerID:      customerID,
+		})
+		if err != nil {
+			return err
+		}
+		if sale.Items, err = createSaleItems(ctx, q, sale.ID, lines); err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{Entity: auditSale, EntityID: sale.ID, Action: "create", After: sale.Sale})
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to create sale"})
 	}
+	return c.JSON(201, sale)
+}
 
-	updateParams := internals.UpdateSaleParams{
-		ID:          currentSale.ID,
-		ProductName: currentSale.ProductName,
-		Category:    currentSale.Category,
-		Currency:    currentSale.Currency,
-		Price:       currentSale.Price,
-		SaleDate:    currentSale.SaleDate,
-		EmployeeID:  currentSale.EmployeeID,
-	}
+// saleUpdateRequest is what an update of a sale asks to change, empty fields keep the value of the sale
+type saleUpdateRequest struct {
+	ProductName string
+	Category    string
+	Currency    string
+	Price       money.Amount
+	SaleDate    time.Time
+	EmployeeID  int32
+	CustomerID  *int32 // 0 removes the customer
+	Items       []saleItemRequest
+	Discount    discountRequest
+}
 
-	var jsonParams internals.UpdateSaleParams
-	if err := c.Bind(&jsonParams); err == nil {
-		if jsonParams.ProductName != "" {
-			updateParams.ProductName = jsonParams.ProductName
-		}
-		if jsonParams.Category != "" {
-			updateParams.Category = jsonParams.Category
-		}
-		if jsonParams.Currency != "" {
-			updateParams.Currency = jsonParams.Currency
-		}
-		if jsonParams.Price != "" {
-			// Validate price format and value
-			price, err := strconv.ParseFloat(jsonParams.Price, 64)
-			if err != nil {
-				return c.JSON(400, map[string]string{"error": "Invalid price format"})
-			}
-			if price <= 0 {
-				return c.JSON(400, map[string]string{"error": "Price must be greater than 0"})
-			}
-			updateParams.Price = jsonParams.Price
+// Helper function to read the changes of a sale from the JSON body and the query, the query takes precedence
+func parseSaleUpdate(c echo.Context) (saleUpdateRequest, error) {
+	// Header fields are matched the same way as the fields of internals.UpdateSaleParams
+	type UpdateSaleRequest struct {
+		ProductName     string
+		Category        string
+		Currency        string
+		Price           money.Amount
+		SaleDate        time.Time
+		EmployeeID      int32
+		CustomerID      *int32            `json:"customer_id"` // 0 removes the customer
+		Items           []saleItemRequest `json:"items"`
+		DiscountPercent *money.Amount     `json:"discount_percent"`
+		DiscountAmount  *money.Amount     `json:"discount_amount"`
+		PromoCode       string            `json:"promo_code"`
+	}
+
+	var req saleUpdateRequest
+	var jsonParams UpdateSaleRequest
+	err := c.Bind(&jsonParams)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return req, errors.New("Invalid price or discount format. Use at most 2 decimal places")
+	}
+	if err == nil {
+		req = saleUpdateRequest{
+			ProductName: jsonParams.ProductName,
+			Category:    jsonParams.Category,
+			Currency:    strings.ToUpper(jsonParams.Currency),
+			Price:       jsonParams.Price,
+			SaleDate:    jsonParams.SaleDate,
+			EmployeeID:  jsonParams.EmployeeID,
+			CustomerID:  jsonParams.CustomerID,
+			Items:       jsonParams.Items,
+			Discount:    discountRequest{Percent: jsonParams.DiscountPercent, Amount: jsonParams.DiscountAmount, PromoCode: jsonParams.PromoCode},
 		}
-		if !jsonParams.SaleDate.IsZero() {
-			// Validate sale date is not in the future
-			if jsonParams.SaleDate.After(time.Now()) {
-				return c.JSON(400, map[string]string{"error": "Sale date cannot be in the future"})
-			}
-			updateParams.SaleDate = jsonParams.SaleDate
-		}
-		if jsonParams.EmployeeID != 0 {
-			// Check if employee exists
-			_, err := queries.GetEmployee(ctx, jsonParams.EmployeeID)
-			if err != nil {
-				return c.JSON(400, map[string]string{"error": "Employee not found"})
-			}
-			updateParams.EmployeeID = jsonParams.EmployeeID
+		// Validate price value
+		if req.Price < 0 {
+			return req, errors.New("Price must be greater than 0")
 		}
 	}
 
 	if productName := c.QueryParam("product_name"); productName != "" {
-		updateParams.ProductName = productName
+		req.ProductName = productName
 	}
 
 	if category := c.QueryParam("category"); category != "" {
-		updateParams.Category = category
+		req.Category = category
 	}
 
 	if currency := c.QueryParam("currency"); currency != "" {
-		updateParams.Currency = currency
+		req.Currency = strings.ToUpper(currency)
 	}
 
 	if priceStr := c.QueryParam("price"); priceStr != "" {
-		price, err := strconv.ParseFloat(priceStr, 64)
+		price, err := money.Parse(priceStr)
 		if err != nil {
-			return c.JSON(400, map[string]string{"error": "Invalid price format"})
+			return req, errors.New("Invalid price format. Use at most 2 decimal places")
 		}
 		if price <= 0 {
-			return c.JSON(400, map[string]string{"error": "Price must be greater than 0"})
+			return req, errors.New("Price must be greater than 0")
 		}
-		updateParams.Price = fmt.Sprintf("%.2f", price)
+		req.Price = price
 	}
 
 	if saleDateStr := c.QueryParam("sale_date"); saleDateStr != "" {
 		saleDate, err := time.Parse(time.RFC3339, saleDateStr)
 		if err != nil {
-			return c.JSON(400, map[string]string{"error": "Invalid sale_date format"})
-		}
-		// Validate sale date is not in the future
-		if saleDate.After(time.Now()) {
-			return c.JSON(400, map[string]string{"error": "Sale date cannot be in the future"})
+			return req, errors.New("Invalid sale_date format")
 		}
-		updateParams.SaleDate = saleDate
+		req.SaleDate = saleDate
+	}
+	// Validate sale date is not in the future
+	if req.SaleDate.After(time.Now()) {
+		return req, errors.New("Sale date cannot be in the future")
 	}
 
 	if employeeIDStr := c.QueryParam("employee_id"); employeeIDStr != "" {
 		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 32)
 		if err != nil {
-			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
+			return req, errors.New("Invalid employee_id format")
 		}
-		// Check if employee exists
-		_, err = queries.GetEmployee(ctx, int32(employeeID))
+		req.EmployeeID = int32(employeeID)
+	}
+
+	if customerIDStr := c.QueryParam("customer_id"); customerIDStr != "" {
+		customerID, err := strconv.ParseInt(customerIDStr, 10, 32)
 		if err != nil {
-			return c.JSON(400, map[string]string{"error": "Employee not found"})
+			return req, errors.New("Invalid customer_id format")
+		}
+		id := int32(customerID)
+		req.CustomerID = &id
+	}
+
+	if !req.Discount.given() {
+		if req.Discount, err = parseDiscountQuery(c); err != nil {
+			return req, err
 		}
-		updateParams.EmployeeID = int32(employeeID)
 	}
+	return req, nil
+}
 
-	if updateParams.ProductName == "" && updateParams.Category == "" && updateParams.Currency == "" && updateParams.Price == "" && updateParams.SaleDate.IsZero() && updateParams.EmployeeID == 0 {
-		return c.JSON(400, map[string]string{"error": "No fields to update"})
+// Helper function to apply the requested changes to a sale and its lines. current is the sale as locked
+// by the transaction of the update, lines is nil when the lines of the sale stay as they are.
+func (req saleUpdateRequest) params(ctx context.Context, current internals.Sale, currentItems []internals.SaleItem) (params internals.UpdateSaleParams, lines []internals.CreateSaleItemParams, err error) {
+	params = internals.UpdateSaleParams{
+		ID:              current.ID,
+		ProductName:     current.ProductName,
+		Category:        current.Category,
+		Currency:        current.Currency,
+		Price:           current.Price,
+		SaleDate:        current.SaleDate,
+		EmployeeID:      current.EmployeeID,
+		ProductID:       current.ProductID,
+		NetAmount:       current.NetAmount,
+		TaxAmount:       current.TaxAmount,
+		DiscountAmount:  current.DiscountAmount,
+		DiscountPercent: current.DiscountPercent,
+		PromotionID:     current.PromotionID,
+		CustomerID:      current.CustomerID,
+	}
+	if req.ProductName != "" {
+		params.ProductName = req.ProductName
+	}
+	if req.Category != "" {
+		params.Category = req.Category
+	}
+	if req.Currency != "" {
+		params.Currency = req.Currency
+	}
+	if req.Price != 0 {
+		params.Price = req.Price
+	}
+	if !req.SaleDate.IsZero() {
+		params.SaleDate = req.SaleDate
+	}
+	if req.EmployeeID != 0 {
+		params.EmployeeID = sql.NullInt32{Int32: req.EmployeeID, Valid: true}
+	}
+	if req.CustomerID != nil {
+		params.CustomerID = sql.NullInt32{Int32: *req.CustomerID, Valid: *req.CustomerID != 0}
+	}
+
+	// Check if employee exists and worked here on the sale date, when either of them changes
+	if params.EmployeeID.Valid && (params.EmployeeID != current.EmployeeID || !params.SaleDate.Equal(current.SaleDate)) {
+		employee, err := queries.GetEmployee(ctx, params.EmployeeID.Int32)
+		if err != nil {
+			return params, nil, errors.New("Employee not found")
+		}
+		if err := checkEmployeeCanSell(employee, params.SaleDate); err != nil {
+			return params, nil, err
+		}
+	}
+	if params.CustomerID.Valid && params.CustomerID != current.CustomerID {
+		if _, err := queries.GetCustomer(ctx, params.CustomerID.Int32); err != nil {
+			return params, nil, errors.New("Customer not found")
+		}
 	}
 
-	updatedSale, err := queries.UpdateSale(ctx, updateParams)
+	// The product fields of the header apply to the only line of the sale
+	items := req.Items
+	productChanged := params.ProductName != current.ProductName || params.Category != current.Category || params.Price != current.Price
+	if productChanged && len(items) > 0 {
+		return params, nil, errors.New("Update either items or product_name, category and price")
+	}
+	if productChanged {
+		if len(currentItems) != 1 {
+			return params, nil, errors.New("Sale has several items, update them with items")
+		}
+		items = storedSaleItems(currentItems)
+		items[0].ProductName = params.ProductName
+		items[0].Category = params.Category
+		if params.Price != current.Price {
+			if items[0].Quantity != 1 {
+				return params, nil, fmt.Errorf("Sale item has quantity %d, update it with items", items[0].Quantity)
+			}
+			items[0].UnitPrice = params.Price
+		}
+	}
 
-	return c.JSON(http.StatusOK, updatedSale)
+	// Validate the lines when they, the currency or the discount change, unchanged legacy currency codes are left alone.
+	// The discount of the sale is taken off the new lines again unless another one is given.
+	if len(items) == 0 && (params.Currency != current.Currency || req.Discount.given()) {
+		items = storedSaleItems(currentItems)
+	}
+	if len(items) > 0 {
+		var currency internals.Currency
+		lines, currency, err = resolveSaleItems(ctx, params.Currency, items)
+		if err != nil {
+			return params, nil, err
+		}
+		var discount saleDiscount
+		if req.Discount.given() {
+			discount, err = resolveDiscount(ctx, req.Discount, currency, params.SaleDate)
+		} else {
+			discount, err = storedDiscount(current, currency)
+		}
+		if err != nil {
+			return params, nil, err
+		}
+		if err := applyDiscount(lines, discount, currency); err != nil {
+			return params, nil, err
+		}
+		summary := summarizeSaleItems(lines)
+		params.Currency = currency.Code
+		params.ProductName = summary.ProductName
+		params.Category = summary.Category
+		params.ProductID = summary.ProductID
+		params.Price = summary.Total
+		params.NetAmount = summary.NetAmount
+		params.TaxAmount = summary.TaxAmount
+		params.DiscountAmount = summary.Discount
+		params.DiscountPercent = discount.Percent.String()
+		params.PromotionID = discount.PromotionID
+	}
+
+	if params.ProductName == "" && params.Category == "" && params.Currency == "" && params.Price == 0 && params.SaleDate.IsZero() && !params.EmployeeID.Valid {
+		return params, nil, errors.New("No fields to update")
+	}
+	return params, lines, nil
 }
 
-func DeleteSale(c echo.Context) error {
-	// Logic to delete a sale
+// UpdateSale changes a sale. items replaces all lines of the sale. Without items,
+// product_name, category and price still update a sale with a single line, as for older clients.
+func UpdateSale(c echo.Context) error {
 	ctx := c.Request().Context()
+
 	idStr := c.Param("id")
 	if idStr == "" {
 		return c.JSON(400, map[string]string{"error": "Sale ID is required"})
 	}
+
 	id, err := strconv.ParseInt(idStr, 10, 32)
 	if err != nil {
 		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
 	}
-	err = queries.DeleteSale(ctx, int32(id))
+
+	req, err := parseSaleUpdate(c)
 	if err != nil {
-		return c.JSON(404, map[string]string{"error": "Sale not found"})
+		return c.JSON(400, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(200, map[string]string{"message": "Sale deleted successfully"})
-}
+	// The sale is read and locked in the transaction, so that concurrent updates apply one after the other
+	// and every audit entry starts from the sale the previous one left
+	var sale saleResponse
+	err = withTx(ctx, func(q *internals.Queries) error {
+		current, err := q.GetSaleForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if sale.Items, err = q.GetSaleItems(ctx, current.ID); err != nil {
+			return err
+		}
+		updateParams, lines, err := req.params(ctx, current, sale.Items)
+		if err != nil {
+			return rejection(err.Error())
+		}
+		if err := checkRefundedSale(ctx, q, current, updateParams); err != nil {
+			return err
+		}
 
-func GetAllSales(c echo.Context) error {
-	ctx := c.Request().Context()
-	sales, err := queries.GetSales(ctx)
+		if sale.Sale, err = q.UpdateSale(ctx, updateParams); err != nil {
+			return err
+		}
+		if lines != nil {
+			if err := q.DeleteSaleItems(ctx, sale.ID); err != nil {
+				return err
+			}
+			if sale.Items, err = createSaleItems(ctx, q, sale.ID, lines); err != nil {
+				return err
+			}
+		}
+		return recordAudit(c, q, auditChange{
+			Entity: auditSale, EntityID: sale.ID, Action: "update",
+			Before: current, After: sale.Sale,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Sale not found"})
+	}
 	if err != nil {
-		return c.JSON(500, map[string]string{"error": "Failed to get sales"})
+		return rejectionError(c, err, "Failed to update sale")
 	}
-	return c.JSON(200, sales)
+	if sale.Items == nil {
+		sale.Items = []internals.SaleItem{}
+	}
+
+	return c.JSON(http.StatusOK, sale)
 }
 
-func GenerateEmployeeMonthlyReport(c echo.Context) error {
+func DeleteSale(c echo.Context) error {
+	// Logic to delete a sale
 	ctx := c.Request().Context()
-
-	idStr, yearStr, monthStr := c.Param("id"), c.QueryParam("year"), c.QueryParam("month")
-
-	if idStr == "" || yearStr == "" || monthStr == "" {
-		return c.JSON(400, map[string]string{
-			"error": "Employee ID, year and month are required",
-		})
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Sale ID is required"})
 	}
 	id, err := strconv.ParseInt(idStr, 10, 32)
 	if err != nil {
-		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	// the sale is only marked as deleted, see RestoreSale and StartPurgeJob
+	err = withTx(ctx, func(q *internals.Queries) error {
+		sale, err := q.GetSaleForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if _, err := q.DeleteSale(ctx, sale.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{Entity: auditSale, EntityID: sale.ID, Action: "delete", Before: sale})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Sale not found"})
 	}
-
-	year, err := strconv.Atoi(yearStr)
 	if err != nil {
-		return c.JSON(400, map[string]string{"error": "Invalid year"})
+		return c.JSON(500, map[string]string{"error": "Failed to delete sale"})
 	}
 
-	month, err := strconv.Atoi(monthStr)
-	if err != nil || month < 1 || month > 12 {
-		return c.JSON(400, map[string]string{"error": "Invalid month (1-12)"})
+	return c.JSON(200, map[string]string{"message": "Sale deleted successfully"})
+}
+
+// Sort orders accepted by GET /sales, "-" means descending
+var saleSorts = []string{"-sale_date", "sale_date", "-price", "price", "-id", "id"}
+
+// GetAllSales lists sales page by page, e.g.
+// /sales?employee_id=1&customer_id=2&category=Electronics&currency=PLN&min_price=100&max_price=5000&from=2025-01-01&to=2025-01-31&sort=-price&limit=20.
+// ?unassigned=true lists the sales of the unassigned pool, see OffboardEmployee. Admins can add ?include_deleted=true.
+// The next page is requested with the next_cursor value from the response metadata.
+func GetAllSales(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var filters internals.CountSalesParams
+	if employeeIDStr := c.QueryParam("employee_id"); employeeIDStr != "" {
+		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
+		}
+		filters.EmployeeID = sql.NullInt32{Int32: int32(employeeID), Valid: true}
 	}
-	employee, err := queries.GetEmployee(ctx, int32(id))
-	if err != nil {
-		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	if unassignedStr := c.QueryParam("unassigned"); unassignedStr != "" {
+		unassigned, err := strconv.ParseBool(unassignedStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid unassigned format"})
+		}
+		filters.Unassigned = unassigned
 	}
-	startDate := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 1, -1).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-
-	sales, err := queries.GetSalesByDateRange(ctx, internals.GetSalesByDateRangeParams{
-		SaleDate:   startDate,
-		SaleDate_2: endDate,
-	})
+	includeDeleted, err := parseIncludeDeleted(c)
 	if err != nil {
-		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+		return adminError(c, err)
 	}
-	var employeeSales []internals.Sale
-	for _, sale := range sales {
-		if sale.EmployeeID == int32(id) {
-			employeeSales = append(employeeSales, sale)
+	filters.IncludeDeleted = includeDeleted
+	if customerIDStr := c.QueryParam("customer_id"); customerIDStr != "" {
+		customerID, err := strconv.ParseInt(customerIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid customer_id format"})
 		}
+		filters.CustomerID = sql.NullInt32{Int32: int32(customerID), Valid: true}
+	}
+	if category := c.QueryParam("category"); category != "" {
+		// Match categories regardless of case, unknown ones simply match no sales
+		if managed, err := queries.GetCategoryByName(ctx, category); err == nil {
+			category = managed.Name
+		}
+		filters.Category = sql.NullString{String: category, Valid: true}
+	}
+	if currency := c.QueryParam("currency"); currency != "" {
+		filters.Currency = sql.NullString{String: strings.ToUpper(currency), Valid: true}
+	}
+	if minPriceStr := c.QueryParam("min_price"); minPriceStr != "" {
+		minPrice, err := money.Parse(minPriceStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid min_price format"})
+		}
+		filters.MinPrice = sql.NullString{String: minPrice.String(), Valid: true}
+	}
+	if maxPriceStr := c.QueryParam("max_price"); maxPriceStr != "" {
+		maxPrice, err := money.Parse(maxPriceStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid max_price format"})
+		}
+		filters.MaxPrice = sql.NullString{String: maxPrice.String(), Valid: true}
+	}
+	// from and to are whole days, both inclusive
+	if fromStr := c.QueryParam("from"); fromStr != "" {
+		from, err := parseDate(fromStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid from date format"})
+		}
+		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+		filters.FromDate = sql.NullTime{Time: from, Valid: true}
+	}
+	if toStr := c.QueryParam("to"); toStr != "" {
+		to, err := parseDate(toStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid to date format"})
+		}
+		to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		filters.ToDate = sql.NullTime{Time: to, Valid: true}
 	}
 
-	// GENERATE PDF PDF
-	pdf := generateMonthlyReportPDF(employee, employeeSales, year, month)
-
-	// RETURNS PDF
-	c.Response().Header().Set("Content-Type", "application/pdf")
-	c.Response().Header().Set("Content-Disposition",
-		fmt.Sprintf("attachment; filename=\"raport_%s_%s_%d_%d.pdf\"",
-			employee.Name, employee.Surname, year, month))
-
-	return c.Stream(200, "application/pdf", pdf)
-
+	return listSales(c, filters)
 }
 
-func GenerateEmployeeQuarterlyReport(c echo.Context) error {
+// Helper function to answer one page of the sales matching filters, ordered by ?sort= from ?cursor=
+func listSales(c echo.Context, filters internals.CountSalesParams) error {
 	ctx := c.Request().Context()
 
-	idStr, yearStr, quarterStr := c.Param("id"), c.QueryParam("year"), c.QueryParam("quarter")
-
-	if idStr == "" || yearStr == "" || quarterStr == "" {
-		return c.JSON(400, map[string]string{
-			"error": "Employee ID, year and month are required",
-		})
-	}
-	id, err := strconv.ParseInt(idStr, 10, 32)
+	limit, err := parsePageLimit(c)
 	if err != nil {
-		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+		return c.JSON(400, map[string]string{"error": err.Error()})
 	}
-
-	year, err := strconv.Atoi(yearStr)
+	sort, err := parsePageSort(c, saleSorts, "-sale_date")
 	if err != nil {
-		return c.JSON(400, map[string]string{"error": "Invalid year"})
+		return c.JSON(400, map[string]string{"error": err.Error()})
 	}
-
-	employee, err := queries.GetEmployee(ctx, int32(id))
+	cursor, err := parsePageCursor(c, sort)
 	if err != nil {
-		return c.JSON(404, map[string]string{"error": "Employee not found"})
-	}
-	if year < employee.CreatedAt.Time.Year() || year > time.Now().Year() {
-		return c.JSON(400, map[string]string{"error": "Invalid year"})
-	}
-
-	quarter, err := strconv.Atoi(quarterStr)
-	if err != nil || quarter < 1 || quarter > 4 {
-		return c.JSON(400, map[string]string{"error": "Invalid month (1-4)"})
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	params := internals.ListSalesParams{
+		EmployeeID:     filters.EmployeeID,
+		Unassigned:     filters.Unassigned,
+		IncludeDeleted: filters.IncludeDeleted,
+		Category:       filters.Category,
+		Currency:       filters.Currency,
+		MinPrice:       filters.MinPrice,
+		MaxPrice:       filters.MaxPrice,
+		FromDate:       filters.FromDate,
+		ToDate:         filters.ToDate,
+		CustomerID:     filters.CustomerID,
+		Sort:           sort,
+		PageLimit:      limit + 1, // one extra row tells whether there is a next page
+	}
+	if cursor != nil {
+		params.CursorID = sql.NullInt32{Int32: cursor.ID, Valid: true}
+		switch strings.TrimPrefix(sort, "-") {
+		case "sale_date":
+			cursorDate, err := time.Parse(time.RFC3339Nano, cursor.Value)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid cursor"})
+			}
+			params.CursorDate = sql.NullTime{Time: cursorDate, Valid: true}
+		case "price":
+			cursorPrice, err := money.Parse(cursor.Value)
+			if err != nil {
+				return c.JSON(400, map[string]string{"error": "Invalid cursor"})
+			}
+			params.CursorPrice = sql.NullString{String: cursorPrice.String(), Valid: true}
+		}
 	}
 
-	startMonth := (quarter-1)*3 + 1
-	startDate := time.Date(year, time.Month(startMonth), 1, 0, 0, 0, 0, time.UTC)
-	endDate := startDate.AddDate(0, 3, -1).Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-
-	sales, err := queries.GetSalesByDateRange(ctx, internals.GetSalesByDateRangeParams{
-		SaleDate:   startDate,
-		SaleDate_2: endDate,
-	})
+	sales, err := queries.ListSales(ctx, params)
 	if err != nil {
-		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+		return c.JSON(500, map[string]string{"error": "Failed to get sales"})
 	}
-	var employeeSales []internals.Sale
-	for _, sale := range sales {
-		if sale.EmployeeID == int32(id) {
-			employeeSales = append(employeeSales, sale)
+	total, err := queries.CountSales(ctx, filters)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to count sales"})
+	}
+
+	result := page[internals.Sale]{
+		Data: make([]internals.Sale, 0, len(sales)),
+		Meta: pageMeta{Total: total, Limit: limit, Sort: sort},
+	}
+	if len(sales) > int(limit) {
+		sales = sales[:limit]
+		last := sales[len(sales)-1]
+		next := pageCursor{Sort: sort, ID: last.ID}
+		switch strings.TrimPrefix(sort, "-") {
+		case "sale_date":
+			next.Value = last.SaleDate.Format(time.RFC3339Nano)
+		case "price":
+			next.Value = last.Price.String()
 		}
+		result.Meta.NextCursor = next.encode()
 	}
+	result.Data = append(result.Data, sales...)
 
-	// GENERATE PDF PDF
-	pdf := generateQuarterlyReportPDF(employee, employeeSales, year, quarter)
-
-	// RETURNS PDF
-	c.Response().Header().Set("Content-Type", "application/pdf")
-	c.Response().Header().Set("Content-Disposition",
-		fmt.Sprintf("attachment; filename=\"raport_%s_%s_%d_%d.pdf\"",
-			employee.Name, employee.Surname, year, quarter))
-
-	return c.Stream(200, "application/pdf", pdf)
-}
-
-func generateMonthlyReportPDF(employee internals.Employee, sales []internals.Sale, year, month int) *bytes.Buffer {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-
-	// header
-	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(0, 10, fmt.Sprintf("Monthly report - %s %s", employee.Name, employee.Surname))
-	pdf.Ln(10)
-
-	pdf.SetFont("Arial", "", 12)
-	pdf.Cell(0, 10, fmt.Sprintf("Period: %s %d", time.Month(month).String(), year))
-	pdf.Ln(10)
-
-	// Statistics
-	totalSales := len(sales)
-	var totalRevenue float64
-	for _, sale := range sales {
-		price, _ := strconv.ParseFloat(sale.Price, 64)
-		totalRevenue += price
-	}
-
-	pdf.Cell(0, 10, fmt.Sprintf("Number of sales: %d", totalSales))
-	pdf.Ln(5)
-	pdf.Cell(0, 10, fmt.Sprintf("Total revenue: %.2f PLN", totalRevenue))
-	pdf.Ln(10)
-
-	// Sales table
-	if len(sales) > 0 {
-		pdf.SetFont("Arial", "B", 10)
-		pdf.Cell(30, 10, "Date")
-		pdf.Cell(50, 10, "Product")
-		pdf.Cell(30, 10, "Category")
-		pdf.Cell(30, 10, "Prize")
-		pdf.Ln(10)
-
-		pdf.SetFont("Arial", "", 9)
-		for _, sale := range sales {
-			pdf.Cell(30, 8, sale.SaleDate.Format("2006-01-02"))
-			pdf.Cell(50, 8, sale.ProductName)
-			pdf.Cell(30, 8, sale.Category)
-			pdf.Cell(30, 8, sale.Price+" "+sale.Currency)
-			pdf.Ln(8)
-		}
-	}
-
-	var buf bytes.Buffer
-	pdf.Output(&buf)
-	return &buf
-}
-
-func generateQuarterlyReportPDF(employee internals.Employee, sales []internals.Sale, year, quarter int) *bytes.Buffer {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-
-	// header
-	pdf.SetFont("Arial", "B", 16)
-	pdf.Cell(0, 10, fmt.Sprintf("Quarterly report- %s %s", employee.Name, employee.Surname))
-	pdf.Ln(10)
-
-	pdf.SetFont("Arial", "", 12)
-	pdf.Cell(0, 10, fmt.Sprintf("Period: Q%d %d", quarter, year))
-	pdf.Ln(10)
-
-	// Statistics
-	totalSales := len(sales)
-	var totalRevenue float64
-	for _, sale := range sales {
-		price, _ := strconv.ParseFloat(sale.Price, 64)
-		totalRevenue += price
-	}
-
-	pdf.Cell(0, 10, fmt.Sprintf("Number of sales: %d", totalSales))
-	pdf.Ln(5)
-	pdf.Cell(0, 10, fmt.Sprintf("Total revenue: %.2f PLN", totalRevenue))
-	pdf.Ln(10)
-
-	if len(sales) > 0 {
-		pdf.SetFont("Arial", "B", 10)
-		pdf.Cell(30, 10, "Date")
-		pdf.Cell(50, 10, "Product")
-		pdf.Cell(30, 10, "Category")
-		pdf.Cell(30, 10, "Price")
-		pdf.Ln(10)
-
-		pdf.SetFont("Arial", "", 9)
-		for _, sale := range sales {
-			pdf.Cell(30, 8, sale.SaleDate.Format("2006-01-02"))
-			pdf.Cell(50, 8, sale.ProductName)
-			pdf.Cell(30, 8, sale.Category)
-			pdf.Cell(30, 8, sale.Price+" "+sale.Currency)
-			pdf.Ln(8)
-		}
-	}
-
-	var buf bytes.Buffer
-	pdf.Output(&buf)
-	return &buf
+	return c.JSON(200, result)
 }
 
 // Helper function to parse date from string with multiple formats
@@ -0,0 +1,257 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxSaleItems keeps a single order from growing without bounds
+const maxSaleItems = 100
+
+// maxSalePrice is the largest total of a sale before its discount, sales.price and list_price are DECIMAL(10,2)
+const maxSalePrice money.Amount = 99999999_99
+
+// saleItemRequest is one line of a sale. A catalog product given by product_id or sku
+// fills in the name, category and unit price that were not given. The unit price is gross,
+// the tax rate defaults to the rate of the product and then to the rate of the category.
+type saleItemRequest struct {
+	ProductID   int32         `json:"product_id"`
+	SKU         string        `json:"sku"`
+	ProductName string        `json:"product_name"`
+	Category    string        `json:"category"`
+	Quantity    int32         `json:"quantity"` // defaults to 1
+	UnitPrice   money.Amount  `json:"unit_price"`
+	TaxRate     *money.Amount `json:"tax_rate"`
+
+	linkedProduct sql.NullInt32 // product of a line that is already stored, it is not looked up again
+}
+
+// saleResponse is a sale header together with its lines and, when a single sale is read, its refunds
+type saleResponse struct {
+	internals.Sale
+	Items   []internals.SaleItem `json:"items"`
+	Refunds []internals.Refund   `json:"refunds,omitempty"`
+}
+
+// Helper function to turn the stored lines of a sale back into requests, e.g. to change its currency
+func storedSaleItems(items []internals.SaleItem) []saleItemRequest {
+	requests := make([]saleItemRequest, 0, len(items))
+	for _, item := range items {
+		request := saleItemRequest{
+			ProductName:   item.ProductName,
+			Category:      item.Category,
+			Quantity:      item.Quantity,
+			UnitPrice:     item.UnitPrice,
+			linkedProduct: item.ProductID,
+		}
+		if rate, err := parseTaxRate(item.TaxRate); err == nil {
+			request.TaxRate = &rate
+		}
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+// Helper function to validate the lines of a sale. The sale currency defaults to the currency
+// of the first catalog product and then to PLN, all lines are priced in it.
+func resolveSaleItems(ctx context.Context, currencyCode string, items []saleItemRequest) ([]internals.CreateSaleItemParams, internals.Currency, error) {
+	if len(items) == 0 {
+		return nil, internals.Currency{}, errors.New("Sale needs at least one item")
+	}
+	if len(items) > maxSaleItems {
+		return nil, internals.Currency{}, fmt.Errorf("Sale can have at most %d items", maxSaleItems)
+	}
+
+	// Errors of an order with several lines tell which line is wrong
+	lineError := func(i int, err error) error {
+		if len(items) == 1 {
+			return err
+		}
+		return fmt.Errorf("Item %d: %w", i+1, err)
+	}
+
+	products := make([]*internals.Product, len(items))
+	for i, item := range items {
+		if item.ProductID == 0 && item.SKU == "" {
+			continue
+		}
+		product, err := getSaleProduct(ctx, item.ProductID, item.SKU)
+		if err != nil {
+			return nil, internals.Currency{}, lineError(i, err)
+		}
+		products[i] = &product
+		if currencyCode == "" {
+			currencyCode = product.Currency
+		}
+	}
+
+	if currencyCode == "" {
+		currencyCode = "PLN"
+	}
+	currency, err := getSaleCurrency(ctx, strings.ToUpper(currencyCode))
+	if err != nil {
+		return nil, internals.Currency{}, err
+	}
+
+	lines := make([]internals.CreateSaleItemParams, 0, len(items))
+	var total money.Amount
+	for i, item := range items {
+		line, err := resolveSaleItem(ctx, item, products[i], currency)
+		if err != nil {
+			return nil, internals.Currency{}, lineError(i, err)
+		}
+		lines = append(lines, line)
+		// Every line is at most maxSalePrice, so the sum of maxSaleItems lines cannot overflow
+		total += line.UnitPrice * money.Amount(line.Quantity)
+	}
+	if total > maxSalePrice {
+		return nil, internals.Currency{}, fmt.Errorf("Sale total can be at most %s %s", maxSalePrice, currency.Code)
+	}
+	return lines, currency, nil
+}
+
+// Helper function to validate one line of a sale, product is nil for lines without a catalog product
+func resolveSaleItem(ctx context.Context, item saleItemRequest, product *internals.Product, currency internals.Currency) (internals.CreateSaleItemParams, error) {
+	line := internals.CreateSaleItemParams{
+		ProductID:   item.linkedProduct,
+		ProductName: strings.TrimSpace(item.ProductName),
+		Category:    item.Category,
+		Quantity:    item.Quantity,
+		UnitPrice:   item.UnitPrice,
+	}
+	if product != nil {
+		line.ProductID = sql.NullInt32{Int32: product.ID, Valid: true}
+		if line.ProductName == "" {
+			line.ProductName = product.Name
+		}
+		if line.Category == "" {
+			line.Category = product.Category
+		}
+		if line.UnitPrice == 0 {
+			if product.Currency != currency.Code {
+				return line, fmt.Errorf("Product %s is priced in %s, give its unit_price in %s", product.Sku, product.Currency, currency.Code)
+			}
+			line.UnitPrice = product.Price
+		}
+	}
+	if line.Quantity == 0 {
+		line.Quantity = 1
+	}
+
+	if line.ProductName == "" {
+		return line, errors.New("Product name is required")
+	}
+	if len(line.ProductName) > 255 {
+		return line, errors.New("Product name must be at most 255 characters long")
+	}
+	if line.Category == "" {
+		return line, errors.New("Category is required")
+	}
+	if line.Quantity < 0 {
+		return line, errors.New("Quantity must be greater than 0")
+	}
+	if line.UnitPrice <= 0 {
+		return line, errors.New("Price must be greater than 0")
+	}
+	if !line.UnitPrice.HasDecimals(int(currency.MinorUnits)) {
+		return line, fmt.Errorf("Price in %s can have at most %d decimal places", currency.Code, currency.MinorUnits)
+	}
+	// Divided rather than multiplied, quantity times unit price could overflow
+	if money.Amount(line.Quantity) > maxSalePrice/line.UnitPrice {
+		return line, fmt.Errorf("Quantity times price can be at most %s %s", maxSalePrice, currency.Code)
+	}
+
+	category, err := getSaleCategory(ctx, line.Category)
+	if err != nil {
+		return line, err
+	}
+	line.Category = category.Name
+
+	var rate money.Amount
+	switch {
+	case item.TaxRate != nil:
+		rate = *item.TaxRate
+	case product != nil && product.TaxRate.Valid:
+		rate, err = parseTaxRate(product.TaxRate.String)
+	default:
+		rate, err = parseTaxRate(category.TaxRate)
+	}
+	if err != nil {
+		return line, err
+	}
+	if err := validateTaxRate(rate); err != nil {
+		return line, err
+	}
+	line.TaxRate = rate.String()
+	line.NetAmount, line.TaxAmount = splitGross(line.UnitPrice*money.Amount(line.Quantity), rate)
+	return line, nil
+}
+
+// saleSummary is what the header of a sale repeats from its lines: the charged gross, net, tax
+// and discount totals of the order and the name, category and product of the first line.
+// An order with more lines is named after the first one, e.g. "Laptop Dell XPS 13 (+2 more)".
+type saleSummary struct {
+	ProductName string
+	Category    string
+	ProductID   sql.NullInt32
+	Total       money.Amount
+	NetAmount   money.Amount
+	TaxAmount   money.Amount
+	Discount    money.Amount
+}
+
+func summarizeSaleItems(lines []internals.CreateSaleItemParams) saleSummary {
+	summary := saleSummary{
+		ProductName: lines[0].ProductName,
+		Category:    lines[0].Category,
+		ProductID:   lines[0].ProductID,
+	}
+	if len(lines) > 1 {
+		more := fmt.Sprintf(" (+%d more)", len(lines)-1)
+		if len(summary.ProductName)+len(more) > 255 {
+			summary.ProductName = truncateUTF8(summary.ProductName, 255-len(more))
+		}
+		summary.ProductName += more
+	}
+	for _, line := range lines {
+		summary.Total += line.UnitPrice*money.Amount(line.Quantity) - line.DiscountAmount
+		summary.Discount += line.DiscountAmount
+		summary.NetAmount += line.NetAmount
+		summary.TaxAmount += line.TaxAmount
+	}
+	return summary
+}
+
+// Helper function to shorten s to at most n bytes without cutting a character in half
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := 0
+	for i := range s {
+		if i > n {
+			break
+		}
+		cut = i
+	}
+	return s[:cut]
+}
+
+// Helper function to store the lines of a sale, it runs inside the transaction of the sale
+func createSaleItems(ctx context.Context, q *internals.Queries, saleID int32, lines []internals.CreateSaleItemParams) ([]internals.SaleItem, error) {
+	items := make([]internals.SaleItem, 0, len(lines))
+	for _, line := range lines {
+		line.SaleID = saleID
+		item, err := q.CreateSaleItem(ctx, line)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,272 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var errAdminOnly = errors.New("Admin token required, send it in the X-Admin-Token header")
+
+var (
+	errEmployeeNotDeleted  = errors.New("Employee is not deleted")
+	errSaleNotDeleted      = errors.New("Sale is not deleted")
+	errSaleEmployeeDeleted = errors.New("Employee of the sale is deleted, restore the employee first")
+)
+
+// Admin token expected in the X-Admin-Token header, admin features are disabled while it is empty
+var adminToken string
+
+// How long soft-deleted employees and sales are kept before they are purged
+var purgeRetention time.Duration
+
+// SetAdminToken sets the token that unlocks ?include_deleted=true, restores and purges
+func SetAdminToken(token string) {
+	adminToken = token
+}
+
+// Helper function to check that the request was made by an admin
+func requireAdmin(c echo.Context) error {
+	token := c.Request().Header.Get("X-Admin-Token")
+	if adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+		return errAdminOnly
+	}
+	return nil
+}
+
+// Helper function to read ?include_deleted=, only admins may see soft-deleted rows
+func parseIncludeDeleted(c echo.Context) (bool, error) {
+	includeDeletedStr := c.QueryParam("include_deleted")
+	if includeDeletedStr == "" {
+		return false, nil
+	}
+	includeDeleted, err := strconv.ParseBool(includeDeletedStr)
+	if err != nil {
+		return false, errors.New("Invalid include_deleted format")
+	}
+	if includeDeleted {
+		if err := requireAdmin(c); err != nil {
+			return false, err
+		}
+	}
+	return includeDeleted, nil
+}
+
+// Helper function to answer an error of requireAdmin or parseIncludeDeleted
+func adminError(c echo.Context, err error) error {
+	if errors.Is(err, errAdminOnly) {
+		return c.JSON(http.StatusForbidden, map[string]string{"error": err.Error()})
+	}
+	return c.JSON(400, map[string]string{"error": err.Error()})
+}
+
+// RestoreEmployee brings back a soft-deleted employee. It fails when another employee took the email in the meantime.
+func RestoreEmployee(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if err := requireAdmin(c); err != nil {
+		return adminError(c, err)
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+
+	var restored internals.Employee
+	err = withTx(ctx, func(q *internals.Queries) error {
+		employee, err := q.GetEmployeeIncludingDeletedForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if !employee.DeletedAt.Valid {
+			return errEmployeeNotDeleted
+		}
+		if restored, err = q.RestoreEmployee(ctx, employee.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{
+			Entity: auditEmployee, EntityID: employee.ID, Action: "restore",
+			Before: employee, After: restored,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if errors.Is(err, errEmployeeNotDeleted) {
+		return c.JSON(409, map[string]string{"error": err.Error()})
+	}
+	if isUniqueViolation(err) {
+		return c.JSON(409, map[string]string{"error": "Email already used by another employee"})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to restore employee"})
+	}
+	return c.JSON(http.StatusOK, restored)
+}
+
+// RestoreSale brings back a soft-deleted sale with its items and refunds.
+// The employee of the sale has to be restored first.
+func RestoreSale(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	if err := requireAdmin(c); err != nil {
+		return adminError(c, err)
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid sale ID"})
+	}
+
+	var restored internals.Sale
+	err = withTx(ctx, func(q *internals.Queries) error {
+		sale, err := q.GetSaleIncludingDeletedForUpdate(ctx, int32(id))
+		if err != nil {
+			return err
+		}
+		if !sale.DeletedAt.Valid {
+			return errSaleNotDeleted
+		}
+		if sale.EmployeeID.Valid {
+			// the employee is locked too, so that it cannot be deleted while the sale comes back
+			_, err := q.GetEmployeeForUpdate(ctx, sale.EmployeeID.Int32)
+			if errors.Is(err, sql.ErrNoRows) {
+				return errSaleEmployeeDeleted
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if restored, err = q.RestoreSale(ctx, sale.ID); err != nil {
+			return err
+		}
+		return recordAudit(c, q, auditChange{
+			Entity: auditSale, EntityID: sale.ID, Action: "restore",
+			Before: sale, After: restored,
+		})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return c.JSON(404, map[string]string{"error": "Sale not found"})
+	}
+	if errors.Is(err, errSaleNotDeleted) || errors.Is(err, errSaleEmployeeDeleted) {
+		return c.JSON(409, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to restore sale"})
+	}
+	return c.JSON(http.StatusOK, restored)
+}
+
+type purgeResult struct {
+	DeletedBefore   time.Time `json:"deleted_before"`
+	SalesPurged     int64     `json:"sales_purged"`
+	EmployeesPurged int64     `json:"employees_purged"`
+}
+
+// Helper function to remove for good the employees and sales soft-deleted before the retention period.
+// Sales go first so that the employees they kept are purged in the same run. Every removed row is
+// recorded in the audit log, and so are the employees who lose their manager to the purge.
+func purgeDeleted(ctx context.Context, retention time.Duration, requestID sql.NullString) (purgeResult, error) {
+	result := purgeResult{DeletedBefore: time.Now().Add(-retention)}
+	err := withTx(ctx, func(q *internals.Queries) error {
+		sales, err := q.PurgeSales(ctx, result.DeletedBefore)
+		if err != nil {
+			return err
+		}
+		// manager_id would be cleared by ON DELETE SET NULL, it is done here to audit it
+		unmanaged, err := q.ClearPurgedManagers(ctx, result.DeletedBefore)
+		if err != nil {
+			return err
+		}
+		employees, err := q.PurgeEmployees(ctx, result.DeletedBefore)
+		if err != nil {
+			return err
+		}
+
+		changes := make([]auditChange, 0, len(sales)+len(unmanaged)+len(employees))
+		for _, sale := range sales {
+			changes = append(changes, auditChange{Entity: auditSale, EntityID: sale.ID, Action: "delete", Before: sale})
+		}
+		for _, before := range unmanaged {
+			after, err := q.GetEmployeeIncludingDeleted(ctx, before.ID)
+			if err != nil {
+				return err
+			}
+			changes = append(changes, auditChange{
+				Entity: auditEmployee, EntityID: before.ID, Action: "update",
+				Before: before, After: after,
+			})
+		}
+		for _, employee := range employees {
+			changes = append(changes, auditChange{Entity: auditEmployee, EntityID: employee.ID, Action: "delete", Before: employee})
+		}
+		if err := writeAudit(ctx, q, requestID, auditSystem, changes...); err != nil {
+			return err
+		}
+
+		result.SalesPurged = int64(len(sales))
+		result.EmployeesPurged = int64(len(employees))
+		return nil
+	})
+	return result, err
+}
+
+// StartPurgeJob purges the employees and sales soft-deleted longer than retention,
+// once at start and then every interval until ctx is done
+func StartPurgeJob(ctx context.Context, retention, interval time.Duration) {
+	purgeRetention = retention
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			result, err := purgeDeleted(ctx, retention, sql.NullString{})
+			if err != nil {
+				log.Printf("Unable to purge deleted rows: %v", err)
+			} else if result.SalesPurged > 0 || result.EmployeesPurged > 0 {
+				log.Printf("Purged %d sales and %d employees deleted before %s",
+					result.SalesPurged, result.EmployeesPurged, result.DeletedBefore.Format(time.RFC3339))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// PurgeDeleted runs the purge job right away, e.g. before a backup
+func PurgeDeleted(c echo.Context) error {
+	if err := requireAdmin(c); err != nil {
+		return adminError(c, err)
+	}
+	result, err := purgeDeleted(c.Request().Context(), purgeRetention, auditRequestID(c))
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to purge deleted rows"})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// Helper function to get an employee, soft-deleted ones included when includeDeleted is set
+func getEmployee(ctx context.Context, id int32, includeDeleted bool) (internals.Employee, error) {
+	if includeDeleted {
+		return queries.GetEmployeeIncludingDeleted(ctx, id)
+	}
+	return queries.GetEmployee(ctx, id)
+}
+
+// Helper function to get a sale, soft-deleted ones included when includeDeleted is set
+func getSale(ctx context.Context, id int32, includeDeleted bool) (internals.Sale, error) {
+	if includeDeleted {
+		return queries.GetSaleIncludingDeleted(ctx, id)
+	}
+	return queries.GetSale(ctx, id)
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// employeeStats are the sales statistics of one employee, amounts are in the base currency.
+// Average, median, min and max are null when the employee has no sales in the period.
+type employeeStats struct {
+	EmployeeID      int32         `json:"employee_id"`
+	Name            string        `json:"name"`
+	Surname         string        `json:"surname"`
+	Email           string        `json:"email"`
+	TotalSales      int64         `json:"total_sales"`
+	TotalRevenue    money.Amount  `json:"total_revenue"`
+	TotalDiscount   money.Amount  `json:"total_discount"` // taken off the list prices of the sales
+	TotalRefunded   money.Amount  `json:"total_refunded"` // refunded in the period, also for earlier sales
+	NetRevenue      money.Amount  `json:"net_revenue"`
+	AvgSaleValue    *money.Amount `json:"avg_sale_value"`
+	MedianSaleValue *money.Amount `json:"median_sale_value"`
+	MinSaleValue    *money.Amount `json:"min_sale_value"`
+	MaxSaleValue    *money.Amount `json:"max_sale_value"`
+	FirstSaleDate   *time.Time    `json:"first_sale_date"`
+	LastSaleDate    *time.Time    `json:"last_sale_date"`
+}
+
+type statsResponse struct {
+	Period    string          `json:"period"`
+	From      *time.Time      `json:"from,omitempty"` // omitted for all-time statistics
+	To        *time.Time      `json:"to,omitempty"`   // exclusive
+	Category  string          `json:"category,omitempty"`
+	Scope     string          `json:"scope,omitempty"` // department or manager the statistics are limited to
+	Currency  string          `json:"currency"`
+	Employee  *employeeStats  `json:"employee,omitempty"`
+	Employees []employeeStats `json:"employees,omitempty"`
+}
+
+// GetEmployeesStats answers /employees/stats with the statistics of every employee,
+// ordered by revenue. See parseStatsPeriod for the period parameters, ?category= narrows the sales
+// and ?department_id= or ?manager_id= the employees.
+func GetEmployeesStats(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	period, err := parseStatsPeriod(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	scope, err := parseOrgScope(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err := checkBaseRates(ctx, period); errors.Is(err, errMissingBaseRates) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	} else if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get exchange rates"})
+	}
+
+	response := newStatsResponse(c, period)
+	response.Scope = scope.Label
+	stats, err := getEmployeeStats(ctx, period, response.Category, sql.NullInt32{}, scope)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales statistics"})
+	}
+	response.Employees = stats
+	return c.JSON(200, response)
+}
+
+// GetEmployeeStats answers /employee/:id/stats with the statistics of a single employee
+func GetEmployeeStats(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Employee ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+
+	period, err := parseStatsPeriod(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	employee, err := queries.GetEmployee(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+	if err := checkBaseRates(ctx, period); errors.Is(err, errMissingBaseRates) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	} else if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get exchange rates"})
+	}
+
+	response := newStatsResponse(c, period)
+	stats, err := getEmployeeStats(ctx, period, response.Category, sql.NullInt32{Int32: employee.ID, Valid: true}, orgScope{})
+	if err != nil || len(stats) == 0 {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales statistics"})
+	}
+	response.Employee = &stats[0]
+	return c.JSON(200, response)
+}
+
+// Helper function to read the statistics period. It is either
+// ?period=month|quarter|year with year and month/quarter, from and to for any range of days,
+// or all time when no period is given.
+func parseStatsPeriod(c echo.Context) (reportPeriod, error) {
+	kind := c.QueryParam("period")
+	if kind == "" && (c.QueryParam("from") != "" || c.QueryParam("to") != "") {
+		kind = "range"
+	}
+	switch kind {
+	case "":
+		return reportPeriod{
+			Title: "Sales statistics",
+			Label: "All time",
+			To:    time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1),
+		}, nil
+	case "month", "quarter", "year", "range":
+		return parseReportPeriod(c, kind)
+	default:
+		return reportPeriod{}, errors.New("Invalid period (month, quarter, year, range)")
+	}
+}
+
+func newStatsResponse(c echo.Context, period reportPeriod) statsResponse {
+	response := statsResponse{
+		Period:   period.Label,
+		Category: c.QueryParam("category"),
+		Currency: rates.Base(),
+	}
+	if !period.From.IsZero() {
+		response.From = &period.From
+		response.To = &period.To
+	}
+	return response
+}
+
+// Helper function to query and convert the statistics of all employees of the scope or the given one
+func getEmployeeStats(ctx context.Context, period reportPeriod, category string, employeeID sql.NullInt32, scope orgScope) ([]employeeStats, error) {
+	params := internals.GetEmployeeSalesStatsParams{
+		BaseCurrency: rates.Base(),
+		FromDate:     period.From,
+		ToDate:       period.To,
+		EmployeeID:   employeeID,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+	}
+	if category != "" {
+		params.Category = sql.NullString{String: category, Valid: true}
+	}
+
+	rows, err := queries.GetEmployeeSalesStats(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]employeeStats, 0, len(rows))
+	for _, row := range rows {
+		revenue, err := money.Parse(row.TotalRevenue)
+		if err != nil {
+			return nil, err
+		}
+		discount, err := money.Parse(row.TotalDiscount)
+		if err != nil {
+			return nil, err
+		}
+		refunded, err := money.Parse(row.TotalRefunded)
+		if err != nil {
+			return nil, err
+		}
+		item := employeeStats{
+			EmployeeID:    row.ID,
+			Name:          row.Name,
+			Surname:       row.Surname,
+			Email:         row.Email,
+			TotalSales:    row.TotalSales,
+			TotalRevenue:  revenue,
+			TotalDiscount: discount,
+			TotalRefunded: refunded,
+			NetRevenue:    revenue - refunded,
+		}
+		if item.AvgSaleValue, err = parseNullAmount(row.AvgSaleValue); err != nil {
+			return nil, err
+		}
+		if item.MedianSaleValue, err = parseNullAmount(row.MedianSaleValue); err != nil {
+			return nil, err
+		}
+		if item.MinSaleValue, err = parseNullAmount(row.MinSaleValue); err != nil {
+			return nil, err
+		}
+		if item.MaxSaleValue, err = parseNullAmount(row.MaxSaleValue); err != nil {
+			return nil, err
+		}
+		if row.FirstSaleDate.Valid {
+			item.FirstSaleDate = &row.FirstSaleDate.Time
+		}
+		if row.LastSaleDate.Valid {
+			item.LastSaleDate = &row.LastSaleDate.Time
+		}
+		stats = append(stats, item)
+	}
+	return stats, nil
+}
+
+// Helper function to parse an aggregate that is NULL when there were no sales
+func parseNullAmount(value sql.NullString) (*money.Amount, error) {
+	if !value.Valid {
+		return nil, nil
+	}
+	amount, err := money.Parse(value.String)
+	if err != nil {
+		return nil, err
+	}
+	return &amount, nil
+}
@@ -0,0 +1,314 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/phpdave11/gofpdf"
+)
+
+// targetAttainment compares the net revenue of a target period, converted to the
+// target currency at the rates of its last day, with the target amount
+type targetAttainment struct {
+	Target   money.Amount  `json:"target"`
+	Currency string        `json:"currency"`
+	Achieved money.Amount  `json:"achieved"`
+	Percent  float64       `json:"attainment_percent"`
+	Gap      money.Amount  `json:"gap_to_target"` // 0 once the target is reached
+	Rates    []appliedRate `json:"rates,omitempty"`
+
+	MissingRate string `json:"-"` // set when the net revenue could not be converted, only the target is known
+}
+
+// targetResponse is a target together with how far it has been reached
+type targetResponse struct {
+	internals.SalesTarget
+	Attainment *targetAttainment `json:"attainment"`
+}
+
+// Helper function to get the report period of a target
+func targetPeriod(target internals.SalesTarget) reportPeriod {
+	start := target.PeriodStart
+	if target.PeriodType == "quarter" {
+		return quarterlyPeriod(start.Year(), (int(start.Month())-1)/3+1)
+	}
+	return monthlyPeriod(start.Year(), int(start.Month()))
+}
+
+// Helper function to sum the sales and refunds of an employee per currency in a period
+func employeeTotals(ctx context.Context, employeeID int32, period reportPeriod) ([]currencyTotal, error) {
+	rows, err := queries.GetEmployeeRevenueByCurrency(ctx, internals.GetEmployeeRevenueByCurrencyParams{
+		EmployeeID: employeeID,
+		FromDate:   period.From,
+		ToDate:     period.To,
+	})
+	if err != nil {
+		return nil, err
+	}
+	totals := make([]currencyTotal, 0, len(rows))
+	for _, row := range rows {
+		total := currencyTotal{Currency: row.Currency, TotalSales: row.TotalSales}
+		if total.TotalRevenue, err = money.Parse(row.TotalRevenue); err != nil {
+			return nil, err
+		}
+		if total.TotalRefunded, err = money.Parse(row.TotalRefunded); err != nil {
+			return nil, err
+		}
+		total.NetRevenue = total.TotalRevenue - total.TotalRefunded
+		totals = append(totals, total)
+	}
+	return totals, nil
+}
+
+// Helper function to measure a target against the per-currency totals of its period.
+// given are exchange rates that take precedence over the exchange_rates table, it may be nil.
+func attainTarget(ctx context.Context, target internals.SalesTarget, totals []currencyTotal, given map[string]float64) (*targetAttainment, error) {
+	conversion := &reportConversion{Currency: target.Currency, RateDate: targetPeriod(target).rateDate(), given: given}
+	if err := conversion.convert(ctx, totals); err != nil {
+		return nil, err
+	}
+
+	attainment := &targetAttainment{
+		Target:   target.Amount,
+		Currency: target.Currency,
+		Achieved: conversion.Total,
+		Percent:  math.Round(float64(conversion.Total)*1000/float64(target.Amount)) / 10,
+		Rates:    conversion.Rates,
+	}
+	if conversion.Total < target.Amount {
+		attainment.Gap = target.Amount - conversion.Total
+	}
+	return attainment, nil
+}
+
+// Helper function to write the target of the period and how far it has been reached
+func writeTargetAttainment(pdf *gofpdf.Fpdf, attainment *targetAttainment, formats currencyFormats) {
+	if attainment == nil {
+		return
+	}
+
+	pdf.SetFont(reportFont, "B", 12)
+	pdf.Cell(0, 10, "Sales target: "+formats.format(attainment.Target, attainment.Currency))
+	pdf.Ln(5)
+	pdf.SetFont(reportFont, "", 12)
+	if attainment.MissingRate != "" {
+		pdf.Cell(0, 10, "Achieved: not available, missing exchange rate: "+attainment.MissingRate)
+		pdf.Ln(10)
+		return
+	}
+	pdf.Cell(0, 10, fmt.Sprintf("Achieved: %s (%.1f%%)", formats.format(attainment.Achieved, attainment.Currency), attainment.Percent))
+	pdf.Ln(5)
+	if attainment.Gap > 0 {
+		pdf.Cell(0, 10, "Gap to target: "+formats.format(attainment.Gap, attainment.Currency))
+	} else {
+		pdf.Cell(0, 10, "Target exceeded by: "+formats.format(attainment.Achieved-attainment.Target, attainment.Currency))
+	}
+	pdf.Ln(5)
+
+	pdf.SetFont(reportFont, "", 9)
+	for _, rate := range attainment.Rates {
+		pdf.Cell(0, 10, fmt.Sprintf("Exchange rate: 1 %s = %.4f %s (%s)",
+			rate.Currency, rate.Rate, attainment.Currency, rate.RateDate.Format("2006-01-02")))
+		pdf.Ln(5)
+	}
+	pdf.SetFont(reportFont, "", 12)
+	pdf.Ln(5)
+}
+
+// SetSalesTarget sets the monthly or quarterly target of an employee, from JSON or e.g.
+// ?period=month&year=2025&month=1&amount=50000&currency=PLN. An existing target of the period is replaced.
+func SetSalesTarget(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid employee ID"})
+	}
+
+	type SetSalesTargetRequest struct {
+		Period   string       `json:"period"` // month or quarter
+		Year     int          `json:"year"`
+		Month    int          `json:"month"`
+		Quarter  int          `json:"quarter"`
+		Amount   money.Amount `json:"amount"`
+		Currency string       `json:"currency"`
+	}
+
+	var req SetSalesTargetRequest
+	err = c.Bind(&req)
+	if errors.Is(err, money.ErrInvalidAmount) {
+		return c.JSON(400, map[string]string{"error": "Invalid amount format. Use at most 2 decimal places"})
+	}
+	for name, field := range map[string]*int{"year": &req.Year, "month": &req.Month, "quarter": &req.Quarter} {
+		if value := c.QueryParam(name); value != "" {
+			if *field, err = strconv.Atoi(value); err != nil {
+				return c.JSON(400, map[string]string{"error": fmt.Sprintf("Invalid %s", name)})
+			}
+		}
+	}
+	if period := c.QueryParam("period"); period != "" {
+		req.Period = period
+	}
+	if amountStr := c.QueryParam("amount"); amountStr != "" {
+		amount, err := money.Parse(amountStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid amount format. Use at most 2 decimal places"})
+		}
+		req.Amount = amount
+	}
+	if currency := c.QueryParam("currency"); currency != "" {
+		req.Currency = currency
+	}
+
+	var period reportPeriod
+	switch req.Period {
+	case "month":
+		if req.Month < 1 || req.Month > 12 {
+			return c.JSON(400, map[string]string{"error": "Invalid month (1-12)"})
+		}
+		period = monthlyPeriod(req.Year, req.Month)
+	case "quarter":
+		if req.Quarter < 1 || req.Quarter > 4 {
+			return c.JSON(400, map[string]string{"error": "Invalid quarter (1-4)"})
+		}
+		period = quarterlyPeriod(req.Year, req.Quarter)
+	default:
+		return c.JSON(400, map[string]string{"error": "period must be month or quarter"})
+	}
+	if req.Year < 2000 || req.Year > time.Now().Year()+1 {
+		return c.JSON(400, map[string]string{"error": "Invalid year"})
+	}
+	if req.Amount <= 0 {
+		return c.JSON(400, map[string]string{"error": "Amount must be greater than 0"})
+	}
+	if req.Currency == "" {
+		req.Currency = "PLN"
+	}
+	currency, err := getSaleCurrency(ctx, strings.ToUpper(req.Currency))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if !req.Amount.HasDecimals(int(currency.MinorUnits)) {
+		return c.JSON(400, map[string]string{"error": fmt.Sprintf("Amount in %s can have at most %d decimal places", currency.Code, currency.MinorUnits)})
+	}
+
+	if _, err := queries.GetEmployee(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Employee not found"})
+	}
+
+	target, err := queries.SetSalesTarget(ctx, internals.SetSalesTargetParams{
+		EmployeeID:  int32(id),
+		PeriodType:  req.Period,
+		PeriodStart: period.From,
+		Amount:      req.Amount,
+		Currency:    currency.Code,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to set sales target"})
+	}
+	return c.JSON(http.StatusOK, target)
+}
+
+// GetAllSalesTargets lists targets with their attainment so far,
+// optionally filtered with ?employee_id=, ?period=month|quarter and ?year=
+func GetAllSalesTargets(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params internals.ListSalesTargetsParams
+	if employeeIDStr := c.QueryParam("employee_id"); employeeIDStr != "" {
+		employeeID, err := strconv.ParseInt(employeeIDStr, 10, 32)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid employee_id format"})
+		}
+		params.EmployeeID = sql.NullInt32{Int32: int32(employeeID), Valid: true}
+	}
+	if period := c.QueryParam("period"); period != "" {
+		if period != "month" && period != "quarter" {
+			return c.JSON(400, map[string]string{"error": "period must be month or quarter"})
+		}
+		params.PeriodType = sql.NullString{String: period, Valid: true}
+	}
+	if yearStr := c.QueryParam("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "Invalid year"})
+		}
+		from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		params.FromDate = sql.NullTime{Time: from, Valid: true}
+		params.ToDate = sql.NullTime{Time: from.AddDate(1, 0, 0), Valid: true}
+	}
+
+	targets, err := queries.ListSalesTargets(ctx, params)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales targets"})
+	}
+
+	response := make([]targetResponse, 0, len(targets))
+	for _, target := range targets {
+		totals, err := employeeTotals(ctx, target.EmployeeID, targetPeriod(target))
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+		}
+		attainment, err := attainTarget(ctx, target, totals, nil)
+		if err != nil {
+			return conversionError(c, err)
+		}
+		response = append(response, targetResponse{SalesTarget: target, Attainment: attainment})
+	}
+	return c.JSON(200, response)
+}
+
+func GetSalesTarget(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.QueryParam("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Target ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	target, err := queries.GetSalesTarget(ctx, int32(id))
+	if err != nil {
+		return c.JSON(404, map[string]string{"error": "Target not found"})
+	}
+
+	totals, err := employeeTotals(ctx, target.EmployeeID, targetPeriod(target))
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+	}
+	attainment, err := attainTarget(ctx, target, totals, nil)
+	if err != nil {
+		return conversionError(c, err)
+	}
+	return c.JSON(http.StatusOK, targetResponse{SalesTarget: target, Attainment: attainment})
+}
+
+func DeleteSalesTarget(c echo.Context) error {
+	ctx := c.Request().Context()
+	idStr := c.Param("id")
+	if idStr == "" {
+		return c.JSON(400, map[string]string{"error": "Target ID is required"})
+	}
+	id, err := strconv.ParseInt(idStr, 10, 32)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "Invalid ID format"})
+	}
+	if _, err := queries.GetSalesTarget(ctx, int32(id)); err != nil {
+		return c.JSON(404, map[string]string{"error": "Target not found"})
+	}
+	if err := queries.DeleteSalesTarget(ctx, int32(id)); err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to delete sales target"})
+	}
+	return c.JSON(200, map[string]string{"message": "Sales target deleted successfully"})
+}
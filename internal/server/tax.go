@@ -0,0 +1,122 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/phpdave11/gofpdf"
+)
+
+// Tax rates are percentages with up to 2 decimal places. They are handled as money.Amount,
+// i.e. in hundredths of a percent, so that 2300 is 23%.
+const defaultTaxRate money.Amount = 2300 // standard Polish VAT rate of new categories
+
+// taxBreakdown is the net, tax and gross revenue at one tax rate in one currency
+type taxBreakdown struct {
+	Currency    string       `json:"currency"`
+	TaxRate     string       `json:"tax_rate"`
+	NetAmount   money.Amount `json:"net_amount"`
+	TaxAmount   money.Amount `json:"tax_amount"`
+	GrossAmount money.Amount `json:"gross_amount"`
+}
+
+// Helper function to read a tax rate stored as NUMERIC(5,2)
+func parseTaxRate(s string) (money.Amount, error) {
+	rate, err := money.Parse(s)
+	if err != nil {
+		return 0, err
+	}
+	return rate, validateTaxRate(rate)
+}
+
+func validateTaxRate(rate money.Amount) error {
+	if rate < 0 || rate > 10000 {
+		return errors.New("Tax rate must be between 0 and 100")
+	}
+	return nil
+}
+
+// Helper function to split a gross amount into its net amount and tax, e.g. 123.00 at 23% is 100.00 + 23.00.
+// The net amount is rounded half away from zero and the tax is the rest, so both always add up to gross.
+func splitGross(gross, rate money.Amount) (net, tax money.Amount) {
+	numerator := int64(gross) * 10000
+	denominator := 10000 + int64(rate)
+	if numerator >= 0 {
+		net = money.Amount((2*numerator + denominator) / (2 * denominator))
+	} else {
+		net = -money.Amount((-2*numerator + denominator) / (2 * denominator))
+	}
+	return net, gross - net
+}
+
+// Helper function to sum the lines of the period per currency and tax rate, for all employees
+// of the scope or the given one
+func getTaxBreakdown(ctx context.Context, period reportPeriod, employeeID sql.NullInt32, scope orgScope) ([]taxBreakdown, error) {
+	rows, err := queries.GetTaxBreakdown(ctx, internals.GetTaxBreakdownParams{
+		FromDate:     period.From,
+		ToDate:       period.To,
+		EmployeeID:   employeeID,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	breakdown := make([]taxBreakdown, 0, len(rows))
+	for _, row := range rows {
+		item := taxBreakdown{Currency: row.Currency, TaxRate: row.TaxRate}
+		if item.NetAmount, err = money.Parse(row.NetAmount); err != nil {
+			return nil, err
+		}
+		if item.TaxAmount, err = money.Parse(row.TaxAmount); err != nil {
+			return nil, err
+		}
+		if item.GrossAmount, err = money.Parse(row.GrossAmount); err != nil {
+			return nil, err
+		}
+		breakdown = append(breakdown, item)
+	}
+	return breakdown, nil
+}
+
+// Helper function to write the tax breakdown table of a report
+func writeTaxBreakdown(pdf *gofpdf.Fpdf, breakdown []taxBreakdown, formats currencyFormats) {
+	if len(breakdown) == 0 {
+		return
+	}
+
+	pdf.SetFont(reportFont, "B", 10)
+	pdf.Cell(25, 10, "Currency")
+	pdf.Cell(25, 10, "VAT rate")
+	pdf.Cell(40, 10, "Net")
+	pdf.Cell(40, 10, "VAT")
+	pdf.Cell(40, 10, "Gross")
+	pdf.Ln(10)
+
+	pdf.SetFont(reportFont, "", 9)
+	for _, row := range breakdown {
+		pdf.Cell(25, 8, row.Currency)
+		pdf.Cell(25, 8, formatTaxRate(row.TaxRate))
+		pdf.Cell(40, 8, formats.format(row.NetAmount, row.Currency))
+		pdf.Cell(40, 8, formats.format(row.TaxAmount, row.Currency))
+		pdf.Cell(40, 8, formats.format(row.GrossAmount, row.Currency))
+		pdf.Ln(8)
+	}
+	pdf.Ln(5)
+}
+
+// Helper function to print a stored tax rate without needless decimals, e.g. "23.00" as "23%"
+func formatTaxRate(s string) string {
+	rate, err := money.Parse(s)
+	if err != nil {
+		return s + "%"
+	}
+	if rate.HasDecimals(0) {
+		return rate.StringFixed(0) + "%"
+	}
+	return rate.String() + "%"
+}
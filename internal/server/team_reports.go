@@ -0,0 +1,374 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// teamReportEmployee is ranked by net revenue, i.e. revenue less the refunds of the period
+type teamReportEmployee struct {
+	Rank          int64        `json:"rank"`
+	EmployeeID    int32        `json:"employee_id"`
+	Name          string       `json:"name"`
+	Surname       string       `json:"surname"`
+	Email         string       `json:"email"`
+	TotalSales    int64        `json:"total_sales"`
+	TotalRevenue  money.Amount `json:"total_revenue"`
+	TotalRefunded money.Amount `json:"total_refunded"`
+	NetRevenue    money.Amount `json:"net_revenue"`
+	AvgSaleValue  money.Amount `json:"avg_sale_value"`
+}
+
+type teamReportCategory struct {
+	Category     string       `json:"category"`
+	TotalSales   int64        `json:"total_sales"`
+	TotalRevenue money.Amount `json:"total_revenue"`
+	Share        float64      `json:"share"` // percent of the period's total revenue
+}
+
+// teamReportProduct is a catalog product, or an ad-hoc product name for sales without one
+type teamReportProduct struct {
+	Rank          int          `json:"rank"`
+	ProductID     *int32       `json:"product_id"`
+	Sku           string       `json:"sku,omitempty"`
+	ProductName   string       `json:"product_name"`
+	TotalSales    int64        `json:"total_sales"`
+	TotalQuantity int64        `json:"total_quantity"`
+	TotalRevenue  money.Amount `json:"total_revenue"`
+}
+
+type teamReport struct {
+	Period       string               `json:"period"`
+	Scope        string               `json:"scope,omitempty"` // department or manager the report is limited to
+	From         time.Time            `json:"from"`
+	To           time.Time            `json:"to"`
+	BaseCurrency string               `json:"base_currency"` // currency of employee and category revenue
+	TotalSales   int64                `json:"total_sales"`
+	Totals       []currencyTotal      `json:"totals"`
+	Conversion   *reportConversion    `json:"converted_total,omitempty"`
+	Employees    []teamReportEmployee `json:"employees"`
+	Categories   []teamReportCategory `json:"categories"`
+	TopProducts  []teamReportProduct  `json:"top_products"`
+	Taxes        []taxBreakdown       `json:"tax_breakdown"`
+}
+
+// topProductsLimit is the number of best selling products listed in team reports
+const topProductsLimit = 10
+
+func GenerateTeamMonthlyReport(c echo.Context) error {
+	return generateTeamReport(c, "month")
+}
+
+func GenerateTeamQuarterlyReport(c echo.Context) error {
+	return generateTeamReport(c, "quarter")
+}
+
+func GenerateTeamYearlyReport(c echo.Context) error {
+	return generateTeamReport(c, "year")
+}
+
+func GenerateTeamRangeReport(c echo.Context) error {
+	return generateTeamReport(c, "range")
+}
+
+// generateTeamReport answers with a PDF by default, or with JSON for ?format=json.
+// ?department_id= or ?manager_id= limit the report to a department or a manager's subtree.
+func generateTeamReport(c echo.Context, kind string) error {
+	format := c.QueryParam("format")
+	if format != "" && format != "pdf" && format != "json" {
+		return c.JSON(400, map[string]string{"error": "Invalid format (pdf, json)"})
+	}
+
+	period, err := parseReportPeriod(c, kind)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	conversion, err := parseReportConversion(c, period)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	scope, err := parseOrgScope(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	// Rankings and category shares are computed in the base currency
+	if err := checkBaseRates(ctx, period); errors.Is(err, errMissingBaseRates) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	} else if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get exchange rates"})
+	}
+
+	report, err := buildTeamReport(ctx, period, scope)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+	}
+	if conversion != nil {
+		if err := conversion.convert(ctx, report.Totals); err != nil {
+			return conversionError(c, err)
+		}
+		report.Conversion = conversion
+	}
+
+	if format == "json" {
+		return c.JSON(200, report)
+	}
+
+	formats, err := loadCurrencyFormats(ctx)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get currencies"})
+	}
+	pdf := generateTeamReportPDF(report, period, formats)
+	return streamPDF(c, fmt.Sprintf("raport_team_%s.pdf", period.FileName), pdf)
+}
+
+func buildTeamReport(ctx context.Context, period reportPeriod, scope orgScope) (teamReport, error) {
+	stats, err := queries.GetSalesStatsByEmployee(ctx, internals.GetSalesStatsByEmployeeParams{
+		BaseCurrency: rates.Base(),
+		FromDate:     period.From,
+		ToDate:       period.To,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+	})
+	if err != nil {
+		return teamReport{}, err
+	}
+	categories, err := queries.GetRevenueByCategory(ctx, internals.GetRevenueByCategoryParams{
+		BaseCurrency: rates.Base(),
+		FromDate:     period.From,
+		ToDate:       period.To,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+	})
+	if err != nil {
+		return teamReport{}, err
+	}
+	currencies, err := queries.GetRevenueByCurrency(ctx, internals.GetRevenueByCurrencyParams{
+		FromDate:     period.From,
+		ToDate:       period.To,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+	})
+	if err != nil {
+		return teamReport{}, err
+	}
+	products, err := queries.GetTopProducts(ctx, internals.GetTopProductsParams{
+		BaseCurrency: rates.Base(),
+		FromDate:     period.From,
+		ToDate:       period.To,
+		DepartmentID: scope.DepartmentID,
+		ManagerID:    scope.ManagerID,
+		ProductLimit: topProductsLimit,
+	})
+	if err != nil {
+		return teamReport{}, err
+	}
+	taxes, err := getTaxBreakdown(ctx, period, sql.NullInt32{}, scope)
+	if err != nil {
+		return teamReport{}, err
+	}
+
+	report := teamReport{
+		Period:       period.Label,
+		Scope:        scope.Label,
+		BaseCurrency: rates.Base(),
+		From:         period.From,
+		To:           period.To,
+		Totals:       make([]currencyTotal, 0, len(currencies)),
+		Employees:    make([]teamReportEmployee, 0, len(stats)),
+		Categories:   make([]teamReportCategory, 0, len(categories)),
+		TopProducts:  make([]teamReportProduct, 0, len(products)),
+		Taxes:        taxes,
+	}
+
+	// Aggregates come back as NUMERIC text, parse them exactly
+	for _, row := range currencies {
+		revenue, err := money.Parse(row.TotalRevenue)
+		if err != nil {
+			return teamReport{}, err
+		}
+		discount, err := money.Parse(row.TotalDiscount)
+		if err != nil {
+			return teamReport{}, err
+		}
+		refunded, err := money.Parse(row.TotalRefunded)
+		if err != nil {
+			return teamReport{}, err
+		}
+		report.TotalSales += row.TotalSales
+		report.Totals = append(report.Totals, currencyTotal{
+			Currency:      row.Currency,
+			TotalSales:    row.TotalSales,
+			TotalRevenue:  revenue,
+			TotalDiscount: discount,
+			TotalRefunded: refunded,
+			NetRevenue:    revenue - refunded,
+		})
+	}
+
+	for _, row := range stats {
+		revenue, err := money.Parse(row.TotalRevenue)
+		if err != nil {
+			return teamReport{}, err
+		}
+		refunded, err := money.Parse(row.TotalRefunded)
+		if err != nil {
+			return teamReport{}, err
+		}
+		average, err := money.Parse(row.AvgSaleValue)
+		if err != nil {
+			return teamReport{}, err
+		}
+		report.Employees = append(report.Employees, teamReportEmployee{
+			Rank:          row.Rank,
+			EmployeeID:    row.ID,
+			Name:          row.Name,
+			Surname:       row.Surname,
+			Email:         row.Email,
+			TotalSales:    row.TotalSales,
+			TotalRevenue:  revenue,
+			TotalRefunded: refunded,
+			NetRevenue:    revenue - refunded,
+			AvgSaleValue:  average,
+		})
+	}
+	categoryRevenue := make([]money.Amount, len(categories))
+	var totalRevenue money.Amount
+	for i, row := range categories {
+		revenue, err := money.Parse(row.TotalRevenue)
+		if err != nil {
+			return teamReport{}, err
+		}
+		categoryRevenue[i] = revenue
+		totalRevenue += revenue
+	}
+	for i, row := range categories {
+		var share float64
+		if totalRevenue > 0 {
+			share = categoryRevenue[i].Float64() / totalRevenue.Float64() * 100
+		}
+		report.Categories = append(report.Categories, teamReportCategory{
+			Category:     row.Category,
+			TotalSales:   row.TotalSales,
+			TotalRevenue: categoryRevenue[i],
+			Share:        share,
+		})
+	}
+
+	for i, row := range products {
+		revenue, err := money.Parse(row.TotalRevenue)
+		if err != nil {
+			return teamReport{}, err
+		}
+		product := teamReportProduct{
+			Rank:          i + 1,
+			Sku:           row.Sku,
+			ProductName:   row.ProductName,
+			TotalSales:    row.TotalSales,
+			TotalQuantity: row.TotalQuantity,
+			TotalRevenue:  revenue,
+		}
+		if row.ProductID.Valid {
+			product.ProductID = &row.ProductID.Int32
+		}
+		report.TopProducts = append(report.TopProducts, product)
+	}
+
+	return report, nil
+}
+
+func generateTeamReportPDF(report teamReport, period reportPeriod, formats currencyFormats) *bytes.Buffer {
+	scope := "All employees"
+	if report.Scope != "" {
+		scope = report.Scope
+	}
+	pdf := newReportPDF(fmt.Sprintf("%s - %s", period.Title, scope), period)
+
+	// Statistics
+	pdf.Cell(0, 10, fmt.Sprintf("Number of sales: %d", report.TotalSales))
+	pdf.Ln(5)
+	writeCurrencyTotals(pdf, report.Totals, report.Conversion, formats)
+	writeTaxBreakdown(pdf, report.Taxes, formats)
+
+	// Employee ranking table
+	pdf.SetFont(reportFont, "B", 10)
+	pdf.Cell(12, 10, "Rank")
+	pdf.Cell(48, 10, "Employee")
+	pdf.Cell(15, 10, "Sales")
+	pdf.Cell(30, 10, "Revenue ("+report.BaseCurrency+")")
+	pdf.Cell(25, 10, "Refunds")
+	pdf.Cell(30, 10, "Net revenue")
+	pdf.Cell(30, 10, "Average sale")
+	pdf.Ln(10)
+
+	pdf.SetFont(reportFont, "", 9)
+	for _, employee := range report.Employees {
+		pdf.Cell(12, 8, strconv.FormatInt(employee.Rank, 10))
+		pdf.Cell(48, 8, employee.Name+" "+employee.Surname)
+		pdf.Cell(15, 8, strconv.FormatInt(employee.TotalSales, 10))
+		pdf.Cell(30, 8, formats.format(employee.TotalRevenue, report.BaseCurrency))
+		pdf.Cell(25, 8, formats.format(employee.TotalRefunded, report.BaseCurrency))
+		pdf.Cell(30, 8, formats.format(employee.NetRevenue, report.BaseCurrency))
+		pdf.Cell(30, 8, formats.format(employee.AvgSaleValue, report.BaseCurrency))
+		pdf.Ln(8)
+	}
+	pdf.Ln(5)
+
+	// Revenue by category table
+	if len(report.Categories) > 0 {
+		pdf.SetFont(reportFont, "B", 10)
+		pdf.Cell(60, 10, "Category")
+		pdf.Cell(25, 10, "Sales")
+		pdf.Cell(35, 10, "Revenue ("+report.BaseCurrency+")")
+		pdf.Cell(25, 10, "Share")
+		pdf.Ln(10)
+
+		pdf.SetFont(reportFont, "", 9)
+		for _, category := range report.Categories {
+			pdf.Cell(60, 8, category.Category)
+			pdf.Cell(25, 8, strconv.FormatInt(category.TotalSales, 10))
+			pdf.Cell(35, 8, formats.format(category.TotalRevenue, report.BaseCurrency))
+			pdf.Cell(25, 8, fmt.Sprintf("%.1f%%", category.Share))
+			pdf.Ln(8)
+		}
+		pdf.Ln(5)
+	}
+
+	// Top products table
+	if len(report.TopProducts) > 0 {
+		pdf.SetFont(reportFont, "B", 10)
+		pdf.Cell(15, 10, "Rank")
+		pdf.Cell(30, 10, "SKU")
+		pdf.Cell(60, 10, "Product")
+		pdf.Cell(20, 10, "Sales")
+		pdf.Cell(20, 10, "Quantity")
+		pdf.Cell(35, 10, "Revenue ("+report.BaseCurrency+")")
+		pdf.Ln(10)
+
+		pdf.SetFont(reportFont, "", 9)
+		for _, product := range report.TopProducts {
+			pdf.Cell(15, 8, strconv.Itoa(product.Rank))
+			pdf.Cell(30, 8, product.Sku)
+			pdf.Cell(60, 8, product.ProductName)
+			pdf.Cell(20, 8, strconv.FormatInt(product.TotalSales, 10))
+			pdf.Cell(20, 8, strconv.FormatInt(product.TotalQuantity, 10))
+			pdf.Cell(35, 8, formats.format(product.TotalRevenue, report.BaseCurrency))
+			pdf.Ln(8)
+		}
+	}
+
+	return outputPDF(pdf)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	internals "WorkRESTAPI/internal"
+	"WorkRESTAPI/internal/money"
+	"errors"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// maxTimeseriesBuckets keeps a daily series over many years from flooding the response
+const maxTimeseriesBuckets = 1000
+
+type timeseriesPoint struct {
+	BucketStart  time.Time    `json:"bucket_start"`
+	TotalSales   int64        `json:"total_sales"`
+	TotalRevenue money.Amount `json:"total_revenue"`
+}
+
+// timeseriesSeries is one line of the chart: all sales, or the sales of one employee, department, category or currency
+type timeseriesSeries struct {
+	Key      string            `json:"key,omitempty"`
+	Label    string            `json:"label,omitempty"`
+	Currency string            `json:"currency"`
+	Points   []timeseriesPoint `json:"points"`
+}
+
+type timeseriesResponse struct {
+	Interval string             `json:"interval"`
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"` // exclusive
+	GroupBy  string             `json:"group_by,omitempty"`
+	Scope    string             `json:"scope,omitempty"` // department or manager the series are limited to
+	Series   []timeseriesSeries `json:"series"`
+}
+
+// GetSalesTimeseries answers /sales/timeseries?interval=day|week|month|quarter&from=2025-01-01&to=2025-06-30
+// with the number of sales and revenue per bucket, buckets without sales are included with zeros.
+// ?group_by=employee|department|category|currency splits the series, ?department_id= or ?manager_id=
+// limit the sales to a department or a manager's subtree. Revenue is in the base currency,
+// except when grouped by currency, where each series keeps its own currency.
+func GetSalesTimeseries(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	interval := c.QueryParam("interval")
+	if interval == "" {
+		interval = "month"
+	}
+	if interval != "day" && interval != "week" && interval != "month" && interval != "quarter" {
+		return c.JSON(400, map[string]string{"error": "Invalid interval (day, week, month, quarter)"})
+	}
+
+	groupBy := c.QueryParam("group_by")
+	if groupBy != "" && groupBy != "employee" && groupBy != "department" && groupBy != "category" && groupBy != "currency" {
+		return c.JSON(400, map[string]string{"error": "Invalid group_by (employee, department, category, currency)"})
+	}
+
+	scope, err := parseOrgScope(c)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	period, err := parseReportPeriod(c, "range")
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if timeseriesBuckets(period, interval) > maxTimeseriesBuckets {
+		return c.JSON(400, map[string]string{"error": "Too many buckets, use a shorter range or a longer interval"})
+	}
+
+	if groupBy != "currency" {
+		if err := checkBaseRates(ctx, period); errors.Is(err, errMissingBaseRates) {
+			return c.JSON(400, map[string]string{"error": err.Error()})
+		} else if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to get exchange rates"})
+		}
+	}
+
+	rows, err := queries.GetSalesTimeseries(ctx, internals.GetSalesTimeseriesParams{
+		BucketInterval: interval,
+		FromDate:       period.From,
+		ToDate:         period.To,
+		GroupBy:        groupBy,
+		BaseCurrency:   rates.Base(),
+		DepartmentID:   scope.DepartmentID,
+		ManagerID:      scope.ManagerID,
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+	}
+
+	response := timeseriesResponse{
+		Interval: interval,
+		From:     period.From,
+		To:       period.To,
+		GroupBy:  groupBy,
+		Scope:    scope.Label,
+		Series:   []timeseriesSeries{},
+	}
+	// Rows come ordered by group and bucket, a new key starts a new series
+	for _, row := range rows {
+		revenue, err := money.Parse(row.TotalRevenue)
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": "Failed to get sales data"})
+		}
+		last := len(response.Series) - 1
+		if last < 0 || response.Series[last].Key != row.GroupKey {
+			series := timeseriesSeries{Key: row.GroupKey, Label: row.GroupLabel, Currency: rates.Base()}
+			if groupBy == "currency" {
+				series.Currency = row.GroupKey
+			}
+			response.Series = append(response.Series, series)
+			last++
+		}
+		response.Series[last].Points = append(response.Series[last].Points, timeseriesPoint{
+			BucketStart:  row.BucketStart,
+			TotalSales:   row.TotalSales,
+			TotalRevenue: revenue,
+		})
+	}
+
+	return c.JSON(200, response)
+}
+
+// Helper function to estimate how many buckets of the interval a period spans
+func timeseriesBuckets(period reportPeriod, interval string) int {
+	days := int(period.To.Sub(period.From).Hours() / 24)
+	switch interval {
+	case "week":
+		return days/7 + 1
+	case "month":
+		return days/28 + 1
+	case "quarter":
+		return days/90 + 1
+	default:
+		return days
+	}
+}
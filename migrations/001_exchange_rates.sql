@@ -0,0 +1,40 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id SERIAL PRIMARY KEY,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC(18,8) NOT NULL CHECK (rate > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (from_currency, to_currency, rate_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_sales_currency ON sales(currency);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION convert_amount(amount NUMERIC, from_code VARCHAR, to_code VARCHAR, at TIMESTAMP WITH TIME ZONE)
+RETURNS NUMERIC AS $$
+    SELECT CASE
+        WHEN from_code = to_code THEN amount
+        ELSE amount * COALESCE(
+            (SELECT rate FROM exchange_rates
+             WHERE from_currency = from_code AND to_currency = to_code AND rate_date <= at::date
+             ORDER BY rate_date DESC LIMIT 1),
+            (SELECT 1 / rate FROM exchange_rates
+             WHERE from_currency = to_code AND to_currency = from_code AND rate_date <= at::date
+             ORDER BY rate_date DESC LIMIT 1)
+        )
+    END
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+CREATE TRIGGER update_exchange_rates_updated_at 
+    BEFORE UPDATE ON exchange_rates 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- +goose Down
+DROP FUNCTION IF EXISTS convert_amount(NUMERIC, VARCHAR, VARCHAR, TIMESTAMP WITH TIME ZONE);
+DROP INDEX IF EXISTS idx_sales_currency;
+DROP TABLE IF EXISTS exchange_rates;
@@ -0,0 +1,39 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(3) PRIMARY KEY CHECK (code ~ '^[A-Z]{3}$'),
+    name VARCHAR(100) NOT NULL,
+    minor_units SMALLINT NOT NULL DEFAULT 2 CHECK (minor_units BETWEEN 0 AND 4),
+    symbol VARCHAR(10) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO currencies (code, name, minor_units, symbol, enabled) VALUES 
+('PLN', 'Polish zloty', 2, 'zł', TRUE),
+('EUR', 'Euro', 2, '€', TRUE),
+('USD', 'US dollar', 2, '$', TRUE),
+('GBP', 'Pound sterling', 2, '£', TRUE),
+('CHF', 'Swiss franc', 2, 'CHF', TRUE),
+('CZK', 'Czech koruna', 2, 'Kč', FALSE),
+('DKK', 'Danish krone', 2, 'kr', FALSE),
+('NOK', 'Norwegian krone', 2, 'kr', FALSE),
+('SEK', 'Swedish krona', 2, 'kr', FALSE),
+('HUF', 'Hungarian forint', 2, 'Ft', FALSE),
+('UAH', 'Ukrainian hryvnia', 2, '₴', FALSE),
+('JPY', 'Japanese yen', 0, '¥', FALSE)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TRIGGER update_currencies_updated_at 
+    BEFORE UPDATE ON currencies 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing rows are upper-cased; codes that are still unknown are kept but
+-- the constraint is NOT VALID, so only new writes are checked against the registry
+UPDATE sales SET currency = UPPER(currency) WHERE currency <> UPPER(currency);
+ALTER TABLE sales ADD CONSTRAINT sales_currency_fkey FOREIGN KEY (currency) REFERENCES currencies(code) NOT VALID;
+
+-- +goose Down
+ALTER TABLE sales DROP CONSTRAINT IF EXISTS sales_currency_fkey;
+DROP TABLE IF EXISTS currencies;
@@ -0,0 +1,7 @@
+-- +goose Up
+CREATE INDEX IF NOT EXISTS idx_sales_sale_date_id ON sales(sale_date, id);
+CREATE INDEX IF NOT EXISTS idx_sales_price_id ON sales(price, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sales_price_id;
+DROP INDEX IF EXISTS idx_sales_sale_date_id;
@@ -0,0 +1,37 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_name_lower ON categories(LOWER(name));
+
+CREATE TRIGGER update_categories_updated_at 
+    BEFORE UPDATE ON categories 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing free-text categories are trimmed, and spellings that differ only in case
+-- ("Electronics", "electronics") are merged into the most used one
+UPDATE sales SET category = TRIM(category) WHERE category <> TRIM(category);
+
+INSERT INTO categories (name)
+SELECT DISTINCT ON (LOWER(category)) category
+FROM sales
+GROUP BY category
+ORDER BY LOWER(category), COUNT(*) DESC, category
+ON CONFLICT DO NOTHING;
+
+UPDATE sales s SET category = c.name
+FROM categories c
+WHERE LOWER(s.category) = LOWER(c.name) AND s.category <> c.name;
+
+-- Renaming a category renames it on its sales, a category with sales cannot be deleted
+ALTER TABLE sales ADD CONSTRAINT sales_category_fkey FOREIGN KEY (category) REFERENCES categories(name) ON UPDATE CASCADE;
+
+-- +goose Down
+ALTER TABLE sales DROP CONSTRAINT IF EXISTS sales_category_fkey;
+DROP TABLE IF EXISTS categories;
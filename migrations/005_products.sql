@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS products (
+    id SERIAL PRIMARY KEY,
+    sku VARCHAR(64) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL REFERENCES categories(name) ON UPDATE CASCADE,
+    price DECIMAL(10,2) NOT NULL CHECK (price > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'PLN' REFERENCES currencies(code),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
+
+CREATE TRIGGER update_products_updated_at 
+    BEFORE UPDATE ON products 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing sales keep their free-text product names and are not linked to a product
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS product_id INTEGER REFERENCES products(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_sales_product_id ON sales(product_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_sales_product_id;
+ALTER TABLE sales DROP COLUMN IF EXISTS product_id;
+DROP TABLE IF EXISTS products;
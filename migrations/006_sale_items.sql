@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS sale_items (
+    id SERIAL PRIMARY KEY,
+    sale_id INTEGER NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+    product_id INTEGER REFERENCES products(id) ON DELETE SET NULL,
+    product_name VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL REFERENCES categories(name) ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    unit_price DECIMAL(10,2) NOT NULL CHECK (unit_price > 0),
+    line_total DECIMAL(12,2) GENERATED ALWAYS AS (quantity * unit_price) STORED,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sale_items_sale_id ON sale_items(sale_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_product_id ON sale_items(product_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_category ON sale_items(category);
+
+-- Every existing sale becomes an order with a single line
+INSERT INTO sale_items (sale_id, product_id, product_name, category, quantity, unit_price, created_at)
+SELECT id, product_id, product_name, category, 1, price, created_at
+FROM sales s
+WHERE NOT EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = s.id);
+
+-- +goose Down
+DROP TABLE IF EXISTS sale_items;
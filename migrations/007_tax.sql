@@ -0,0 +1,31 @@
+-- +goose Up
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2) NOT NULL DEFAULT 23 CHECK (tax_rate BETWEEN 0 AND 100);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2) CHECK (tax_rate BETWEEN 0 AND 100);
+
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (tax_rate BETWEEN 0 AND 100);
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS net_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS net_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+
+-- Existing prices are gross and taxed at the rate of their category
+UPDATE sale_items i 
+SET tax_rate = c.tax_rate, 
+    net_amount = ROUND(i.line_total * 100 / (100 + c.tax_rate), 2), 
+    tax_amount = i.line_total - ROUND(i.line_total * 100 / (100 + c.tax_rate), 2) 
+FROM categories c 
+WHERE c.name = i.category;
+
+UPDATE sales s 
+SET net_amount = t.net_amount, tax_amount = t.tax_amount 
+FROM (SELECT sale_id, SUM(net_amount) as net_amount, SUM(tax_amount) as tax_amount FROM sale_items GROUP BY sale_id) t 
+WHERE t.sale_id = s.id;
+
+-- +goose Down
+ALTER TABLE sales DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE sales DROP COLUMN IF EXISTS net_amount;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS tax_amount;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS net_amount;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE products DROP COLUMN IF EXISTS tax_rate;
+ALTER TABLE categories DROP COLUMN IF EXISTS tax_rate;
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    discount_percent NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (discount_percent >= 0 AND discount_percent < 100),
+    discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    currency VARCHAR(3) REFERENCES currencies(code),
+    valid_from DATE,
+    valid_to DATE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((discount_percent > 0) <> (discount_amount > 0)),
+    CHECK (discount_amount = 0 OR currency IS NOT NULL),
+    CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to)
+);
+
+CREATE TRIGGER update_promotions_updated_at 
+    BEFORE UPDATE ON promotions 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing sales were charged their list price
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS discount_percent NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (discount_percent >= 0 AND discount_percent < 100);
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS promotion_id INTEGER REFERENCES promotions(id) ON DELETE SET NULL;
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS list_price DECIMAL(10,2) GENERATED ALWAYS AS (price + discount_amount) STORED;
+CREATE INDEX IF NOT EXISTS idx_sales_promotion_id ON sales(promotion_id);
+
+-- line_total becomes the amount charged for the line, the generated column has to be added again
+ALTER TABLE sale_items ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0);
+ALTER TABLE sale_items DROP COLUMN line_total;
+ALTER TABLE sale_items ADD COLUMN line_total DECIMAL(12,2) GENERATED ALWAYS AS (quantity * unit_price - discount_amount) STORED;
+
+-- +goose Down
+ALTER TABLE sale_items DROP COLUMN line_total;
+ALTER TABLE sale_items DROP COLUMN IF EXISTS discount_amount;
+ALTER TABLE sale_items ADD COLUMN line_total DECIMAL(12,2) GENERATED ALWAYS AS (quantity * unit_price) STORED;
+ALTER TABLE sales DROP COLUMN IF EXISTS list_price;
+ALTER TABLE sales DROP COLUMN IF EXISTS promotion_id;
+ALTER TABLE sales DROP COLUMN IF EXISTS discount_percent;
+ALTER TABLE sales DROP COLUMN IF EXISTS discount_amount;
+DROP TABLE IF EXISTS promotions;
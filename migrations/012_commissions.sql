@@ -0,0 +1,48 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS commission_plans (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    currency VARCHAR(3) NOT NULL DEFAULT 'PLN' REFERENCES currencies(code),
+    base_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (base_rate >= 0 AND base_rate <= 100),
+    accelerator_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (accelerator_rate >= 0 AND accelerator_rate <= 100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS commission_tiers (
+    id SERIAL PRIMARY KEY,
+    plan_id INTEGER NOT NULL REFERENCES commission_plans(id) ON DELETE CASCADE,
+    threshold DECIMAL(12,2) NOT NULL CHECK (threshold >= 0),
+    rate NUMERIC(5,2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    UNIQUE (plan_id, threshold)
+);
+
+CREATE TABLE IF NOT EXISTS commission_category_rates (
+    plan_id INTEGER NOT NULL REFERENCES commission_plans(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL REFERENCES categories(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    rate NUMERIC(5,2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    PRIMARY KEY (plan_id, category)
+);
+
+-- The plan of an employee from valid_from on, until the next assignment
+CREATE TABLE IF NOT EXISTS commission_assignments (
+    employee_id INTEGER NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    plan_id INTEGER NOT NULL REFERENCES commission_plans(id),
+    valid_from DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (employee_id, valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_commission_assignments_plan_id ON commission_assignments(plan_id);
+
+CREATE TRIGGER update_commission_plans_updated_at 
+    BEFORE UPDATE ON commission_plans 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- +goose Down
+DROP TABLE IF EXISTS commission_assignments;
+DROP TABLE IF EXISTS commission_category_rates;
+DROP TABLE IF EXISTS commission_tiers;
+DROP TABLE IF EXISTS commission_plans;
@@ -0,0 +1,54 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS departments (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    parent_id INTEGER REFERENCES departments(id), -- teams sit inside a department
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_departments_parent_id ON departments(parent_id);
+
+CREATE TRIGGER update_departments_updated_at 
+    BEFORE UPDATE ON departments 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- Existing employees start without a department and a manager
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS department_id INTEGER REFERENCES departments(id) ON DELETE SET NULL;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS manager_id INTEGER REFERENCES employees(id) ON DELETE SET NULL CHECK (manager_id <> id);
+CREATE INDEX IF NOT EXISTS idx_employees_department_id ON employees(department_id);
+CREATE INDEX IF NOT EXISTS idx_employees_manager_id ON employees(manager_id);
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION department_employees(root INTEGER)
+RETURNS SETOF INTEGER AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id FROM departments WHERE id = root
+        UNION
+        SELECT d.id FROM departments d JOIN tree t ON d.parent_id = t.id
+    )
+    SELECT e.id FROM employees e JOIN tree t ON e.department_id = t.id
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION manager_subtree(root INTEGER)
+RETURNS SETOF INTEGER AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id FROM employees WHERE id = root
+        UNION
+        SELECT e.id FROM employees e JOIN tree t ON e.manager_id = t.id
+    )
+    SELECT id FROM tree
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION IF EXISTS manager_subtree(INTEGER);
+DROP FUNCTION IF EXISTS department_employees(INTEGER);
+ALTER TABLE employees DROP COLUMN IF EXISTS manager_id;
+ALTER TABLE employees DROP COLUMN IF EXISTS department_id;
+DROP TABLE IF EXISTS departments;
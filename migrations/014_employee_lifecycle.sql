@@ -0,0 +1,19 @@
+-- +goose Up
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS hire_date DATE NOT NULL DEFAULT CURRENT_DATE;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS termination_date DATE;
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS job_title VARCHAR(100);
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'inactive'));
+ALTER TABLE employees ADD CONSTRAINT employees_termination_after_hire CHECK (termination_date >= hire_date);
+CREATE INDEX IF NOT EXISTS idx_employees_status ON employees(status);
+
+-- Existing employees were hired when they were added, or earlier if they have older sales
+UPDATE employees e 
+SET hire_date = LEAST(e.created_at::date, COALESCE((SELECT MIN(s.sale_date)::date FROM sales s WHERE s.employee_id = e.id), e.created_at::date));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_employees_status;
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_termination_after_hire;
+ALTER TABLE employees DROP COLUMN IF EXISTS status;
+ALTER TABLE employees DROP COLUMN IF EXISTS job_title;
+ALTER TABLE employees DROP COLUMN IF EXISTS termination_date;
+ALTER TABLE employees DROP COLUMN IF EXISTS hire_date;
@@ -0,0 +1,31 @@
+-- +goose Up
+-- Sales of leavers can be moved to the unassigned pool, and an employee with sales can no longer be deleted
+ALTER TABLE sales ALTER COLUMN employee_id DROP NOT NULL;
+ALTER TABLE sales DROP CONSTRAINT IF EXISTS sales_employee_id_fkey;
+ALTER TABLE sales ADD CONSTRAINT sales_employee_id_fkey FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE RESTRICT;
+
+CREATE TABLE IF NOT EXISTS offboardings (
+    id SERIAL PRIMARY KEY,
+    employee_id INTEGER NOT NULL,
+    employee_name VARCHAR(100) NOT NULL,
+    employee_surname VARCHAR(100) NOT NULL,
+    employee_email VARCHAR(255) NOT NULL,
+    reassigned_to INTEGER REFERENCES employees(id) ON DELETE SET NULL, -- NULL for the unassigned pool
+    from_date DATE, -- the reassigned sales, NULL filters mean all of them
+    to_date DATE,
+    category VARCHAR(100),
+    customer_id INTEGER,
+    sales_reassigned INTEGER NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('deactivate', 'delete')),
+    performed_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_offboardings_employee_id ON offboardings(employee_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS offboardings;
+-- Fails while there are unassigned sales, assign them to an employee first
+ALTER TABLE sales ALTER COLUMN employee_id SET NOT NULL;
+ALTER TABLE sales DROP CONSTRAINT IF EXISTS sales_employee_id_fkey;
+ALTER TABLE sales ADD CONSTRAINT sales_employee_id_fkey FOREIGN KEY (employee_id) REFERENCES employees(id) ON DELETE CASCADE;
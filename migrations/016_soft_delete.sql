@@ -0,0 +1,20 @@
+-- +goose Up
+-- Deleted employees and sales are kept with deleted_at set until the purge job removes them
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- The email of a deleted employee can be taken by a new one
+ALTER TABLE employees DROP CONSTRAINT IF EXISTS employees_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_email_active ON employees(email) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_employees_deleted_at ON employees(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_sales_deleted_at ON sales(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose Down
+-- Deleted rows come back, fails when a deleted employee shares an email with a current one
+DROP INDEX IF EXISTS idx_sales_deleted_at;
+DROP INDEX IF EXISTS idx_employees_deleted_at;
+DROP INDEX IF EXISTS idx_employees_email_active;
+ALTER TABLE employees ADD CONSTRAINT employees_email_key UNIQUE (email);
+ALTER TABLE sales DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE employees DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,34 @@
+-- +goose Up
+-- Every change to employees and sales, with who made it and the record before and after
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    entity VARCHAR(20) NOT NULL CHECK (entity IN ('employee', 'sale')),
+    entity_id INTEGER NOT NULL, -- no foreign key, entries outlive purged records
+    action VARCHAR(10) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(100),
+    before JSONB, -- NULL for create
+    after JSONB, -- NULL for delete
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id, id);
+
+-- The audit log is append-only
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER audit_log_append_only 
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log 
+    FOR EACH STATEMENT 
+    EXECUTE FUNCTION audit_log_append_only();
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Amounts are stored with 2 decimals, so a currency cannot have more minor units
+UPDATE currencies SET minor_units = 2 WHERE minor_units > 2;
+ALTER TABLE currencies DROP CONSTRAINT IF EXISTS currencies_minor_units_check;
+ALTER TABLE currencies ADD CONSTRAINT currencies_minor_units_check CHECK (minor_units BETWEEN 0 AND 2);
+
+-- +goose Down
+ALTER TABLE currencies DROP CONSTRAINT IF EXISTS currencies_minor_units_check;
+ALTER TABLE currencies ADD CONSTRAINT currencies_minor_units_check CHECK (minor_units BETWEEN 0 AND 4);
@@ -1,101 +1,1099 @@
 -- name: GetEmployee :one
-SELECT id, name, surname, email, created_at, updated_at 
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: GetEmployeeIncludingDeleted :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
 FROM employees 
 WHERE id = $1;
 
+-- name: GetEmployeeForUpdate :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE id = $1 AND deleted_at IS NULL 
+FOR UPDATE;
+
+-- name: GetEmployeeIncludingDeletedForUpdate :one
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
+FROM employees 
+WHERE id = $1 
+FOR UPDATE;
+
 -- name: GetEmployees :many
-SELECT id, name, surname, email, created_at, updated_at 
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
 FROM employees 
+WHERE deleted_at IS NULL 
 ORDER BY id;
 
+-- name: CountEmployees :one
+SELECT COUNT(*) 
+FROM employees e 
+WHERE (sqlc.narg(search)::varchar IS NULL 
+    OR e.name ILIKE '%' || sqlc.narg(search) || '%' 
+    OR e.surname ILIKE '%' || sqlc.narg(search) || '%' 
+    OR e.email ILIKE '%' || sqlc.narg(search) || '%') 
+  AND (sqlc.narg(department_id)::int IS NULL OR e.id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+  AND (sqlc.narg(manager_id)::int IS NULL OR e.id IN (SELECT manager_subtree(sqlc.narg(manager_id)))) 
+  AND (sqlc.narg(status)::varchar IS NULL OR e.status = sqlc.narg(status)) 
+  AND (sqlc.arg(include_deleted)::bool OR e.deleted_at IS NULL);
+
 -- name: CreateEmployee :one
-INSERT INTO employees (name, surname, email) 
-VALUES ($1, $2, $3) 
-RETURNING id, name, surname, email, created_at, updated_at;
+INSERT INTO employees (name, surname, email, hire_date, job_title) 
+VALUES (sqlc.arg(name), sqlc.arg(surname), sqlc.arg(email), COALESCE(sqlc.narg(hire_date)::date, CURRENT_DATE), sqlc.narg(job_title)) 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at;
+
+-- name: CreateAuditEntry :one
+INSERT INTO audit_log (entity, entity_id, action, actor, request_id, before, after) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING id, entity, entity_id, action, actor, request_id, before, after, created_at;
+
+-- name: ListAuditLog :many
+-- Latest entries first, entity and entity_id are optional filters
+SELECT id, entity, entity_id, action, actor, request_id, before, after, created_at 
+FROM audit_log 
+WHERE (sqlc.narg(entity)::text IS NULL OR entity = sqlc.narg(entity)) 
+  AND (sqlc.narg(entity_id)::int IS NULL OR entity_id = sqlc.narg(entity_id)) 
+  AND (sqlc.narg(cursor_id)::int IS NULL OR id < sqlc.narg(cursor_id)) 
+ORDER BY id DESC 
+LIMIT sqlc.arg(page_limit);
+
+-- name: CountAuditLog :one
+SELECT COUNT(*) 
+FROM audit_log 
+WHERE (sqlc.narg(entity)::text IS NULL OR entity = sqlc.narg(entity)) 
+  AND (sqlc.narg(entity_id)::int IS NULL OR entity_id = sqlc.narg(entity_id));
 
 -- name: UpdateEmployee :one
 UPDATE employees 
-SET name = $2, surname = $3, email = $4, updated_at = CURRENT_TIMESTAMP 
-WHERE id = $1 
-RETURNING id, name, surname, email, created_at, updated_at;
+SET name = sqlc.arg(name), surname = sqlc.arg(surname), email = sqlc.arg(email), 
+    hire_date = sqlc.arg(hire_date), termination_date = sqlc.narg(termination_date), 
+    job_title = sqlc.narg(job_title), status = sqlc.arg(status), updated_at = CURRENT_TIMESTAMP 
+WHERE id = sqlc.arg(id) 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at;
+
+-- name: DeleteEmployee :execrows
+-- Soft delete, the employee is kept until PurgeEmployees removes them
+UPDATE employees 
+SET deleted_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NULL;
 
--- name: DeleteEmployee :exec
+-- name: RestoreEmployee :one
+UPDATE employees 
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NOT NULL 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at;
+
+-- name: PurgeEmployees :many
+-- Employees still referenced by sales are kept until the sales are purged or reassigned
 DELETE FROM employees 
-WHERE id = $1;
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamptz 
+  AND NOT EXISTS (SELECT 1 FROM sales s WHERE s.employee_id = employees.id) 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at;
+
+-- name: ClearPurgedManagers :many
+-- Employees reporting to someone PurgeEmployees removes lose their manager before it,
+-- they are returned as they were before
+WITH purged AS ( 
+    SELECT m.id FROM employees m 
+    WHERE m.deleted_at < sqlc.arg(deleted_before)::timestamptz 
+      AND NOT EXISTS (SELECT 1 FROM sales s WHERE s.employee_id = m.id) 
+) 
+UPDATE employees 
+SET manager_id = NULL, updated_at = CURRENT_TIMESTAMP 
+FROM employees old 
+WHERE old.id = employees.id 
+  AND employees.manager_id IN (SELECT id FROM purged) 
+  AND employees.id NOT IN (SELECT id FROM purged) 
+RETURNING old.id, old.name, old.surname, old.email, old.department_id, old.manager_id, old.hire_date, old.termination_date, old.job_title, old.status, old.created_at, old.updated_at, old.deleted_at;
 
 -- name: GetEmployeeByEmail :one
-SELECT id, name, surname, email, created_at, updated_at 
+SELECT id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at 
 FROM employees 
-WHERE email = $1;
+WHERE email = $1 AND deleted_at IS NULL;
+
+-- name: GetDepartment :one
+SELECT id, name, description, parent_id, created_at, updated_at 
+FROM departments 
+WHERE id = $1;
+
+-- name: GetDepartments :many
+SELECT id, name, description, parent_id, created_at, updated_at 
+FROM departments 
+ORDER BY name;
+
+-- name: CreateDepartment :one
+INSERT INTO departments (name, description, parent_id) 
+VALUES ($1, $2, $3) 
+RETURNING id, name, description, parent_id, created_at, updated_at;
+
+-- name: UpdateDepartment :one
+UPDATE departments 
+SET name = $2, description = $3, parent_id = $4, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, description, parent_id, created_at, updated_at;
+
+-- name: DeleteDepartment :exec
+DELETE FROM departments 
+WHERE id = $1;
+
+-- name: IsSubDepartment :one
+-- Whether department_id is root_id or one of the teams below it
+WITH RECURSIVE tree AS ( 
+    SELECT id FROM departments WHERE id = sqlc.arg(root_id) 
+    UNION 
+    SELECT d.id FROM departments d JOIN tree t ON d.parent_id = t.id 
+) 
+SELECT EXISTS (SELECT 1 FROM tree WHERE id = sqlc.arg(department_id)::int);
+
+-- name: LockDepartmentChanges :exec
+-- Serializes department moves until the end of the transaction, so that two of them cannot make a cycle
+SELECT pg_advisory_xact_lock(hashtext('departments.parent_id'));
+
+-- name: IsInManagerSubtree :one
+SELECT EXISTS ( 
+    SELECT 1 FROM manager_subtree(sqlc.arg(manager_id)) AS m(id) WHERE m.id = sqlc.arg(employee_id)::int 
+);
+
+-- name: LockManagerChanges :exec
+-- Serializes manager changes until the end of the transaction, so that two of them cannot make a cycle
+SELECT pg_advisory_xact_lock(hashtext('employees.manager_id'));
+
+-- name: SetEmployeeDepartment :one
+UPDATE employees 
+SET department_id = $2, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at;
+
+-- name: SetEmployeeManager :one
+UPDATE employees 
+SET manager_id = $2, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at;
+
+-- name: TerminateEmployee :one
+-- Ends the employment on the given day, the employee can no longer make sales
+UPDATE employees 
+SET termination_date = $2, status = 'inactive', updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, surname, email, department_id, manager_id, hire_date, termination_date, job_title, status, created_at, updated_at, deleted_at;
+
+-- name: CreateOffboarding :one
+INSERT INTO offboardings (employee_id, employee_name, employee_surname, employee_email, reassigned_to, from_date, to_date, category, customer_id, sales_reassigned, action, performed_by) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
+RETURNING id, employee_id, employee_name, employee_surname, employee_email, reassigned_to, from_date, to_date, category, customer_id, sales_reassigned, action, performed_by, created_at;
+
+-- name: ListOffboardings :many
+SELECT id, employee_id, employee_name, employee_surname, employee_email, reassigned_to, from_date, to_date, category, customer_id, sales_reassigned, action, performed_by, created_at 
+FROM offboardings 
+WHERE sqlc.narg(employee_id)::int IS NULL OR employee_id = sqlc.narg(employee_id) 
+ORDER BY created_at DESC, id DESC;
 
 -- name: GetSale :one
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND id = $1;
+
+-- name: GetSaleIncludingDeleted :one
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
 FROM sales 
 WHERE id = $1;
 
+-- name: GetSaleForUpdate :one
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND id = $1 
+FOR UPDATE;
+
+-- name: GetSaleIncludingDeletedForUpdate :one
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE id = $1 
+FOR UPDATE;
+
 -- name: GetSales :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
 FROM sales 
+WHERE deleted_at IS NULL 
 ORDER BY sale_date DESC;
 
+-- name: CountSales :one
+SELECT COUNT(*) 
+FROM sales 
+WHERE (sqlc.narg(employee_id)::int IS NULL OR employee_id = sqlc.narg(employee_id)) 
+  AND (NOT sqlc.arg(unassigned)::bool OR employee_id IS NULL) 
+  AND (sqlc.arg(include_deleted)::bool OR deleted_at IS NULL) 
+  AND (sqlc.narg(category)::varchar IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = sales.id AND i.category = sqlc.narg(category))) 
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency)) 
+  AND (sqlc.narg(min_price)::numeric IS NULL OR price >= sqlc.narg(min_price)) 
+  AND (sqlc.narg(max_price)::numeric IS NULL OR price <= sqlc.narg(max_price)) 
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR sale_date >= sqlc.narg(from_date)) 
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR sale_date < sqlc.narg(to_date)) 
+  AND (sqlc.narg(customer_id)::int IS NULL OR customer_id = sqlc.narg(customer_id));
+
 -- name: GetSalesByEmployee :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
 FROM sales 
-WHERE employee_id = $1 
+WHERE deleted_at IS NULL AND employee_id = $1 
 ORDER BY sale_date DESC;
 
 -- name: CreateSale :one
-INSERT INTO sales (product_name, category, currency, price, sale_date, employee_id) 
-VALUES ($1, $2, $3, $4, $5, $6) 
-RETURNING id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at;
+INSERT INTO sales (product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, customer_id) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) 
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at;
 
 -- name: UpdateSale :one
 UPDATE sales 
-SET product_name = $2, category = $3, currency = $4, price = $5, sale_date = $6, employee_id = $7, updated_at = CURRENT_TIMESTAMP 
+SET product_name = $2, category = $3, currency = $4, price = $5, sale_date = $6, employee_id = $7, product_id = $8, net_amount = $9, tax_amount = $10, discount_amount = $11, discount_percent = $12, promotion_id = $13, customer_id = $14, updated_at = CURRENT_TIMESTAMP 
 WHERE id = $1 
-RETURNING id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at;
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at;
 
--- name: DeleteSale :exec
+-- name: DeleteSale :execrows
+-- Soft delete, the sale with its lines and refunds is kept until PurgeSales removes it
+UPDATE sales 
+SET deleted_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NULL;
+
+-- name: RestoreSale :one
+UPDATE sales 
+SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 AND deleted_at IS NOT NULL 
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at;
+
+-- name: PurgeSales :many
+-- Lines and refunds of the sales are removed with them
 DELETE FROM sales 
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamptz 
+RETURNING id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at;
+
+-- name: ReassignEmployeeSales :many
+-- Moves the sales of an employee, or only those of a date range, category or customer,
+-- to another employee or to the unassigned pool when to_employee_id is NULL.
+-- The sales are returned as they were before the move.
+UPDATE sales 
+SET employee_id = sqlc.narg(to_employee_id), updated_at = CURRENT_TIMESTAMP 
+FROM sales old 
+WHERE old.id = sales.id AND sales.employee_id = sqlc.arg(employee_id) AND sales.deleted_at IS NULL 
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR sales.sale_date >= sqlc.narg(from_date)) 
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR sales.sale_date < sqlc.narg(to_date)) 
+  AND (sqlc.narg(category)::varchar IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = sales.id AND i.category = sqlc.narg(category))) 
+  AND (sqlc.narg(customer_id)::int IS NULL OR sales.customer_id = sqlc.narg(customer_id)) 
+RETURNING old.id, old.product_name, old.category, old.currency, old.price, old.sale_date, old.employee_id, old.product_id, old.net_amount, old.tax_amount, old.discount_amount, old.discount_percent, old.promotion_id, old.list_price, old.customer_id, old.created_at, old.updated_at, old.deleted_at;
+
+-- name: GetSaleItems :many
+SELECT id, sale_id, product_id, product_name, category, quantity, unit_price, discount_amount, line_total, tax_rate, net_amount, tax_amount, created_at 
+FROM sale_items 
+WHERE sale_id = $1 
+ORDER BY id;
+
+-- name: GetSaleItemsByEmployeeAndDateRange :many
+SELECT i.id, i.sale_id, i.product_id, i.product_name, i.category, i.quantity, i.unit_price, i.discount_amount, i.line_total, i.tax_rate, i.net_amount, i.tax_amount, i.created_at 
+FROM sale_items i 
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+WHERE s.employee_id = sqlc.arg(employee_id) AND s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date) 
+ORDER BY i.sale_id, i.id;
+
+-- name: CreateSaleItem :one
+INSERT INTO sale_items (sale_id, product_id, product_name, category, quantity, unit_price, discount_amount, tax_rate, net_amount, tax_amount) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) 
+RETURNING id, sale_id, product_id, product_name, category, quantity, unit_price, discount_amount, line_total, tax_rate, net_amount, tax_amount, created_at;
+
+-- name: DeleteSaleItems :exec
+DELETE FROM sale_items 
+WHERE sale_id = $1;
+
+-- name: GetRefund :one
+SELECT id, sale_id, amount, reason, refund_date, created_at 
+FROM refunds 
+WHERE id = $1;
+
+-- name: ListRefunds :many
+SELECT r.id, r.sale_id, r.amount, r.reason, r.refund_date, r.created_at 
+FROM refunds r 
+JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+WHERE (sqlc.narg(sale_id)::int IS NULL OR r.sale_id = sqlc.narg(sale_id)) 
+  AND (sqlc.narg(employee_id)::int IS NULL OR s.employee_id = sqlc.narg(employee_id)) 
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR r.refund_date >= sqlc.narg(from_date)) 
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR r.refund_date < sqlc.narg(to_date)) 
+ORDER BY r.refund_date, r.id;
+
+-- name: GetRefundsByEmployeeAndDateRange :many
+SELECT r.id, r.sale_id, r.amount, r.reason, r.refund_date, s.currency, s.product_name 
+FROM refunds r 
+JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+WHERE s.employee_id = sqlc.arg(employee_id) AND r.refund_date >= sqlc.arg(from_date) AND r.refund_date < sqlc.arg(to_date) 
+ORDER BY r.refund_date, r.id;
+
+-- name: GetRefundedAmount :one
+SELECT COALESCE(SUM(amount), 0)::numeric(12,2) as total_refunded 
+FROM refunds 
+WHERE sale_id = $1;
+
+-- name: CreateRefund :one
+INSERT INTO refunds (sale_id, amount, reason, refund_date) 
+VALUES ($1, $2, $3, $4) 
+RETURNING id, sale_id, amount, reason, refund_date, created_at;
+
+-- name: DeleteRefund :exec
+DELETE FROM refunds 
 WHERE id = $1;
 
 -- name: GetSalesByDateRange :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
 FROM sales 
-WHERE sale_date BETWEEN $1 AND $2 
+WHERE deleted_at IS NULL AND sale_date BETWEEN $1 AND $2 
 ORDER BY sale_date DESC;
 
+-- name: GetSalesByEmployeeAndDateRange :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE deleted_at IS NULL AND employee_id = $1 AND sale_date >= $2 AND sale_date < $3 
+ORDER BY sale_date;
+
 -- name: GetSalesByCategory :many
-SELECT id, product_name, category, currency, price, sale_date, employee_id, created_at, updated_at 
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
 FROM sales 
-WHERE category = $1 
+WHERE deleted_at IS NULL AND category = $1 
 ORDER BY sale_date DESC;
 
 -- name: GetSalesStatsByEmployee :many
+WITH converted AS (
+    SELECT employee_id, convert_amount(price, currency, sqlc.arg(base_currency)::varchar, sale_date) as amount
+    FROM sales
+    WHERE deleted_at IS NULL AND sale_date >= sqlc.arg(from_date) AND sale_date < sqlc.arg(to_date)
+), refunded AS (
+    SELECT s.employee_id, SUM(convert_amount(r.amount, s.currency, sqlc.arg(base_currency)::varchar, r.refund_date)) as amount
+    FROM refunds r
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL
+    WHERE r.refund_date >= sqlc.arg(from_date) AND r.refund_date < sqlc.arg(to_date)
+    GROUP BY s.employee_id
+)
 SELECT 
     e.id,
     e.name,
     e.surname,
     e.email,
-    COUNT(s.id) as total_sales,
-    COALESCE(SUM(s.price), 0) as total_revenue,
-    COALESCE(AVG(s.price), 0) as avg_sale_value
+    COUNT(c.employee_id) as total_sales,
+    COALESCE(SUM(c.amount), 0)::numeric(12,2) as total_revenue,
+    COALESCE(MAX(rf.amount), 0)::numeric(12,2) as total_refunded,
+    COALESCE(AVG(c.amount), 0)::numeric(12,2) as avg_sale_value,
+    RANK() OVER (ORDER BY COALESCE(SUM(c.amount), 0) - COALESCE(MAX(rf.amount), 0) DESC) as rank
 FROM employees e
-LEFT JOIN sales s ON e.id = s.employee_id
+LEFT JOIN converted c ON e.id = c.employee_id
+LEFT JOIN refunded rf ON e.id = rf.employee_id
+WHERE e.deleted_at IS NULL
+  AND (sqlc.narg(department_id)::int IS NULL OR e.id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+  AND (sqlc.narg(manager_id)::int IS NULL OR e.id IN (SELECT manager_subtree(sqlc.narg(manager_id))))
 GROUP BY e.id, e.name, e.surname, e.email
-ORDER BY total_revenue DESC;
+ORDER BY rank, e.id;
 
--- name: GetEmployeeWithSales :one
+-- name: GetSalesTimeseries :many
+WITH buckets AS (
+    SELECT generate_series(
+        date_trunc(sqlc.arg(bucket_interval)::text, sqlc.arg(from_date)::timestamptz),
+        sqlc.arg(to_date)::timestamptz - interval '1 microsecond',
+        CASE WHEN sqlc.arg(bucket_interval) = 'quarter' THEN interval '3 months' ELSE ('1 ' || sqlc.arg(bucket_interval))::interval END
+    ) as bucket_start
+),
+filtered AS (
+    SELECT 
+        date_trunc(sqlc.arg(bucket_interval), s.sale_date) as bucket_start,
+        CASE sqlc.arg(group_by)::text 
+            WHEN 'employee' THEN COALESCE(s.employee_id::text, '') 
+            WHEN 'category' THEN l.category 
+            WHEN 'currency' THEN s.currency 
+            WHEN 'department' THEN COALESCE(e.department_id::text, '') 
+            ELSE '' 
+        END as group_key,
+        CASE sqlc.arg(group_by)::text 
+            WHEN 'employee' THEN COALESCE(e.name || ' ' || e.surname, 'Unassigned') 
+            WHEN 'department' THEN COALESCE(d.name, '') 
+            ELSE '' 
+        END as group_label,
+        CASE WHEN sqlc.arg(group_by) = 'currency' THEN l.amount 
+            ELSE convert_amount(l.amount, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date) 
+        END as amount
+    FROM sales s
+    -- Sales of the unassigned pool have no employee
+    LEFT JOIN employees e ON e.id = s.employee_id
+    LEFT JOIN departments d ON d.id = e.department_id
+    -- Grouped by category a sale counts once in each category of its lines, with their total
+    CROSS JOIN LATERAL (
+        SELECT s.category, s.price as amount WHERE sqlc.arg(group_by) <> 'category'
+        UNION ALL
+        SELECT i.category, SUM(i.line_total) FROM sale_items i 
+        WHERE i.sale_id = s.id AND sqlc.arg(group_by) = 'category' 
+        GROUP BY i.category
+    ) l
+    WHERE s.deleted_at IS NULL AND s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date)
+        AND (sqlc.narg(department_id)::int IS NULL OR s.employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+        AND (sqlc.narg(manager_id)::int IS NULL OR s.employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id))))
+),
+groups AS (
+    SELECT DISTINCT group_key, group_label FROM filtered
+    UNION
+    SELECT '', '' WHERE sqlc.arg(group_by) = ''
+)
+SELECT 
+    b.bucket_start::timestamptz as bucket_start,
+    g.group_key::text as group_key,
+    g.group_label::text as group_label,
+    COUNT(f.bucket_start) as total_sales,
+    COALESCE(SUM(f.amount), 0)::numeric(12,2) as total_revenue
+FROM buckets b
+CROSS JOIN groups g
+LEFT JOIN filtered f ON f.bucket_start = b.bucket_start AND f.group_key = g.group_key
+GROUP BY b.bucket_start, g.group_key, g.group_label
+ORDER BY g.group_key, b.bucket_start;
+
+-- name: GetEmployeeSalesStats :many
+WITH converted AS (
+    SELECT s.employee_id, s.sale_date, convert_amount(
+        CASE WHEN sqlc.narg(category)::varchar IS NULL THEN s.price 
+            ELSE (SELECT SUM(i.line_total) FROM sale_items i WHERE i.sale_id = s.id AND i.category = sqlc.narg(category)) 
+        END, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date) as amount,
+        convert_amount(
+        CASE WHEN sqlc.narg(category)::varchar IS NULL THEN s.discount_amount 
+            ELSE (SELECT SUM(i.discount_amount) FROM sale_items i WHERE i.sale_id = s.id AND i.category = sqlc.narg(category)) 
+        END, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date) as discount
+    FROM sales s
+    WHERE s.deleted_at IS NULL AND s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date)
+        AND (sqlc.narg(category) IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = s.id AND i.category = sqlc.narg(category)))
+), refunded AS (
+    -- Refunds of a sale are shared between its categories in proportion to their part of the price
+    SELECT s.employee_id, SUM(convert_amount(
+        CASE WHEN sqlc.narg(category)::varchar IS NULL THEN r.amount 
+            ELSE r.amount * (SELECT SUM(i.line_total) FROM sale_items i WHERE i.sale_id = s.id AND i.category = sqlc.narg(category)) / s.price 
+        END, s.currency, sqlc.arg(base_currency)::varchar, r.refund_date)) as amount
+    FROM refunds r
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL
+    WHERE r.refund_date >= sqlc.arg(from_date) AND r.refund_date < sqlc.arg(to_date)
+        AND (sqlc.narg(category) IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = s.id AND i.category = sqlc.narg(category)))
+    GROUP BY s.employee_id
+)
 SELECT 
     e.id,
     e.name,
     e.surname,
     e.email,
-    e.created_at,
-    e.updated_at,
-    COUNT(s.id) as total_sales,
-    COALESCE(SUM(s.price), 0) as total_revenue
+    COUNT(c.employee_id) as total_sales,
+    COALESCE(SUM(c.amount), 0)::numeric(12,2) as total_revenue,
+    COALESCE(SUM(c.discount), 0)::numeric(12,2) as total_discount,
+    COALESCE(MAX(rf.amount), 0)::numeric(12,2) as total_refunded,
+    AVG(c.amount)::numeric(12,2) as avg_sale_value,
+    (PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY c.amount))::numeric(12,2) as median_sale_value,
+    MIN(c.amount)::numeric(12,2) as min_sale_value,
+    MAX(c.amount)::numeric(12,2) as max_sale_value,
+    MIN(c.sale_date) as first_sale_date,
+    MAX(c.sale_date) as last_sale_date
 FROM employees e
-LEFT JOIN sales s ON e.id = s.employee_id
-WHERE e.id = $1
-GROUP BY e.id, e.name, e.surname, e.email, e.created_at, e.updated_at;
+LEFT JOIN converted c ON e.id = c.employee_id
+LEFT JOIN refunded rf ON e.id = rf.employee_id
+WHERE e.deleted_at IS NULL
+  AND (sqlc.narg(employee_id)::int IS NULL OR e.id = sqlc.narg(employee_id))
+  AND (sqlc.narg(department_id)::int IS NULL OR e.id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+  AND (sqlc.narg(manager_id)::int IS NULL OR e.id IN (SELECT manager_subtree(sqlc.narg(manager_id))))
+GROUP BY e.id, e.name, e.surname, e.email
+ORDER BY total_revenue DESC, e.id;
+
+-- name: ListEmployees :many
+SELECT 
+    e.id, 
+    e.name, 
+    e.surname, 
+    e.email, 
+    e.department_id, 
+    e.manager_id, 
+    e.hire_date, 
+    e.termination_date, 
+    e.job_title, 
+    e.status, 
+    e.created_at, 
+    e.updated_at, 
+    e.deleted_at, 
+    summary.total_sales, 
+    summary.total_revenue, 
+    summary.last_sale_date, 
+    summary.missing_currencies 
+FROM employees e 
+CROSS JOIN LATERAL ( 
+    SELECT 
+        COUNT(*) as total_sales, 
+        COALESCE(SUM(convert_amount(s.price, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date)), 0)::numeric(12,2) as total_revenue, 
+        MAX(s.sale_date) as last_sale_date, 
+        COALESCE(string_agg(DISTINCT s.currency, ',') FILTER (WHERE convert_amount(s.price, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date) IS NULL), '')::text as missing_currencies 
+    FROM sales s 
+    WHERE s.deleted_at IS NULL AND s.employee_id = e.id AND sqlc.arg(with_sales)::bool 
+) summary 
+WHERE (sqlc.narg(search)::varchar IS NULL 
+    OR e.name ILIKE '%' || sqlc.narg(search) || '%' 
+    OR e.surname ILIKE '%' || sqlc.narg(search) || '%' 
+    OR e.email ILIKE '%' || sqlc.narg(search) || '%') 
+  AND (sqlc.narg(department_id)::int IS NULL OR e.id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+  AND (sqlc.narg(manager_id)::int IS NULL OR e.id IN (SELECT manager_subtree(sqlc.narg(manager_id)))) 
+  AND (sqlc.narg(status)::varchar IS NULL OR e.status = sqlc.narg(status)) 
+  AND (sqlc.arg(include_deleted)::bool OR e.deleted_at IS NULL) 
+  AND (sqlc.narg(cursor_id)::int IS NULL OR CASE sqlc.arg(sort)::varchar 
+    WHEN 'id' THEN e.id > sqlc.narg(cursor_id) 
+    WHEN '-id' THEN e.id < sqlc.narg(cursor_id) 
+    WHEN 'name' THEN (e.name, e.id) > (sqlc.narg(cursor_value)::varchar, sqlc.narg(cursor_id)) 
+    WHEN '-name' THEN (e.name, e.id) < (sqlc.narg(cursor_value), sqlc.narg(cursor_id)) 
+    WHEN 'surname' THEN (e.surname, e.id) > (sqlc.narg(cursor_value), sqlc.narg(cursor_id)) 
+    WHEN '-surname' THEN (e.surname, e.id) < (sqlc.narg(cursor_value), sqlc.narg(cursor_id)) 
+    WHEN 'email' THEN (e.email, e.id) > (sqlc.narg(cursor_value), sqlc.narg(cursor_id)) 
+    WHEN '-email' THEN (e.email, e.id) < (sqlc.narg(cursor_value), sqlc.narg(cursor_id)) 
+  END) 
+ORDER BY 
+  CASE WHEN sqlc.arg(sort) = 'name' THEN e.name END ASC, 
+  CASE WHEN sqlc.arg(sort) = '-name' THEN e.name END DESC, 
+  CASE WHEN sqlc.arg(sort) = 'surname' THEN e.surname END ASC, 
+  CASE WHEN sqlc.arg(sort) = '-surname' THEN e.surname END DESC, 
+  CASE WHEN sqlc.arg(sort) = 'email' THEN e.email END ASC, 
+  CASE WHEN sqlc.arg(sort) = '-email' THEN e.email END DESC, 
+  CASE WHEN sqlc.arg(sort) IN ('id', 'name', 'surname', 'email') THEN e.id END ASC, 
+  CASE WHEN sqlc.arg(sort) IN ('-id', '-name', '-surname', '-email') THEN e.id END DESC 
+LIMIT sqlc.arg(page_limit)::int;
+
+-- name: ListSales :many
+SELECT id, product_name, category, currency, price, sale_date, employee_id, product_id, net_amount, tax_amount, discount_amount, discount_percent, promotion_id, list_price, customer_id, created_at, updated_at, deleted_at 
+FROM sales 
+WHERE (sqlc.narg(employee_id)::int IS NULL OR employee_id = sqlc.narg(employee_id)) 
+  AND (NOT sqlc.arg(unassigned)::bool OR employee_id IS NULL) 
+  AND (sqlc.arg(include_deleted)::bool OR deleted_at IS NULL) 
+  AND (sqlc.narg(category)::varchar IS NULL OR EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = sales.id AND i.category = sqlc.narg(category))) 
+  AND (sqlc.narg(currency)::varchar IS NULL OR currency = sqlc.narg(currency)) 
+  AND (sqlc.narg(min_price)::numeric IS NULL OR price >= sqlc.narg(min_price)) 
+  AND (sqlc.narg(max_price)::numeric IS NULL OR price <= sqlc.narg(max_price)) 
+  AND (sqlc.narg(from_date)::timestamptz IS NULL OR sale_date >= sqlc.narg(from_date)) 
+  AND (sqlc.narg(to_date)::timestamptz IS NULL OR sale_date < sqlc.narg(to_date)) 
+  AND (sqlc.narg(customer_id)::int IS NULL OR customer_id = sqlc.narg(customer_id)) 
+  AND (sqlc.narg(cursor_id)::int IS NULL OR CASE sqlc.arg(sort)::varchar 
+    WHEN 'sale_date' THEN (sale_date, id) > (sqlc.narg(cursor_date)::timestamptz, sqlc.narg(cursor_id)) 
+    WHEN '-sale_date' THEN (sale_date, id) < (sqlc.narg(cursor_date), sqlc.narg(cursor_id)) 
+    WHEN 'price' THEN (price, id) > (sqlc.narg(cursor_price)::numeric, sqlc.narg(cursor_id)) 
+    WHEN '-price' THEN (price, id) < (sqlc.narg(cursor_price), sqlc.narg(cursor_id)) 
+    WHEN 'id' THEN id > sqlc.narg(cursor_id) 
+    WHEN '-id' THEN id < sqlc.narg(cursor_id) 
+  END) 
+ORDER BY 
+  CASE WHEN sqlc.arg(sort) = 'sale_date' THEN sale_date END ASC, 
+  CASE WHEN sqlc.arg(sort) = '-sale_date' THEN sale_date END DESC, 
+  CASE WHEN sqlc.arg(sort) = 'price' THEN price END ASC, 
+  CASE WHEN sqlc.arg(sort) = '-price' THEN price END DESC, 
+  CASE WHEN sqlc.arg(sort) IN ('sale_date', 'price', 'id') THEN id END ASC, 
+  CASE WHEN sqlc.arg(sort) IN ('-sale_date', '-price', '-id') THEN id END DESC 
+LIMIT sqlc.arg(page_limit)::int;
+
+-- name: GetRevenueByCategory :many
+SELECT 
+    i.category,
+    COUNT(DISTINCT s.id) as total_sales,
+    COALESCE(SUM(convert_amount(i.line_total, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date)), 0)::numeric(12,2) as total_revenue
+FROM sale_items i 
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+WHERE s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date) 
+    AND (sqlc.narg(department_id)::int IS NULL OR s.employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+    AND (sqlc.narg(manager_id)::int IS NULL OR s.employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id)))) 
+GROUP BY i.category 
+ORDER BY total_revenue DESC, i.category;
+
+-- name: GetRevenueByCurrency :many
+WITH sold AS (
+    SELECT currency, COUNT(id) as total_sales, SUM(price) as total_revenue, SUM(discount_amount) as total_discount 
+    FROM sales 
+    WHERE deleted_at IS NULL AND sale_date >= sqlc.arg(from_date) AND sale_date < sqlc.arg(to_date) 
+        AND (sqlc.narg(department_id)::int IS NULL OR employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+        AND (sqlc.narg(manager_id)::int IS NULL OR employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id)))) 
+    GROUP BY currency
+), refunded AS (
+    SELECT s.currency, SUM(r.amount) as total_refunded 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    WHERE r.refund_date >= sqlc.arg(from_date) AND r.refund_date < sqlc.arg(to_date) 
+        AND (sqlc.narg(department_id)::int IS NULL OR s.employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+        AND (sqlc.narg(manager_id)::int IS NULL OR s.employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id)))) 
+    GROUP BY s.currency
+)
+SELECT 
+    COALESCE(sold.currency, refunded.currency)::varchar as currency,
+    COALESCE(sold.total_sales, 0) as total_sales,
+    COALESCE(sold.total_revenue, 0)::numeric as total_revenue,
+    COALESCE(sold.total_discount, 0)::numeric as total_discount,
+    COALESCE(refunded.total_refunded, 0)::numeric as total_refunded
+FROM sold 
+FULL JOIN refunded ON refunded.currency = sold.currency 
+ORDER BY 1;
+
+-- name: GetTaxBreakdown :many
+SELECT 
+    s.currency,
+    i.tax_rate,
+    COALESCE(SUM(i.net_amount), 0)::numeric(12,2) as net_amount,
+    COALESCE(SUM(i.tax_amount), 0)::numeric(12,2) as tax_amount,
+    COALESCE(SUM(i.line_total), 0)::numeric(12,2) as gross_amount
+FROM sale_items i 
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+WHERE s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date) 
+    AND (sqlc.narg(employee_id)::int IS NULL OR s.employee_id = sqlc.narg(employee_id)) 
+    AND (sqlc.narg(department_id)::int IS NULL OR s.employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+    AND (sqlc.narg(manager_id)::int IS NULL OR s.employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id)))) 
+GROUP BY s.currency, i.tax_rate 
+ORDER BY s.currency, i.tax_rate DESC;
+
+-- name: GetTopProducts :many
+SELECT 
+    i.product_id,
+    COALESCE(p.sku, '')::varchar as sku,
+    COALESCE(p.name, i.product_name)::varchar as product_name,
+    COUNT(DISTINCT s.id) as total_sales,
+    SUM(i.quantity)::bigint as total_quantity,
+    COALESCE(SUM(convert_amount(i.line_total, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date)), 0)::numeric(12,2) as total_revenue
+FROM sale_items i
+JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL
+LEFT JOIN products p ON p.id = i.product_id
+WHERE s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date)
+    AND (sqlc.narg(department_id)::int IS NULL OR s.employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+    AND (sqlc.narg(manager_id)::int IS NULL OR s.employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id))))
+GROUP BY i.product_id, p.sku, COALESCE(p.name, i.product_name)
+ORDER BY total_revenue DESC, total_quantity DESC, product_name
+LIMIT sqlc.arg(product_limit)::int;
+
+-- name: GetCurrenciesWithoutExchangeRate :many
+SELECT currency
+FROM sales 
+WHERE deleted_at IS NULL AND sale_date >= sqlc.arg(from_date) AND sale_date < sqlc.arg(to_date) 
+    AND convert_amount(price, currency, sqlc.arg(base_currency)::varchar, sale_date) IS NULL 
+UNION 
+SELECT s.currency 
+FROM refunds r 
+JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+WHERE r.refund_date >= sqlc.arg(from_date) AND r.refund_date < sqlc.arg(to_date) 
+    AND convert_amount(r.amount, s.currency, sqlc.arg(base_currency)::varchar, r.refund_date) IS NULL 
+ORDER BY currency;
+
+-- name: GetExchangeRate :one
+SELECT id, from_currency, to_currency, rate_date, rate, created_at, updated_at 
+FROM exchange_rates 
+WHERE id = $1;
+
+-- name: GetExchangeRates :many
+SELECT id, from_currency, to_currency, rate_date, rate, created_at, updated_at 
+FROM exchange_rates 
+WHERE (sqlc.narg(from_currency)::varchar IS NULL OR from_currency = sqlc.narg(from_currency)) 
+    AND (sqlc.narg(to_currency)::varchar IS NULL OR to_currency = sqlc.narg(to_currency)) 
+ORDER BY rate_date DESC, from_currency, to_currency;
+
+-- name: GetExchangeRateOnDate :one
+SELECT id, from_currency, to_currency, rate_date, rate, created_at, updated_at 
+FROM exchange_rates 
+WHERE from_currency = $1 AND to_currency = $2 AND rate_date <= $3 
+ORDER BY rate_date DESC 
+LIMIT 1;
+
+-- name: CreateExchangeRate :one
+INSERT INTO exchange_rates (from_currency, to_currency, rate_date, rate) 
+VALUES ($1, $2, $3, $4) 
+RETURNING id, from_currency, to_currency, rate_date, rate, created_at, updated_at;
+
+-- name: UpsertExchangeRate :one
+INSERT INTO exchange_rates (from_currency, to_currency, rate_date, rate) 
+VALUES ($1, $2, $3, $4) 
+ON CONFLICT (from_currency, to_currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate, updated_at = CURRENT_TIMESTAMP 
+RETURNING id, from_currency, to_currency, rate_date, rate, created_at, updated_at;
+
+-- name: UpdateExchangeRate :one
+UPDATE exchange_rates 
+SET from_currency = $2, to_currency = $3, rate_date = $4, rate = $5, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, from_currency, to_currency, rate_date, rate, created_at, updated_at;
+
+-- name: DeleteExchangeRate :exec
+DELETE FROM exchange_rates 
+WHERE id = $1;
+
+-- name: GetCurrency :one
+SELECT code, name, minor_units, symbol, enabled, created_at, updated_at 
+FROM currencies 
+WHERE code = $1;
+
+-- name: GetCurrencies :many
+SELECT code, name, minor_units, symbol, enabled, created_at, updated_at 
+FROM currencies 
+ORDER BY code;
+
+-- name: CreateCurrency :one
+INSERT INTO currencies (code, name, minor_units, symbol, enabled) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING code, name, minor_units, symbol, enabled, created_at, updated_at;
+
+-- name: UpdateCurrency :one
+UPDATE currencies 
+SET name = $2, minor_units = $3, symbol = $4, enabled = $5, updated_at = CURRENT_TIMESTAMP 
+WHERE code = $1 
+RETURNING code, name, minor_units, symbol, enabled, created_at, updated_at;
+
+-- name: GetCategory :one
+SELECT id, name, description, tax_rate, created_at, updated_at 
+FROM categories 
+WHERE id = $1;
+
+-- name: GetCategoryByName :one
+SELECT id, name, description, tax_rate, created_at, updated_at 
+FROM categories 
+WHERE LOWER(name) = LOWER($1);
+
+-- name: GetCategories :many
+SELECT id, name, description, tax_rate, created_at, updated_at 
+FROM categories 
+ORDER BY name;
+
+-- name: CreateCategory :one
+INSERT INTO categories (name, description, tax_rate) 
+VALUES ($1, $2, $3) 
+RETURNING id, name, description, tax_rate, created_at, updated_at;
+
+-- name: UpdateCategory :one
+UPDATE categories 
+SET name = $2, description = $3, tax_rate = $4, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, description, tax_rate, created_at, updated_at;
+
+-- name: DeleteCategory :exec
+DELETE FROM categories 
+WHERE id = $1;
+
+-- name: GetProduct :one
+SELECT id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at 
+FROM products 
+WHERE id = $1;
+
+-- name: GetProductBySku :one
+SELECT id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at 
+FROM products 
+WHERE sku = $1;
+
+-- name: GetProducts :many
+SELECT id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at 
+FROM products 
+WHERE (sqlc.narg(active)::bool IS NULL OR active = sqlc.narg(active)) 
+  AND (sqlc.narg(category)::varchar IS NULL OR category = sqlc.narg(category)) 
+ORDER BY sku;
+
+-- name: CreateProduct :one
+INSERT INTO products (sku, name, category, price, currency, active, tax_rate) 
+VALUES ($1, $2, $3, $4, $5, $6, $7) 
+RETURNING id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at;
+
+-- name: UpdateProduct :one
+UPDATE products 
+SET sku = $2, name = $3, category = $4, price = $5, currency = $6, active = $7, tax_rate = $8, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, sku, name, category, price, currency, active, tax_rate, created_at, updated_at;
+
+-- name: DeleteProduct :exec
+DELETE FROM products 
+WHERE id = $1;
+
+-- name: GetPromotion :one
+SELECT id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at 
+FROM promotions 
+WHERE id = $1;
+
+-- name: GetPromotionByCode :one
+SELECT id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at 
+FROM promotions 
+WHERE code = $1;
+
+-- name: GetPromotions :many
+SELECT id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at 
+FROM promotions 
+WHERE sqlc.narg(active)::bool IS NULL OR active = sqlc.narg(active) 
+ORDER BY code;
+
+-- name: CreatePromotion :one
+INSERT INTO promotions (code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active) 
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8) 
+RETURNING id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at;
+
+-- name: UpdatePromotion :one
+UPDATE promotions 
+SET code = $2, description = $3, discount_percent = $4, discount_amount = $5, currency = $6, valid_from = $7, valid_to = $8, active = $9, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, code, description, discount_percent, discount_amount, currency, valid_from, valid_to, active, created_at, updated_at;
+
+-- name: DeletePromotion :exec
+DELETE FROM promotions 
+WHERE id = $1;
+
+-- name: GetCustomer :one
+SELECT id, name, company, tax_id, email, address, created_at, updated_at 
+FROM customers 
+WHERE id = $1;
+
+-- name: GetCustomerByTaxID :one
+SELECT id, name, company, tax_id, email, address, created_at, updated_at 
+FROM customers 
+WHERE tax_id = $1;
+
+-- name: CountCustomers :one
+SELECT COUNT(*) 
+FROM customers c 
+WHERE (sqlc.narg(search)::varchar IS NULL 
+    OR c.name ILIKE '%' || sqlc.narg(search) || '%' 
+    OR c.company ILIKE '%' || sqlc.narg(search) || '%' 
+    OR c.tax_id ILIKE '%' || sqlc.narg(search) || '%' 
+    OR c.email ILIKE '%' || sqlc.narg(search) || '%');
+
+-- name: ListCustomers :many
+SELECT c.id, c.name, c.company, c.tax_id, c.email, c.address, c.created_at, c.updated_at 
+FROM customers c 
+WHERE (sqlc.narg(search)::varchar IS NULL 
+    OR c.name ILIKE '%' || sqlc.narg(search) || '%' 
+    OR c.company ILIKE '%' || sqlc.narg(search) || '%' 
+    OR c.tax_id ILIKE '%' || sqlc.narg(search) || '%' 
+    OR c.email ILIKE '%' || sqlc.narg(search) || '%') 
+  AND (sqlc.narg(cursor_id)::int IS NULL OR CASE sqlc.arg(sort)::varchar 
+    WHEN 'id' THEN c.id > sqlc.narg(cursor_id) 
+    WHEN '-id' THEN c.id < sqlc.narg(cursor_id) 
+    WHEN 'name' THEN (c.name, c.id) > (sqlc.narg(cursor_value)::varchar, sqlc.narg(cursor_id)) 
+    WHEN '-name' THEN (c.name, c.id) < (sqlc.narg(cursor_value), sqlc.narg(cursor_id)) 
+  END) 
+ORDER BY 
+  CASE WHEN sqlc.arg(sort) = 'name' THEN c.name END ASC, 
+  CASE WHEN sqlc.arg(sort) = '-name' THEN c.name END DESC, 
+  CASE WHEN sqlc.arg(sort) IN ('id', 'name') THEN c.id END ASC, 
+  CASE WHEN sqlc.arg(sort) IN ('-id', '-name') THEN c.id END DESC 
+LIMIT sqlc.arg(page_limit)::int;
+
+-- name: CreateCustomer :one
+INSERT INTO customers (name, company, tax_id, email, address) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING id, name, company, tax_id, email, address, created_at, updated_at;
+
+-- name: UpdateCustomer :one
+UPDATE customers 
+SET name = $2, company = $3, tax_id = $4, email = $5, address = $6, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, company, tax_id, email, address, created_at, updated_at;
+
+-- name: DeleteCustomer :exec
+DELETE FROM customers 
+WHERE id = $1;
+
+-- name: GetSalesTarget :one
+SELECT id, employee_id, period_type, period_start, amount, currency, created_at, updated_at 
+FROM sales_targets 
+WHERE id = $1;
+
+-- name: GetEmployeeSalesTarget :one
+SELECT id, employee_id, period_type, period_start, amount, currency, created_at, updated_at 
+FROM sales_targets 
+WHERE employee_id = $1 AND period_type = $2 AND period_start = $3;
+
+-- name: ListSalesTargets :many
+SELECT id, employee_id, period_type, period_start, amount, currency, created_at, updated_at 
+FROM sales_targets 
+WHERE (sqlc.narg(employee_id)::int IS NULL OR employee_id = sqlc.narg(employee_id)) 
+  AND (sqlc.narg(period_type)::varchar IS NULL OR period_type = sqlc.narg(period_type)) 
+  AND (sqlc.narg(from_date)::date IS NULL OR period_start >= sqlc.narg(from_date)) 
+  AND (sqlc.narg(to_date)::date IS NULL OR period_start < sqlc.narg(to_date)) 
+ORDER BY period_start, period_type, employee_id;
+
+-- name: SetSalesTarget :one
+INSERT INTO sales_targets (employee_id, period_type, period_start, amount, currency) 
+VALUES ($1, $2, $3, $4, $5) 
+ON CONFLICT (employee_id, period_type, period_start) 
+DO UPDATE SET amount = EXCLUDED.amount, currency = EXCLUDED.currency, updated_at = CURRENT_TIMESTAMP 
+RETURNING id, employee_id, period_type, period_start, amount, currency, created_at, updated_at;
+
+-- name: DeleteSalesTarget :exec
+DELETE FROM sales_targets 
+WHERE id = $1;
+
+-- name: GetCommissionPlans :many
+SELECT id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at 
+FROM commission_plans 
+ORDER BY name;
+
+-- name: GetCommissionPlan :one
+SELECT id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at 
+FROM commission_plans 
+WHERE id = $1;
+
+-- name: CreateCommissionPlan :one
+INSERT INTO commission_plans (name, description, currency, base_rate, accelerator_rate) 
+VALUES ($1, $2, $3, $4, $5) 
+RETURNING id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at;
+
+-- name: UpdateCommissionPlan :one
+UPDATE commission_plans 
+SET name = $2, description = $3, currency = $4, base_rate = $5, accelerator_rate = $6, updated_at = CURRENT_TIMESTAMP 
+WHERE id = $1 
+RETURNING id, name, description, currency, base_rate, accelerator_rate, created_at, updated_at;
+
+-- name: DeleteCommissionPlan :exec
+DELETE FROM commission_plans 
+WHERE id = $1;
+
+-- name: GetCommissionTiers :many
+SELECT id, plan_id, threshold, rate 
+FROM commission_tiers 
+WHERE plan_id = $1 
+ORDER BY threshold;
+
+-- name: CreateCommissionTier :one
+INSERT INTO commission_tiers (plan_id, threshold, rate) 
+VALUES ($1, $2, $3) 
+RETURNING id, plan_id, threshold, rate;
+
+-- name: DeleteCommissionTiers :exec
+DELETE FROM commission_tiers 
+WHERE plan_id = $1;
+
+-- name: GetCommissionCategoryRates :many
+SELECT plan_id, category, rate 
+FROM commission_category_rates 
+WHERE plan_id = $1 
+ORDER BY category;
+
+-- name: CreateCommissionCategoryRate :one
+INSERT INTO commission_category_rates (plan_id, category, rate) 
+VALUES ($1, $2, $3) 
+RETURNING plan_id, category, rate;
+
+-- name: DeleteCommissionCategoryRates :exec
+DELETE FROM commission_category_rates 
+WHERE plan_id = $1;
+
+-- name: GetCommissionAssignments :many
+SELECT employee_id, plan_id, valid_from, created_at 
+FROM commission_assignments 
+WHERE employee_id = $1 
+ORDER BY valid_from;
+
+-- name: SetCommissionAssignment :one
+INSERT INTO commission_assignments (employee_id, plan_id, valid_from) 
+VALUES ($1, $2, $3) 
+ON CONFLICT (employee_id, valid_from) DO UPDATE SET plan_id = EXCLUDED.plan_id 
+RETURNING employee_id, plan_id, valid_from, created_at;
+
+-- name: DeleteCommissionAssignment :execrows
+DELETE FROM commission_assignments 
+WHERE employee_id = $1 AND valid_from = $2;
+
+-- name: GetCommissionAssignmentsOn :many
+SELECT DISTINCT ON (employee_id) employee_id, plan_id, valid_from, created_at 
+FROM commission_assignments 
+WHERE valid_from <= sqlc.arg(on_date)::date 
+  AND (sqlc.narg(employee_id)::int IS NULL OR employee_id = sqlc.narg(employee_id)) 
+  AND employee_id IN (SELECT id FROM employees WHERE deleted_at IS NULL) 
+  AND (sqlc.narg(department_id)::int IS NULL OR employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+  AND (sqlc.narg(manager_id)::int IS NULL OR employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id)))) 
+ORDER BY employee_id, valid_from DESC;
+
+-- name: GetCommissionBase :many
+WITH amounts AS ( 
+    -- Every line is converted at the rate of its sale date, refunds are shared 
+    -- between the lines of their sale and converted at the refund date 
+    SELECT i.category, s.currency, false as refund, 
+        convert_amount(i.line_total, s.currency, sqlc.arg(plan_currency)::varchar, s.sale_date) as amount 
+    FROM sale_items i 
+    JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL 
+    WHERE s.employee_id = sqlc.arg(employee_id) AND s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date) 
+    UNION ALL 
+    SELECT i.category, s.currency, true as refund, 
+        convert_amount(r.amount * i.line_total / s.price, s.currency, sqlc.arg(plan_currency)::varchar, r.refund_date) as amount 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    JOIN sale_items i ON i.sale_id = s.id 
+    WHERE s.employee_id = sqlc.arg(employee_id) AND r.refund_date >= sqlc.arg(from_date) AND r.refund_date < sqlc.arg(to_date) 
+) 
+SELECT 
+    category, 
+    COALESCE(SUM(amount) FILTER (WHERE NOT refund), 0)::numeric(12,2) as revenue, 
+    COALESCE(SUM(amount) FILTER (WHERE refund), 0)::numeric(12,2) as refunded, 
+    COALESCE(string_agg(DISTINCT currency, ',') FILTER (WHERE amount IS NULL), '')::varchar as missing_rates 
+FROM amounts 
+GROUP BY category 
+ORDER BY category;
+
+-- name: GetEmployeeRevenueByCurrency :many
+WITH sold AS ( 
+    SELECT currency, COUNT(id) as total_sales, SUM(price) as total_revenue 
+    FROM sales 
+    WHERE deleted_at IS NULL AND employee_id = sqlc.arg(employee_id) AND sale_date >= sqlc.arg(from_date) AND sale_date < sqlc.arg(to_date) 
+    GROUP BY currency 
+), refunded AS ( 
+    SELECT s.currency, SUM(r.amount) as total_refunded 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    WHERE s.employee_id = sqlc.arg(employee_id) AND r.refund_date >= sqlc.arg(from_date) AND r.refund_date < sqlc.arg(to_date) 
+    GROUP BY s.currency 
+) 
+SELECT 
+    COALESCE(sold.currency, refunded.currency)::varchar as currency, 
+    COALESCE(sold.total_sales, 0) as total_sales, 
+    COALESCE(sold.total_revenue, 0)::numeric as total_revenue, 
+    COALESCE(refunded.total_refunded, 0)::numeric as total_refunded 
+FROM sold 
+FULL JOIN refunded ON refunded.currency = sold.currency 
+ORDER BY 1;
+
+-- name: GetCustomerRevenueByCurrency :many
+WITH sold AS ( 
+    SELECT 
+        s.currency, 
+        COUNT(*) as total_sales, 
+        SUM(s.price) as total_revenue, 
+        SUM(convert_amount(s.price, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date)) as base_revenue, 
+        COUNT(*) FILTER (WHERE convert_amount(s.price, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date) IS NULL) as missing_rates, 
+        MIN(s.sale_date) as first_sale_date, 
+        MAX(s.sale_date) as last_sale_date 
+    FROM sales s 
+    WHERE s.deleted_at IS NULL AND s.customer_id = sqlc.arg(customer_id) 
+    GROUP BY s.currency 
+), refunded AS ( 
+    SELECT 
+        s.currency, 
+        SUM(r.amount) as total_refunded, 
+        SUM(convert_amount(r.amount, s.currency, sqlc.arg(base_currency)::varchar, r.refund_date)) as base_refunded, 
+        COUNT(*) FILTER (WHERE convert_amount(r.amount, s.currency, sqlc.arg(base_currency)::varchar, r.refund_date) IS NULL) as missing_rates 
+    FROM refunds r 
+    JOIN sales s ON s.id = r.sale_id AND s.deleted_at IS NULL 
+    WHERE s.customer_id = sqlc.arg(customer_id) 
+    GROUP BY s.currency 
+) 
+SELECT 
+    sold.currency, 
+    sold.total_sales, 
+    sold.total_revenue::numeric(12,2) as total_revenue, 
+    COALESCE(refunded.total_refunded, 0)::numeric(12,2) as total_refunded, 
+    COALESCE(sold.base_revenue, 0)::numeric(12,2) as base_revenue, 
+    COALESCE(refunded.base_refunded, 0)::numeric(12,2) as base_refunded, 
+    (sold.missing_rates + COALESCE(refunded.missing_rates, 0))::bigint as missing_rates, 
+    sold.first_sale_date, 
+    sold.last_sale_date 
+FROM sold 
+LEFT JOIN refunded ON refunded.currency = sold.currency 
+ORDER BY sold.currency;
+
+-- name: GetCategoryStats :many
+WITH converted AS (
+    SELECT i.category, s.id as sale_id, convert_amount(i.line_total, s.currency, sqlc.arg(base_currency)::varchar, s.sale_date) as amount
+    FROM sale_items i
+    JOIN sales s ON s.id = i.sale_id AND s.deleted_at IS NULL
+    WHERE s.sale_date >= sqlc.arg(from_date) AND s.sale_date < sqlc.arg(to_date)
+        AND (sqlc.narg(employee_id)::int IS NULL OR s.employee_id = sqlc.narg(employee_id))
+        AND (sqlc.narg(department_id)::int IS NULL OR s.employee_id IN (SELECT department_employees(sqlc.narg(department_id)))) 
+        AND (sqlc.narg(manager_id)::int IS NULL OR s.employee_id IN (SELECT manager_subtree(sqlc.narg(manager_id))))
+)
+SELECT 
+    c.id,
+    c.name,
+    COUNT(DISTINCT cv.sale_id) as total_sales,
+    COALESCE(SUM(cv.amount), 0)::numeric(12,2) as total_revenue,
+    (SUM(cv.amount) / NULLIF(COUNT(DISTINCT cv.sale_id), 0))::numeric(12,2) as avg_sale_value
+FROM categories c
+LEFT JOIN converted cv ON cv.category = c.name
+GROUP BY c.id, c.name
+ORDER BY total_revenue DESC, c.name;
@@ -1,31 +1,341 @@
+-- Departments and the teams inside them
+CREATE TABLE IF NOT EXISTS departments (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    parent_id INTEGER REFERENCES departments(id), -- teams sit inside a department
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent_id <> id)
+);
+
 -- Employees table
 CREATE TABLE IF NOT EXISTS employees (
     id SERIAL PRIMARY KEY,
     name VARCHAR(100) NOT NULL,
     surname VARCHAR(100) NOT NULL,
-    email VARCHAR(255) UNIQUE NOT NULL,
+    email VARCHAR(255) NOT NULL, -- unique among the employees that are not deleted
+    department_id INTEGER REFERENCES departments(id) ON DELETE SET NULL,
+    manager_id INTEGER REFERENCES employees(id) ON DELETE SET NULL,
+    hire_date DATE NOT NULL DEFAULT CURRENT_DATE,
+    termination_date DATE, -- set when the employee leaves
+    job_title VARCHAR(100),
+    status VARCHAR(10) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'inactive')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE, -- set by a soft delete, the row is purged after the retention period
+    CHECK (manager_id <> id),
+    CHECK (termination_date >= hire_date)
+);
+
+-- Currencies registry (ISO 4217)
+CREATE TABLE IF NOT EXISTS currencies (
+    code VARCHAR(3) PRIMARY KEY CHECK (code ~ '^[A-Z]{3}$'),
+    name VARCHAR(100) NOT NULL,
+    minor_units SMALLINT NOT NULL DEFAULT 2 CHECK (minor_units BETWEEN 0 AND 2), -- amounts are stored with 2 decimals
+    symbol VARCHAR(10) NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO currencies (code, name, minor_units, symbol, enabled) VALUES 
+('PLN', 'Polish zloty', 2, 'zł', TRUE),
+('EUR', 'Euro', 2, '€', TRUE),
+('USD', 'US dollar', 2, '$', TRUE),
+('GBP', 'Pound sterling', 2, '£', TRUE),
+('CHF', 'Swiss franc', 2, 'CHF', TRUE),
+('CZK', 'Czech koruna', 2, 'Kč', FALSE),
+('DKK', 'Danish krone', 2, 'kr', FALSE),
+('NOK', 'Norwegian krone', 2, 'kr', FALSE),
+('SEK', 'Swedish krona', 2, 'kr', FALSE),
+('HUF', 'Hungarian forint', 2, 'Ft', FALSE),
+('UAH', 'Ukrainian hryvnia', 2, '₴', FALSE),
+('JPY', 'Japanese yen', 0, '¥', FALSE)
+ON CONFLICT (code) DO NOTHING;
+
+-- Categories of sold products, names are unique regardless of case.
+-- tax_rate is the VAT rate in percent of the products in the category.
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 23 CHECK (tax_rate BETWEEN 0 AND 100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_categories_name_lower ON categories(LOWER(name));
+
+-- Product catalog, price is the default (list) price of a sale.
+-- A product without tax_rate is taxed at the rate of its category.
+CREATE TABLE IF NOT EXISTS products (
+    id SERIAL PRIMARY KEY,
+    sku VARCHAR(64) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL REFERENCES categories(name) ON UPDATE CASCADE,
+    price DECIMAL(10,2) NOT NULL CHECK (price > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'PLN' REFERENCES currencies(code),
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    tax_rate NUMERIC(5,2) CHECK (tax_rate BETWEEN 0 AND 100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Promotion codes, a promotion takes either discount_percent off a sale or discount_amount in its currency.
+-- Codes are stored in upper case. A sale can use a code from valid_from to valid_to (both days inclusive).
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(50) UNIQUE NOT NULL,
+    description TEXT,
+    discount_percent NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (discount_percent >= 0 AND discount_percent < 100),
+    discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    currency VARCHAR(3) REFERENCES currencies(code),
+    valid_from DATE,
+    valid_to DATE,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((discount_percent > 0) <> (discount_amount > 0)),
+    CHECK (discount_amount = 0 OR currency IS NOT NULL),
+    CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to)
+);
+
+-- Customers who buy, tax_id is the NIP of companies and is unique when given
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    company VARCHAR(255),
+    tax_id VARCHAR(20),
+    email VARCHAR(255),
+    address TEXT,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
 -- Sales table
+-- A sale is the header of an order, its lines are in sale_items. The header keeps the order total
+-- in price and the name, category and product of its first line for clients reading single sales.
+-- Prices are gross, net_amount and tax_amount are the sums of the lines.
+-- price is the charged price, list_price the price before discount_amount was taken off.
+-- discount_percent is kept for percentage discounts so that they can be applied again when the lines change.
 CREATE TABLE IF NOT EXISTS sales (
     id SERIAL PRIMARY KEY,
     product_name VARCHAR(255) NOT NULL,
-    category VARCHAR(100) NOT NULL,
-    currency VARCHAR(3) NOT NULL DEFAULT 'PLN',
+    category VARCHAR(100) NOT NULL REFERENCES categories(name) ON UPDATE CASCADE,
+    currency VARCHAR(3) NOT NULL DEFAULT 'PLN' REFERENCES currencies(code),
     price DECIMAL(10,2) NOT NULL CHECK (price > 0),
     sale_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    employee_id INTEGER REFERENCES employees(id) ON DELETE RESTRICT, -- NULL for the unassigned pool
+    product_id INTEGER REFERENCES products(id) ON DELETE SET NULL,
+    net_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    discount_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    discount_percent NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (discount_percent >= 0 AND discount_percent < 100),
+    promotion_id INTEGER REFERENCES promotions(id) ON DELETE SET NULL,
+    list_price DECIMAL(10,2) GENERATED ALWAYS AS (price + discount_amount) STORED,
+    customer_id INTEGER REFERENCES customers(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP WITH TIME ZONE -- set by a soft delete, the row is purged after the retention period
+);
+
+-- Line items of a sale, all lines are in the currency of their sale.
+-- line_total is the gross amount charged for the line after its share of the sale discount,
+-- it is split into net_amount and tax_amount at tax_rate.
+CREATE TABLE IF NOT EXISTS sale_items (
+    id SERIAL PRIMARY KEY,
+    sale_id INTEGER NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+    product_id INTEGER REFERENCES products(id) ON DELETE SET NULL,
+    product_name VARCHAR(255) NOT NULL,
+    category VARCHAR(100) NOT NULL REFERENCES categories(name) ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    unit_price DECIMAL(10,2) NOT NULL CHECK (unit_price > 0),
+    discount_amount DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (discount_amount >= 0),
+    line_total DECIMAL(12,2) GENERATED ALWAYS AS (quantity * unit_price - discount_amount) STORED,
+    tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (tax_rate BETWEEN 0 AND 100),
+    net_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    tax_amount DECIMAL(12,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Refunds of sales, in the currency of their sale. A sale stays as it was sold and its refunds
+-- are taken off revenue in the period of refund_date. All refunds of a sale add up to at most its price.
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    sale_id INTEGER NOT NULL REFERENCES sales(id) ON DELETE CASCADE,
+    amount DECIMAL(10,2) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL,
+    refund_date TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Sales quotas of employees for a month or a quarter starting on period_start.
+-- Attainment compares the net revenue of the period, converted to the target currency.
+CREATE TABLE IF NOT EXISTS sales_targets (
+    id SERIAL PRIMARY KEY,
     employee_id INTEGER NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    period_type VARCHAR(10) NOT NULL CHECK (period_type IN ('month', 'quarter')),
+    period_start DATE NOT NULL,
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'PLN' REFERENCES currencies(code),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (employee_id, period_type, period_start)
+);
+
+-- Commission plans. Rates are percentages of the net revenue (sales less refunds) in the plan currency.
+-- base_rate applies below the first tier, a tier rate applies to the revenue from its threshold up
+-- and a category rate replaces both for the revenue of its category.
+-- accelerator_rate is paid on top for the net revenue above the sales target of the period.
+CREATE TABLE IF NOT EXISTS commission_plans (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    currency VARCHAR(3) NOT NULL DEFAULT 'PLN' REFERENCES currencies(code),
+    base_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (base_rate >= 0 AND base_rate <= 100),
+    accelerator_rate NUMERIC(5,2) NOT NULL DEFAULT 0 CHECK (accelerator_rate >= 0 AND accelerator_rate <= 100),
     created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS commission_tiers (
+    id SERIAL PRIMARY KEY,
+    plan_id INTEGER NOT NULL REFERENCES commission_plans(id) ON DELETE CASCADE,
+    threshold DECIMAL(12,2) NOT NULL CHECK (threshold >= 0),
+    rate NUMERIC(5,2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    UNIQUE (plan_id, threshold)
+);
+
+CREATE TABLE IF NOT EXISTS commission_category_rates (
+    plan_id INTEGER NOT NULL REFERENCES commission_plans(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL REFERENCES categories(name) ON UPDATE CASCADE ON DELETE CASCADE,
+    rate NUMERIC(5,2) NOT NULL CHECK (rate >= 0 AND rate <= 100),
+    PRIMARY KEY (plan_id, category)
+);
+
+-- The plan of an employee from valid_from on, until the next assignment
+CREATE TABLE IF NOT EXISTS commission_assignments (
+    employee_id INTEGER NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    plan_id INTEGER NOT NULL REFERENCES commission_plans(id),
+    valid_from DATE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (employee_id, valid_from)
+);
+
+-- Offboardings of leavers: which of their sales went to whom, who did it and whether the employee
+-- was deactivated or deleted. The employee is copied since it may no longer exist.
+CREATE TABLE IF NOT EXISTS offboardings (
+    id SERIAL PRIMARY KEY,
+    employee_id INTEGER NOT NULL,
+    employee_name VARCHAR(100) NOT NULL,
+    employee_surname VARCHAR(100) NOT NULL,
+    employee_email VARCHAR(255) NOT NULL,
+    reassigned_to INTEGER REFERENCES employees(id) ON DELETE SET NULL, -- NULL for the unassigned pool
+    from_date DATE, -- the reassigned sales, NULL filters mean all of them
+    to_date DATE,
+    category VARCHAR(100),
+    customer_id INTEGER,
+    sales_reassigned INTEGER NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('deactivate', 'delete')),
+    performed_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Audit log of every change to employees and sales: who made it, in which request,
+-- and the record before and after as JSON. Entries are never changed or removed.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id SERIAL PRIMARY KEY,
+    entity VARCHAR(20) NOT NULL CHECK (entity IN ('employee', 'sale')),
+    entity_id INTEGER NOT NULL, -- no foreign key, entries outlive purged records
+    action VARCHAR(10) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore')),
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(100),
+    before JSONB, -- NULL for create
+    after JSONB, -- NULL for delete
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Exchange rates table
+-- One unit of from_currency is worth rate units of to_currency on rate_date
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    id SERIAL PRIMARY KEY,
+    from_currency VARCHAR(3) NOT NULL,
+    to_currency VARCHAR(3) NOT NULL,
+    rate_date DATE NOT NULL,
+    rate NUMERIC(18,8) NOT NULL CHECK (rate > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (from_currency, to_currency, rate_date)
+);
+
 -- Indexes
 CREATE INDEX IF NOT EXISTS idx_employees_email ON employees(email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_email_active ON employees(email) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_employees_deleted_at ON employees(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_sales_deleted_at ON sales(deleted_at) WHERE deleted_at IS NOT NULL;
 CREATE INDEX IF NOT EXISTS idx_sales_employee_id ON sales(employee_id);
 CREATE INDEX IF NOT EXISTS idx_sales_sale_date ON sales(sale_date);
 CREATE INDEX IF NOT EXISTS idx_sales_category ON sales(category);
+CREATE INDEX IF NOT EXISTS idx_sales_currency ON sales(currency);
+CREATE INDEX IF NOT EXISTS idx_sales_sale_date_id ON sales(sale_date, id);
+CREATE INDEX IF NOT EXISTS idx_sales_price_id ON sales(price, id);
+CREATE INDEX IF NOT EXISTS idx_sales_product_id ON sales(product_id);
+CREATE INDEX IF NOT EXISTS idx_products_category ON products(category);
+CREATE INDEX IF NOT EXISTS idx_sale_items_sale_id ON sale_items(sale_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_product_id ON sale_items(product_id);
+CREATE INDEX IF NOT EXISTS idx_sale_items_category ON sale_items(category);
+CREATE INDEX IF NOT EXISTS idx_sales_promotion_id ON sales(promotion_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_sale_id ON refunds(sale_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_refund_date ON refunds(refund_date);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tax_id ON customers(tax_id) WHERE tax_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_sales_customer_id ON sales(customer_id);
+CREATE INDEX IF NOT EXISTS idx_commission_assignments_plan_id ON commission_assignments(plan_id);
+CREATE INDEX IF NOT EXISTS idx_departments_parent_id ON departments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_employees_department_id ON employees(department_id);
+CREATE INDEX IF NOT EXISTS idx_employees_manager_id ON employees(manager_id);
+CREATE INDEX IF NOT EXISTS idx_employees_status ON employees(status);
+CREATE INDEX IF NOT EXISTS idx_offboardings_employee_id ON offboardings(employee_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id, id);
+
+-- Converts an amount using the latest rate known on the given date.
+-- Falls back to the inverse pair and returns NULL when no rate is known.
+CREATE OR REPLACE FUNCTION convert_amount(amount NUMERIC, from_code VARCHAR, to_code VARCHAR, at TIMESTAMP WITH TIME ZONE)
+RETURNS NUMERIC AS $$
+    SELECT CASE
+        WHEN from_code = to_code THEN amount
+        ELSE amount * COALESCE(
+            (SELECT rate FROM exchange_rates
+             WHERE from_currency = from_code AND to_currency = to_code AND rate_date <= at::date
+             ORDER BY rate_date DESC LIMIT 1),
+            (SELECT 1 / rate FROM exchange_rates
+             WHERE from_currency = to_code AND to_currency = from_code AND rate_date <= at::date
+             ORDER BY rate_date DESC LIMIT 1)
+        )
+    END
+$$ LANGUAGE sql STABLE;
+
+-- Employees of a department and of the teams below it
+CREATE OR REPLACE FUNCTION department_employees(root INTEGER)
+RETURNS SETOF INTEGER AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id FROM departments WHERE id = root
+        UNION
+        SELECT d.id FROM departments d JOIN tree t ON d.parent_id = t.id
+    )
+    SELECT e.id FROM employees e JOIN tree t ON e.department_id = t.id
+$$ LANGUAGE sql STABLE;
+
+-- A manager and everybody reporting to them, directly or through other managers
+CREATE OR REPLACE FUNCTION manager_subtree(root INTEGER)
+RETURNS SETOF INTEGER AS $$
+    WITH RECURSIVE tree AS (
+        SELECT id FROM employees WHERE id = root
+        UNION
+        SELECT e.id FROM employees e JOIN tree t ON e.manager_id = t.id
+    )
+    SELECT id FROM tree
+$$ LANGUAGE sql STABLE;
 
 -- Trigger to update the updated_at column on update
 CREATE OR REPLACE FUNCTION update_updated_at_column()
@@ -36,6 +346,11 @@ BEGIN
 END;
 $$ language 'plpgsql';
 
+CREATE TRIGGER update_departments_updated_at 
+    BEFORE UPDATE ON departments 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
 CREATE TRIGGER update_employees_updated_at 
     BEFORE UPDATE ON employees 
     FOR EACH ROW 
@@ -45,3 +360,56 @@ CREATE TRIGGER update_sales_updated_at
     BEFORE UPDATE ON sales 
     FOR EACH ROW 
     EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_currencies_updated_at 
+    BEFORE UPDATE ON currencies 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_exchange_rates_updated_at 
+    BEFORE UPDATE ON exchange_rates 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_categories_updated_at 
+    BEFORE UPDATE ON categories 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_products_updated_at 
+    BEFORE UPDATE ON products 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_promotions_updated_at 
+    BEFORE UPDATE ON promotions 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_customers_updated_at 
+    BEFORE UPDATE ON customers 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_sales_targets_updated_at 
+    BEFORE UPDATE ON sales_targets 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TRIGGER update_commission_plans_updated_at 
+    BEFORE UPDATE ON commission_plans 
+    FOR EACH ROW 
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- The audit log is append-only
+CREATE OR REPLACE FUNCTION audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only 
+    BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log 
+    FOR EACH STATEMENT 
+    EXECUTE FUNCTION audit_log_append_only();
@@ -1,14 +1,52 @@
 -- Test data for WorkRESTAPI
 -- Insert employees
-INSERT INTO employees (name, surname, email) VALUES 
-('Jan', 'Kowalski', 'jan.kowalski@firma.pl'),
-('Anna', 'Nowak', 'anna.nowak@firma.pl'),
-('Piotr', 'Wiśniewski', 'piotr.wisniewski@firma.pl'),
-('Maria', 'Wójcik', 'maria.wojcik@firma.pl'),
-('Tomasz', 'Kowalczyk', 'tomasz.kowalczyk@firma.pl'),
-('Katarzyna', 'Kamińska', 'katarzyna.kaminska@firma.pl'),
-('Michał', 'Lewandowski', 'michal.lewandowski@firma.pl'),
-('Magdalena', 'Zielińska', 'magdalena.zielinska@firma.pl');
+INSERT INTO employees (name, surname, email, hire_date, job_title) VALUES 
+('Jan', 'Kowalski', 'jan.kowalski@firma.pl', '2020-03-01', 'Head of Sales'),
+('Anna', 'Nowak', 'anna.nowak@firma.pl', '2021-06-01', 'Team Lead'),
+('Piotr', 'Wiśniewski', 'piotr.wisniewski@firma.pl', '2022-01-10', 'Sales Representative'),
+('Maria', 'Wójcik', 'maria.wojcik@firma.pl', '2021-09-01', 'Team Lead'),
+('Tomasz', 'Kowalczyk', 'tomasz.kowalczyk@firma.pl', '2023-02-01', 'Sales Representative'),
+('Katarzyna', 'Kamińska', 'katarzyna.kaminska@firma.pl', '2023-05-15', 'Sales Representative'),
+('Michał', 'Lewandowski', 'michal.lewandowski@firma.pl', '2024-04-01', 'Junior Sales Representative'),
+('Magdalena', 'Zielińska', 'magdalena.zielinska@firma.pl', '2024-09-01', 'Junior Sales Representative');
+
+-- A leaver, kept for the history but no longer able to make sales
+INSERT INTO employees (name, surname, email, hire_date, termination_date, job_title, status) VALUES 
+('Paweł', 'Dąbrowski', 'pawel.dabrowski@firma.pl', '2022-03-01', '2024-11-30', 'Sales Representative', 'inactive');
+
+-- Sales department with two teams, Jan Kowalski leads it and Anna Nowak and Maria Wójcik lead the teams
+INSERT INTO departments (name, description) VALUES 
+('Sales', 'Sales department')
+ON CONFLICT (name) DO NOTHING;
+INSERT INTO departments (name, description, parent_id) 
+SELECT t.name, t.description, d.id FROM departments d, (VALUES 
+    ('Sales Warsaw', 'Team for Warsaw and Mazovia'), 
+    ('Sales Kraków', 'Team for southern Poland')
+) AS t(name, description) 
+WHERE d.name = 'Sales'
+ON CONFLICT (name) DO NOTHING;
+
+UPDATE employees SET department_id = (SELECT id FROM departments WHERE name = 'Sales') 
+WHERE email = 'jan.kowalski@firma.pl';
+UPDATE employees SET department_id = (SELECT id FROM departments WHERE name = 'Sales Warsaw'), 
+    manager_id = (SELECT id FROM employees WHERE email = 'jan.kowalski@firma.pl') 
+WHERE email = 'anna.nowak@firma.pl';
+UPDATE employees SET department_id = (SELECT id FROM departments WHERE name = 'Sales Kraków'), 
+    manager_id = (SELECT id FROM employees WHERE email = 'jan.kowalski@firma.pl') 
+WHERE email = 'maria.wojcik@firma.pl';
+UPDATE employees SET department_id = (SELECT id FROM departments WHERE name = 'Sales Warsaw'), 
+    manager_id = (SELECT id FROM employees WHERE email = 'anna.nowak@firma.pl') 
+WHERE email IN ('piotr.wisniewski@firma.pl', 'tomasz.kowalczyk@firma.pl');
+UPDATE employees SET department_id = (SELECT id FROM departments WHERE name = 'Sales Kraków'), 
+    manager_id = (SELECT id FROM employees WHERE email = 'maria.wojcik@firma.pl') 
+WHERE email IN ('katarzyna.kaminska@firma.pl', 'michal.lewandowski@firma.pl', 'magdalena.zielinska@firma.pl');
+
+-- Insert categories
+INSERT INTO categories (name, description) VALUES 
+('Electronics', 'Laptops, phones and accessories'),
+('Furniture', 'Office furniture'),
+('Software', 'Licenses and subscriptions')
+ON CONFLICT (name) DO NOTHING;
 
 -- Insert sales with various dates from different months and quarters
 INSERT INTO sales (product_name, category, currency, price, sale_date, employee_id) VALUES 
@@ -70,3 +108,110 @@ INSERT INTO sales (product_name, category, currency, price, sale_date, employee_
 ('Software licencja', 'Software', 'EUR', 299.99, '2025-01-30 12:00:00+01:00', 8),
 ('Antywirus premium', 'Software', 'USD', 89.99, '2025-03-12 15:30:00+01:00', 1),
 ('Office 365', 'Software', 'PLN', 449.00, '2025-06-08 11:15:00+02:00', 3);
+
+-- Insert catalog products and link the sales of them
+INSERT INTO products (sku, name, category, price, currency) VALUES 
+('DELL-XPS13', 'Laptop Dell XPS 13', 'Electronics', 4500.00, 'PLN'),
+('APPLE-IP15', 'iPhone 15', 'Electronics', 3999.99, 'PLN'),
+('APPLE-MBP', 'MacBook Pro', 'Electronics', 8999.00, 'PLN'),
+('SONY-WH1000', 'Słuchawki Sony', 'Electronics', 799.00, 'PLN'),
+('MS-O365', 'Office 365', 'Software', 449.00, 'PLN')
+ON CONFLICT (sku) DO NOTHING;
+
+UPDATE sales s SET product_id = p.id 
+FROM products p 
+WHERE s.product_name = p.name AND s.product_id IS NULL;
+
+-- Every sale above is an order with a single line
+INSERT INTO sale_items (sale_id, product_id, product_name, category, quantity, unit_price) 
+SELECT id, product_id, product_name, category, 1, price 
+FROM sales s 
+WHERE NOT EXISTS (SELECT 1 FROM sale_items i WHERE i.sale_id = s.id);
+
+-- An order with several lines: 2 x iPhone 15 and 2 x Office 365
+WITH sale AS (
+    INSERT INTO sales (product_name, category, currency, price, sale_date, employee_id, product_id) 
+    SELECT 'iPhone 15 (+1 more)', 'Electronics', 'PLN', 8897.98, '2025-06-20 09:45:00+02:00', 2, id 
+    FROM products WHERE sku = 'APPLE-IP15' 
+    RETURNING id
+)
+INSERT INTO sale_items (sale_id, product_id, product_name, category, quantity, unit_price) 
+SELECT sale.id, p.id, p.name, p.category, 2, p.price 
+FROM sale, products p 
+WHERE p.sku IN ('APPLE-IP15', 'MS-O365');
+
+-- Prices are gross and taxed at the rate of their category
+UPDATE sale_items i 
+SET tax_rate = c.tax_rate, 
+    net_amount = ROUND(i.line_total * 100 / (100 + c.tax_rate), 2), 
+    tax_amount = i.line_total - ROUND(i.line_total * 100 / (100 + c.tax_rate), 2) 
+FROM categories c 
+WHERE c.name = i.category;
+
+UPDATE sales s 
+SET net_amount = t.net_amount, tax_amount = t.tax_amount 
+FROM (SELECT sale_id, SUM(net_amount) as net_amount, SUM(tax_amount) as tax_amount FROM sale_items GROUP BY sale_id) t 
+WHERE t.sale_id = s.id;
+
+-- A January sale partly refunded in February
+INSERT INTO refunds (sale_id, amount, reason, refund_date) 
+SELECT id, 200.00, 'Damaged packaging', '2025-02-05 11:00:00+01:00' 
+FROM sales 
+WHERE product_name = 'Samsung Galaxy S24' AND sale_date = '2025-01-25 16:45:00+01:00';
+
+-- Promotion codes
+INSERT INTO promotions (code, description, discount_percent, discount_amount, currency, valid_from, valid_to) VALUES 
+('SUMMER10', '10% off in summer 2025', 10, 0, NULL, '2025-06-01', '2025-08-31'),
+('WELCOME100', '100 zł off an order', 0, 100, 'PLN', NULL, NULL)
+ON CONFLICT (code) DO NOTHING;
+
+-- Customers, two companies with a NIP and a private buyer
+INSERT INTO customers (name, company, tax_id, email, address) VALUES 
+('Marek Zieliński', 'Zieliński IT Sp. z o.o.', '5260250274', 'zakupy@zielinski-it.pl', 'ul. Marszałkowska 10, 00-590 Warszawa'),
+('Ewa Kowalczyk', 'Biuro Rachunkowe Kowalczyk', '1130001116', 'ewa@kowalczyk-biuro.pl', 'ul. Piotrkowska 45, 90-001 Łódź'),
+('Tomasz Nowicki', NULL, NULL, 'tomasz.nowicki@gmail.com', NULL)
+ON CONFLICT (tax_id) WHERE tax_id IS NOT NULL DO NOTHING;
+
+-- The laptops and the order of several lines were bought by the company customers
+UPDATE sales SET customer_id = (SELECT id FROM customers WHERE tax_id = '5260250274') 
+WHERE product_name IN ('Laptop Dell XPS 13', 'MacBook Pro');
+UPDATE sales SET customer_id = (SELECT id FROM customers WHERE tax_id = '1130001116') 
+WHERE product_name LIKE 'iPhone 15 (+%' OR product_name = 'Samsung Galaxy S24';
+
+-- Sales targets of the first employees for January and Q1 2025
+INSERT INTO sales_targets (employee_id, period_type, period_start, amount, currency) VALUES 
+(1, 'month', '2025-01-01', 15000.00, 'PLN'),
+(2, 'month', '2025-01-01', 5000.00, 'PLN'),
+(1, 'quarter', '2025-01-01', 40000.00, 'PLN'),
+(2, 'quarter', '2025-01-01', 12000.00, 'PLN')
+ON CONFLICT (employee_id, period_type, period_start) DO NOTHING;
+
+-- Commission plan of the sales team, assigned to the first employees from January 2025
+INSERT INTO commission_plans (name, description, currency, base_rate, accelerator_rate) VALUES 
+('Sales 2025', 'Tiered plan with a higher rate for software', 'PLN', 2.00, 1.50)
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO commission_tiers (plan_id, threshold, rate) 
+SELECT id, t.threshold, t.rate FROM commission_plans, (VALUES (10000.00, 3.00), (30000.00, 4.50)) AS t(threshold, rate) 
+WHERE name = 'Sales 2025'
+ON CONFLICT (plan_id, threshold) DO NOTHING;
+
+INSERT INTO commission_category_rates (plan_id, category, rate) 
+SELECT id, 'Software', 8.00 FROM commission_plans WHERE name = 'Sales 2025'
+ON CONFLICT (plan_id, category) DO NOTHING;
+
+INSERT INTO commission_assignments (employee_id, plan_id, valid_from) 
+SELECT e.id, p.id, '2025-01-01' FROM commission_plans p, (VALUES (1), (2)) AS e(id) 
+WHERE p.name = 'Sales 2025'
+ON CONFLICT (employee_id, valid_from) DO NOTHING;
+
+-- Exchange rates to PLN used to compute aggregates in the base currency
+INSERT INTO exchange_rates (from_currency, to_currency, rate_date, rate) VALUES 
+('EUR', 'PLN', '2024-10-01', 4.2846),
+('USD', 'PLN', '2024-10-01', 3.8484),
+('EUR', 'PLN', '2025-01-02', 4.2718),
+('USD', 'PLN', '2025-01-02', 4.1219),
+('EUR', 'PLN', '2025-03-03', 4.1522),
+('USD', 'PLN', '2025-03-03', 3.9868),
+('EUR', 'PLN', '2025-06-02', 4.2762),
+('USD', 'PLN', '2025-06-02', 3.7487);